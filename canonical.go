@@ -0,0 +1,196 @@
+package jce
+
+import (
+	"bytes"
+	"math"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// 规范编码
+// 同一个值在 go 中可能编码出不同的字节（比如 map 的遍历顺序是随机的），
+// 规范编码保证相同的值一定得到相同的字节，用于内容寻址的缓存、签名校验等场景：
+//   1. 整数使用最小的宽度，0 使用 Zero（Encoder 默认就是这样）
+//   2. 浮点数 0、-0 使用 Zero，NaN 统一为同一个编码
+//   3. map 按 key 编码后的字节从小到大排列
+//   4. struct 的字段按 tag 从小到大排列
+// ---------------------------------------------------------------------------
+
+// 规范的 NaN 编码
+const (
+	canonicalNaN32 uint32 = 0x7fc00000
+	canonicalNaN64 uint64 = 0x7ff8000000000000
+)
+
+// 开启或者关闭规范编码，开启后 WriteMap 会对 key 排序，NaN 会被统一
+// 通过 NewEncoder(e) 创建的子 Encoder 会继承这个开关
+func (e *Encoder) SetCanonical(on bool) {
+	e.canonical = on
+}
+
+// 把任意合法的编码数据转换为规范编码
+func Canonicalize(data []byte) (out []byte, err error) {
+	// [step 1] 解析
+	v, err := DecodeValue(data)
+	if err != nil {
+		return
+	}
+
+	// [step 2] 规范化
+	if v, err = canonicalValue(v); err != nil {
+		return
+	}
+
+	// [step 3] 使用规范模式编码
+	return encodeFields(v, true, len(data))
+}
+
+// 编码 struct 值 v 的所有字段，不包括 StructBegin、StructEnd，size 为预估的大小
+func encodeFields(v Value, canonical bool, size int) (data []byte, err error) {
+	b := bytes.NewBuffer(make([]byte, 0, size))
+	e := NewEncoder(b)
+	e.SetCanonical(canonical)
+	for _, field := range v.Fields {
+		if err = e.writeValue(field.Value, field.Tag); err != nil {
+			return
+		}
+	}
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// 返回 v 的规范形式，不会修改 v
+func canonicalValue(v Value) (c Value, err error) {
+	return compactValue(v, true)
+}
+
+// 按 Encoder 的压缩规则返回 v 的紧凑形式，不会修改 v
+// canonical 为 true 时还会对 map 的 key、struct 的字段排序
+func compactValue(v Value, canonical bool) (c Value, err error) {
+	switch v.Type {
+	// [step 1] 整数使用最小的宽度
+	case Int1, Int2, Int4, Int8:
+		return intValueOf(v.Int), nil
+
+	// [step 2] 浮点数 0 使用 Zero，NaN 在规范模式编码时统一
+	case Float4, Float8:
+		if v.Float == 0 {
+			return Value{Type: Zero}, nil
+		}
+		return v, nil
+
+	// [step 3] list 逐个处理，不改变顺序
+	case List:
+		c = Value{Type: List, List: make([]Value, len(v.List))}
+		for i, item := range v.List {
+			if c.List[i], err = compactValue(item, canonical); err != nil {
+				return
+			}
+		}
+		return
+
+	// [step 4] map 逐个处理，规范模式下按 key 编码后的字节排序
+	case Map:
+		type entry struct {
+			key []byte
+			MapEntry
+		}
+		entries := make([]entry, len(v.Map))
+		for i, item := range v.Map {
+			if entries[i].Key, err = compactValue(item.Key, canonical); err != nil {
+				return
+			}
+			if entries[i].Value, err = compactValue(item.Value, canonical); err != nil {
+				return
+			}
+			if !canonical {
+				continue
+			}
+			if entries[i].key, err = encodeCanonicalItem(entries[i].Key); err != nil {
+				return
+			}
+		}
+		if canonical {
+			sort.SliceStable(entries, func(i, j int) bool {
+				return bytes.Compare(entries[i].key, entries[j].key) < 0
+			})
+		}
+
+		c = Value{Type: Map, Map: make([]MapEntry, len(entries))}
+		for i := range entries {
+			c.Map[i] = entries[i].MapEntry
+		}
+		return
+
+	// [step 5] struct 逐个处理，规范模式下按 tag 排序
+	case StructBegin:
+		c = Value{Type: StructBegin, Fields: make([]Field, len(v.Fields))}
+		for i, field := range v.Fields {
+			c.Fields[i].Tag = field.Tag
+			if c.Fields[i].Value, err = compactValue(field.Value, canonical); err != nil {
+				return
+			}
+		}
+		if canonical {
+			sort.SliceStable(c.Fields, func(i, j int) bool {
+				return c.Fields[i].Tag < c.Fields[j].Tag
+			})
+		}
+		return
+
+	default:
+		return v, nil
+	}
+}
+
+// 按 writeInt* 的规则，返回能表示 u 的最小宽度的整数
+func intValueOf(u uint64) Value {
+	switch {
+	case u == 0:
+		return Value{Type: Zero}
+	case u <= math.MaxUint8:
+		return Value{Type: Int1, Int: u}
+	case u <= math.MaxUint16:
+		return Value{Type: Int2, Int: u}
+	case u <= math.MaxUint32:
+		return Value{Type: Int4, Int: u}
+	default:
+		return Value{Type: Int8, Int: u}
+	}
+}
+
+// 以 tag 0 规范编码 list、map 中的一个元素
+func encodeCanonicalItem(v Value) (item []byte, err error) {
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+	e.SetCanonical(true)
+	if err = e.writeValue(v, 0); err != nil {
+		return
+	}
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// float32 的编码，规范模式下 NaN 统一为同一个编码
+//
+//go:nosplit
+func (e *Encoder) float32bits(f float32) uint32 {
+	if e.canonical && f != f {
+		return canonicalNaN32
+	}
+	return math.Float32bits(f)
+}
+
+// float64 的编码，规范模式下 NaN 统一为同一个编码
+//
+//go:nosplit
+func (e *Encoder) float64bits(f float64) uint64 {
+	if e.canonical && f != f {
+		return canonicalNaN64
+	}
+	return math.Float64bits(f)
+}
@@ -0,0 +1,159 @@
+package jce
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWriteReadMap(t *testing.T) {
+	want := map[string]int32{"b": 2, "a": 1, "ccc": -1, "dd": 70000}
+
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	if err := WriteMap(e, want, 3, (*Encoder).WriteString, (*Encoder).WriteInt32); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]int32
+	d := NewDecoder(data)
+	if err := ReadMap(d, &got, 3, true, (*Decoder).ReadString, (*Decoder).ReadInt32); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("map round trip failed, want:%v, got:%v", want, got)
+	}
+}
+
+func TestWriteMapCanonical(t *testing.T) {
+	encode := func(m map[int64]string) []byte {
+		data := bytes.NewBuffer(make([]byte, 0))
+		e := NewEncoder(data)
+		e.SetCanonical(true)
+		if err := WriteMap(e, m, 0, (*Encoder).WriteInt64, (*Encoder).WriteString); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return data.Bytes()
+	}
+
+	m := map[int64]string{}
+	for i := int64(-50); i < 50; i++ {
+		m[i*1000] = "v"
+	}
+
+	// 多次编码，map 的遍历顺序不同，结果都一样
+	want := encode(m)
+	for i := 0; i < 20; i++ {
+		if got := encode(m); !bytes.Equal(got, want) {
+			t.Fatal("canonical map encoding not deterministic")
+		}
+	}
+
+	// key 按编码后的字节排序
+	v, err := Extract(want, MustParsePath("0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev []byte
+	for _, entry := range v.Map {
+		key, err := encodeCanonicalItem(entry.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Errorf("map keys not sorted, %v >= %v", prev, key)
+		}
+		prev = key
+	}
+}
+
+func TestCanonicalNaN(t *testing.T) {
+	nan := math.Float64frombits(0x7ff8000000000123)
+
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	e.SetCanonical(true)
+	if err := e.WriteFloat64(nan, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteFloat32(float32(math.Inf(1))-float32(math.Inf(1)), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteFloat64(math.Copysign(0, -1), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{0x50, 0x7f, 0xf8, 0, 0, 0, 0, 0, 0, 0x41, 0x7f, 0xc0, 0, 0, 0x62}
+	if !bytes.Equal(data.Bytes(), want) {
+		t.Errorf("canonical float failed\nwant:%x\ngot: %x", want, data.Bytes())
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	// 同一个逻辑值的两种不同编码
+	a := mustEncodeValue(t, structValue(
+		Field{Tag: 3, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("b"), Value: Value{Type: Int8, Int: 2}},
+			{Key: stringValue("a"), Value: Value{Type: Float8, Float: 0}},
+		}}},
+		Field{Tag: 1, Value: Value{Type: Int4, Int: 0}},
+		Field{Tag: 2, Value: Value{Type: List, List: []Value{
+			{Type: Float8, Float: math.Float64frombits(0x7ff8000000000123)},
+			{Type: Int2, Int: 300},
+		}}},
+	))
+	b := mustEncodeValue(t, structValue(
+		Field{Tag: 1, Value: Value{Type: Zero}},
+		Field{Tag: 2, Value: Value{Type: List, List: []Value{
+			{Type: Float8, Float: math.NaN()},
+			{Type: Int4, Int: 300},
+		}}},
+		Field{Tag: 3, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("a"), Value: Value{Type: Int1, Int: 0}},
+			{Key: stringValue("b"), Value: Value{Type: Int1, Int: 2}},
+		}}},
+	))
+	if bytes.Equal(a, b) {
+		t.Fatal("test data should differ")
+	}
+
+	ca, err := Canonicalize(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := Canonicalize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ca, cb) {
+		t.Errorf("canonicalize failed\na:%x\nb:%x", ca, cb)
+	}
+
+	// 幂等
+	cc, err := Canonicalize(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cc, ca) {
+		t.Errorf("canonicalize not idempotent\nwant:%x\ngot: %x", ca, cc)
+	}
+
+	v, err := DecodeValue(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{1: Zero, 2: [Float8(NaN), Int2(300)], 3: map["a": Zero, "b": Int1(2)]}`
+	if v.String() != want {
+		t.Errorf("canonical value failed\nwant:%s\ngot: %s", want, v)
+	}
+}
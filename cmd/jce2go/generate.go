@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/idl"
+	"github.com/erpc-go/jce-codec/internal/codegen"
+)
+
+// ---------------------------------------------------------------------------
+// IDL -> go
+// 每个 module 生成一个 go 包，包名为 module 名字的小写；名字的首字母改为大写：
+//   - enum：type Color int32，成员为常量 Color_RED，按 int32 读写
+//   - const：同名的常量
+//   - struct：同名的 struct 以及 ResetDefault、WriteTo、ReadFrom 方法，字段带有 jce、json 的 tag
+//   - key、interface 不生成代码
+// ---------------------------------------------------------------------------
+
+// output 生成的一个 go 文件，Path 为相对于输出目录的路径
+type output struct {
+	Path string
+	Src  []byte
+}
+
+type generator struct {
+	importPrefix string                         // module 对应的包的 import 路径前缀，引用其他 module 时需要
+	decls        map[string]map[string]idl.Decl // module -> 名字 -> *Struct、*Enum、*Const
+}
+
+// files 为所有加载的文件，包括 include 的文件，用于查找其他文件中定义的类型
+func newGenerator(files []*idl.File, importPrefix string) (g *generator, err error) {
+	g = &generator{importPrefix: strings.TrimSuffix(importPrefix, "/"), decls: map[string]map[string]idl.Decl{}}
+	for _, f := range files {
+		for _, m := range f.Modules {
+			if g.decls[m.Name] == nil {
+				g.decls[m.Name] = map[string]idl.Decl{}
+			}
+			for _, decl := range m.Decls {
+				if _, ok := decl.(*idl.Key); ok {
+					continue
+				}
+				if _, ok := decl.(*idl.Interface); ok {
+					continue
+				}
+				if prev, ok := g.decls[m.Name][decl.DeclName()]; ok && prev != decl {
+					return nil, &idl.Error{Pos: decl.Position(), Msg: fmt.Sprintf("%s redeclared, previous declaration at %s", decl.DeclName(), prev.Position())}
+				}
+				g.decls[m.Name][decl.DeclName()] = decl
+			}
+		}
+	}
+	return
+}
+
+// module 对应的 go 包名
+func packageName(module string) string {
+	return strings.ToLower(module)
+}
+
+// IDL 中的名字对应的 go 名字，首字母大写
+func goName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// 生成 f 中每个 module 的代码，路径为 包名/文件名.go
+func (g *generator) generate(f *idl.File) (outs []output, err error) {
+	base := strings.TrimSuffix(filepath.Base(f.Name), filepath.Ext(f.Name))
+	for _, m := range f.Modules {
+		mg := &moduleGenerator{generator: g, file: f, module: m}
+		mg.out.Package = packageName(m.Name)
+		mg.out.Header = "Code generated by jce2go. DO NOT EDIT.\nsource: " + filepath.Base(f.Name)
+		if err = mg.generate(); err != nil {
+			return
+		}
+		src, err := mg.out.Source()
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, output{Path: path.Join(mg.out.Package, base+".go"), Src: src})
+	}
+	return
+}
+
+// 生成一个 module 的代码
+type moduleGenerator struct {
+	*generator
+	file   *idl.File
+	module *idl.Module
+	out    codegen.File
+
+	codeLines map[int]int // 每一行中第一个节点的偏移，用于区分行尾注释
+}
+
+func (g *moduleGenerator) errorf(pos idl.Pos, format string, args ...any) error {
+	return &idl.Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (g *moduleGenerator) generate() (err error) {
+	for _, decl := range g.module.Decls {
+		switch decl := decl.(type) {
+		case *idl.Enum:
+			err = g.enum(decl)
+		case *idl.Const:
+			err = g.constant(decl)
+		case *idl.Struct:
+			err = g.structure(decl)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 输出 pos 前面紧挨着的注释，作为 go 的文档注释，跟在代码后面的行尾注释除外
+func (g *moduleGenerator) doc(pos idl.Pos) {
+	var lines []string
+	line := pos.Line
+	for i := len(g.file.Comments) - 1; i >= 0; i-- {
+		c := g.file.Comments[i]
+		if c.Pos.Offset >= pos.Offset || c.End.Line >= line {
+			continue
+		}
+		if c.End.Line != line-1 || g.trailing(c) {
+			break
+		}
+		lines = append(commentLines(c.Text), lines...)
+		line = c.Pos.Line
+	}
+	for _, l := range lines {
+		g.out.Printf("%s\n", l)
+	}
+}
+
+// 注释前面同一行中是否有代码
+func (g *moduleGenerator) trailing(c *idl.CommentLine) bool {
+	if g.codeLines == nil {
+		g.codeLines = map[int]int{}
+		add := func(p idl.Pos) {
+			if off, ok := g.codeLines[p.Line]; !ok || p.Offset < off {
+				g.codeLines[p.Line] = p.Offset
+			}
+		}
+		for _, inc := range g.file.Includes {
+			add(inc.Pos)
+		}
+		for _, m := range g.file.Modules {
+			add(m.Pos)
+			add(m.End)
+			for _, decl := range m.Decls {
+				add(decl.Position())
+				switch decl := decl.(type) {
+				case *idl.Struct:
+					add(decl.End)
+					for _, f := range decl.Fields {
+						add(f.Pos)
+					}
+				case *idl.Enum:
+					add(decl.End)
+					for _, member := range decl.Members {
+						add(member.Pos)
+					}
+				case *idl.Interface:
+					add(decl.End)
+					for _, method := range decl.Methods {
+						add(method.Pos)
+					}
+				}
+			}
+		}
+	}
+	off, ok := g.codeLines[c.Pos.Line]
+	return ok && off < c.Pos.Offset
+}
+
+// 把注释转换为 // 开头的行
+func commentLines(text string) (lines []string) {
+	if strings.HasPrefix(text, "//") {
+		return []string{text}
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimPrefix(strings.TrimSpace(l), "*")
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, "// "+l)
+		}
+	}
+	return
+}
+
+// 和 pos 同一行、在 pos 之后的注释，作为行尾注释
+func (g *moduleGenerator) lineComment(pos idl.Pos) string {
+	for _, c := range g.file.Comments {
+		if c.Pos.Line == pos.Line && c.Pos.Offset > pos.Offset && c.End.Line == pos.Line {
+			return " " + commentLines(c.Text)[0]
+		}
+	}
+	return ""
+}
+
+// ---------------------------------------------------------------------------
+// 声明
+// ---------------------------------------------------------------------------
+
+func (g *moduleGenerator) enum(e *idl.Enum) (err error) {
+	values, err := e.Values()
+	if err != nil {
+		return
+	}
+	name := goName(e.Name)
+
+	// [step 1] 类型和常量
+	g.out.Printf("\n")
+	g.doc(e.Pos)
+	g.out.Printf("type %s int32\n\n", name)
+	g.out.Printf("const (\n")
+	for i, m := range e.Members {
+		g.doc(m.Pos)
+		g.out.Printf("%s_%s %s = %d%s\n", name, m.Name, name, values[i], g.lineComment(m.Pos))
+	}
+	g.out.Printf(")\n")
+
+	// [step 2] String，值重复时使用第一个名字
+	g.out.Import("strconv")
+	g.out.Printf("\nfunc (x %s) String() string {\n", name)
+	g.out.Printf("switch x {\n")
+	seen := map[int64]bool{}
+	for i, m := range e.Members {
+		if seen[values[i]] {
+			continue
+		}
+		seen[values[i]] = true
+		g.out.Printf("case %s_%s:\nreturn %q\n", name, m.Name, m.Name)
+	}
+	g.out.Printf("}\n")
+	g.out.Printf("return %q + strconv.FormatInt(int64(x), 10) + \")\"\n", name+"(")
+	g.out.Printf("}\n")
+	return
+}
+
+func (g *moduleGenerator) constant(c *idl.Const) (err error) {
+	if !c.Type.IsBasic() {
+		return g.errorf(c.Type.Pos, "const %s must have a basic type, found %s", c.Name, c.Type)
+	}
+	t, err := g.goType(c.Type)
+	if err != nil {
+		return
+	}
+	value, err := g.literal(c.Value, c.Type, t)
+	if err != nil {
+		return
+	}
+	g.out.Printf("\n")
+	g.doc(c.Pos)
+	g.out.Printf("const %s %s = %s%s\n", goName(c.Name), t.GoType, value, g.lineComment(c.Pos))
+	return
+}
+
+// go 的方法名，字段不能使用
+var reservedFields = map[string]bool{"ResetDefault": true, "WriteTo": true, "ReadFrom": true}
+
+func (g *moduleGenerator) structure(s *idl.Struct) (err error) {
+	// [step 1] 检查字段，转换为 codegen 的模型
+	msg := &codegen.Message{Name: goName(s.Name)}
+	tags := map[int]*idl.Field{}
+	names := map[string]*idl.Field{}
+	for _, f := range s.Fields {
+		if f.Tag < 0 || f.Tag > 255 {
+			return g.errorf(f.TagPos, "tag %d of %s.%s out of range [0, 255]", f.Tag, s.Name, f.Name)
+		}
+		if prev, ok := tags[f.Tag]; ok {
+			return g.errorf(f.TagPos, "duplicate tag %d in %s, used by %s", f.Tag, s.Name, prev.Name)
+		}
+		tags[f.Tag] = f
+
+		field := &codegen.Field{Name: goName(f.Name), Wire: f.Name, Tag: byte(f.Tag), Require: f.Require}
+		if reservedFields[field.Name] {
+			return g.errorf(f.Pos, "field %s.%s conflicts with method %s", s.Name, f.Name, field.Name)
+		}
+		if prev, ok := names[field.Name]; ok {
+			return g.errorf(f.Pos, "field %s.%s conflicts with %s, both are %s in go", s.Name, f.Name, prev.Name, field.Name)
+		}
+		names[field.Name] = f
+
+		if field.Type, err = g.goType(f.Type); err != nil {
+			return
+		}
+		if f.Default != nil {
+			if field.Default, err = g.literal(f.Default, f.Type, field.Type); err != nil {
+				return
+			}
+		}
+		msg.Fields = append(msg.Fields, field)
+	}
+
+	// [step 2] struct 的定义
+	g.out.Printf("\n")
+	g.doc(s.Pos)
+	g.out.Printf("type %s struct {\n", msg.Name)
+	for i, f := range s.Fields {
+		field := msg.Fields[i]
+		g.doc(f.Pos)
+		option := "optional"
+		if f.Require {
+			option = "required"
+		}
+		g.out.Printf("%s %s `jce:\"%d,%s\" json:\"%s\"`%s\n", field.Name, field.Type.GoType, f.Tag, option, f.Name, g.lineComment(f.Pos))
+	}
+	g.out.Printf("}\n")
+
+	// [step 3] 方法
+	g.out.Methods(msg)
+	return
+}
+
+// ---------------------------------------------------------------------------
+// 类型
+// ---------------------------------------------------------------------------
+
+// 查找 struct、enum，返回所在的 module
+func (g *moduleGenerator) lookup(t *idl.Type) (decl idl.Decl, module string, err error) {
+	module = t.Module
+	if module == "" {
+		module = g.module.Name
+	}
+	decl = g.decls[module][t.Name]
+	switch decl.(type) {
+	case *idl.Struct, *idl.Enum:
+		return
+	}
+	return nil, "", g.errorf(t.Pos, "undefined type %s", t)
+}
+
+// 其他 module 中的名字需要加上包名，并 import 对应的包
+func (g *moduleGenerator) qualify(module, name string) (string, error) {
+	if module == g.module.Name {
+		return name, nil
+	}
+	if g.importPrefix == "" {
+		return "", fmt.Errorf("module %s is referenced from %s, -import-prefix is required", module, g.module.Name)
+	}
+	pkg := packageName(module)
+	g.out.Import(g.importPrefix + "/" + pkg)
+	return pkg + "." + name, nil
+}
+
+func (g *moduleGenerator) goType(t *idl.Type) (ct *codegen.Type, err error) {
+	switch t.Kind {
+	case idl.TypeBool:
+		return codegen.Basic(codegen.Bool, ""), nil
+	case idl.TypeByte:
+		return unsigned(t, codegen.Int8, codegen.Uint8), nil
+	case idl.TypeShort:
+		return unsigned(t, codegen.Int16, codegen.Uint16), nil
+	case idl.TypeInt:
+		return unsigned(t, codegen.Int32, codegen.Uint32), nil
+	case idl.TypeLong:
+		return unsigned(t, codegen.Int64, codegen.Uint64), nil
+	case idl.TypeFloat:
+		return codegen.Basic(codegen.Float32, ""), nil
+	case idl.TypeDouble:
+		return codegen.Basic(codegen.Float64, ""), nil
+	case idl.TypeString:
+		return codegen.Basic(codegen.String, ""), nil
+
+	case idl.TypeVector:
+		// vector<byte> 为 SimpleList
+		if t.Elem.Kind == idl.TypeByte {
+			return codegen.Basic(codegen.Bytes, ""), nil
+		}
+		elem, err := g.goType(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &codegen.Type{Kind: codegen.Slice, GoType: "[]" + elem.GoType, Elem: elem}, nil
+
+	case idl.TypeMap:
+		// go 的 map key 需要可以比较，只支持基础类型和 enum
+		key, err := g.goType(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !key.Kind.IsBasic() || key.Kind == codegen.Bytes {
+			return nil, g.errorf(t.Key.Pos, "unsupported map key type %s", t.Key)
+		}
+		elem, err := g.goType(t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return &codegen.Type{Kind: codegen.Map, GoType: "map[" + key.GoType + "]" + elem.GoType, Key: key, Elem: elem}, nil
+
+	default:
+		decl, module, err := g.lookup(t)
+		if err != nil {
+			return nil, err
+		}
+		name, err := g.qualify(module, goName(t.Name))
+		if err != nil {
+			return nil, g.errorf(t.Pos, "%s", err)
+		}
+		if _, ok := decl.(*idl.Enum); ok {
+			return codegen.Basic(codegen.Int32, name), nil
+		}
+		return &codegen.Type{Kind: codegen.Struct, GoType: name}, nil
+	}
+}
+
+func unsigned(t *idl.Type, signed, unsigned codegen.Kind) *codegen.Type {
+	if t.Unsigned {
+		return codegen.Basic(unsigned, "")
+	}
+	return codegen.Basic(signed, "")
+}
+
+// ---------------------------------------------------------------------------
+// 默认值、常量的值
+// ---------------------------------------------------------------------------
+
+// 把字面量转换为 t 类型的 go 表达式
+func (g *moduleGenerator) literal(l *idl.Literal, it *idl.Type, t *codegen.Type) (value string, err error) {
+	invalid := func() (string, error) {
+		return "", g.errorf(l.Pos, "invalid value %s for type %s", l.Text, it)
+	}
+
+	// [step 1] enum，值为成员的名字或者整数
+	if it.Kind == idl.TypeNamed {
+		decl, module, err := g.lookup(it)
+		if err != nil {
+			return "", err
+		}
+		e, ok := decl.(*idl.Enum)
+		if !ok {
+			return "", g.errorf(l.Pos, "default value is not supported for type %s", it)
+		}
+		switch l.Kind {
+		case idl.LiteralInt:
+			return t.GoType + "(" + l.Text + ")", nil
+		case idl.LiteralIdent:
+			name := l.Text[strings.LastIndex(l.Text, ":")+1:]
+			for _, m := range e.Members {
+				if m.Name == name {
+					return g.qualify(module, goName(e.Name)+"_"+m.Name)
+				}
+			}
+			return "", g.errorf(l.Pos, "%s is not a member of enum %s", l.Text, it)
+		}
+		return invalid()
+	}
+	if !it.IsBasic() {
+		return "", g.errorf(l.Pos, "default value is not supported for type %s", it)
+	}
+
+	// [step 2] 常量的名字
+	if l.Kind == idl.LiteralIdent {
+		return g.constRef(l)
+	}
+
+	// [step 3] 基础类型
+	switch t.Kind {
+	case codegen.Bool:
+		if l.Kind == idl.LiteralBool {
+			return l.Text, nil
+		}
+	case codegen.Int8, codegen.Int16, codegen.Int32, codegen.Int64:
+		if l.Kind == idl.LiteralInt {
+			if _, err = strconv.ParseInt(l.Text, 0, intBits[t.Kind]); err != nil {
+				return "", g.errorf(l.Pos, "value %s overflows %s", l.Text, it)
+			}
+			return l.Text, nil
+		}
+	case codegen.Uint8, codegen.Uint16, codegen.Uint32, codegen.Uint64:
+		if l.Kind == idl.LiteralInt {
+			if _, err = strconv.ParseUint(l.Text, 0, intBits[t.Kind]); err != nil {
+				return "", g.errorf(l.Pos, "value %s overflows %s", l.Text, it)
+			}
+			return l.Text, nil
+		}
+	case codegen.Float32, codegen.Float64:
+		if l.Kind == idl.LiteralInt || l.Kind == idl.LiteralFloat {
+			return strings.TrimRight(l.Text, "fF"), nil
+		}
+	case codegen.String:
+		if l.Kind == idl.LiteralString {
+			s, err := strconv.Unquote(l.Text)
+			if err != nil {
+				return "", g.errorf(l.Pos, "invalid string %s", l.Text)
+			}
+			return strconv.Quote(s), nil
+		}
+	}
+	return invalid()
+}
+
+var intBits = map[codegen.Kind]int{
+	codegen.Int8: 8, codegen.Uint8: 8, codegen.Int16: 16, codegen.Uint16: 16,
+	codegen.Int32: 32, codegen.Uint32: 32, codegen.Int64: 64, codegen.Uint64: 64,
+}
+
+// 引用一个常量，可以带 module 前缀
+func (g *moduleGenerator) constRef(l *idl.Literal) (value string, err error) {
+	module, name := g.module.Name, l.Text
+	if i := strings.Index(l.Text, "::"); i >= 0 {
+		module, name = l.Text[:i], l.Text[i+2:]
+	}
+	if _, ok := g.decls[module][name].(*idl.Const); !ok {
+		return "", g.errorf(l.Pos, "undefined const %s", l.Text)
+	}
+	return g.qualify(module, goName(name))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// 生成的代码放在 internal/golden 下，作为普通的包参与编译，并在那里测试读写
+const goldenDir = "internal/golden"
+
+func TestGolden(t *testing.T) {
+	files, err := idl.Load("testdata/demo.jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(files, "github.com/erpc-go/jce-codec/cmd/jce2go/"+goldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		outs, err := g.generate(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range outs {
+			path := filepath.Join(goldenDir, filepath.FromSlash(o.Path))
+			if *update {
+				if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err = os.WriteFile(path, o.Src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(o.Src, want) {
+				t.Errorf("%s differs from the golden file, run go test -update to update it:\n%s", path, o.Src)
+			}
+		}
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	tests := []struct {
+		src    string
+		prefix string
+		err    string
+	}{
+		{src: `struct S { 0 optional int a; 0 optional int b; };`, err: "t.jce:1:41: duplicate tag 0 in S, used by a"},
+		{src: `struct S { 256 optional int a; };`, err: "t.jce:1:23: tag 256 of S.a out of range [0, 255]"},
+		{src: `struct S { 0 optional int a; 1 optional int A; };`, err: "t.jce:1:41: field S.A conflicts with a, both are A in go"},
+		{src: `struct S { 0 optional int writeTo; };`, err: "t.jce:1:23: field S.writeTo conflicts with method WriteTo"},
+		{src: `struct S { 0 optional Missing a; };`, err: "t.jce:1:34: undefined type Missing"},
+		{src: `struct T {}; struct S { 0 optional map<T, int> a; };`, err: "t.jce:1:51: unsupported map key type T"},
+		{src: `struct S { 0 optional Other::T a; };`, err: "t.jce:1:34: undefined type Other::T"},
+		{src: `struct S { 0 optional int a = "x"; };`, err: `t.jce:1:42: invalid value "x" for type int`},
+		{src: `struct S { 0 optional byte a = 200; };`, err: "t.jce:1:43: value 200 overflows byte"},
+		{src: `struct S { 0 optional int a = MISSING; };`, err: "t.jce:1:42: undefined const MISSING"},
+		{src: `enum E { A }; struct S { 0 optional E a = B; };`, err: "t.jce:1:54: B is not a member of enum E"},
+		{src: `struct T {}; struct S { 0 optional T a = 1; };`, err: "t.jce:1:53: default value is not supported for type T"},
+		{src: `struct S {}; struct S {};`, err: "t.jce:1:25: S redeclared, previous declaration at t.jce:1:12"},
+		{src: `enum E { A = B };`, err: "t.jce:1:25: undefined enum member B"},
+	}
+	for _, tt := range tests {
+		f, err := idl.ParseFile("t.jce", []byte("module M { "+tt.src+" };"))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.src, err)
+		}
+		g, err := newGenerator([]*idl.File{f}, tt.prefix)
+		if err == nil {
+			_, err = g.generate(f)
+		}
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: err = %v, want %s", tt.src, err, tt.err)
+		}
+	}
+}
+
+func TestImportPrefixRequired(t *testing.T) {
+	files, err := idl.Load("testdata/demo.jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.generate(files[1])
+	want := "testdata/demo.jce:34:20: module Common is referenced from Demo, -import-prefix is required"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Code generated by jce2go. DO NOT EDIT.
+// source: common.jce
+
+package common
+
+import (
+	"io"
+	"strconv"
+
+	jce "github.com/erpc-go/jce-codec"
+)
+
+// 地址
+type Address struct {
+	City   string `jce:"0,optional" json:"city"`
+	Street string `jce:"1,optional" json:"street"`
+}
+
+// ResetDefault 把所有字段设置为默认值
+func (m *Address) ResetDefault() {
+	*m = Address{}
+	m.City = "shenzhen"
+}
+
+// WriteTo 实现 jce.Messager
+func (m *Address) WriteTo(w io.Writer) (n int64, err error) {
+	e := jce.NewEncoder(w)
+	start := e.Offset()
+	if err = m.jceWriteFields(e); err == nil {
+		err = e.Flush()
+	}
+	return e.Offset() - start, err
+}
+
+func (m *Address) jceWriteFields(e *jce.Encoder) (err error) {
+	if err = e.WriteString(m.City, 0); err != nil {
+		return err
+	}
+	if err = e.WriteString(m.Street, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值
+func (m *Address) ReadFrom(r io.Reader) (n int64, err error) {
+	d := jce.NewDecoder(r)
+	start := d.Offset()
+	m.ResetDefault()
+	err = m.jceReadFields(d)
+	return d.Offset() - start, err
+}
+
+func (m *Address) jceReadFields(d *jce.Decoder) (err error) {
+	if err = d.ReadString(&m.City, 0, false); err != nil {
+		return err
+	}
+	if err = d.ReadString(&m.Street, 1, false); err != nil {
+		return err
+	}
+	return d.SkipToStructEnd()
+}
+
+type Level int32
+
+const (
+	Level_LOW  Level = 1
+	Level_HIGH Level = 2
+)
+
+func (x Level) String() string {
+	switch x {
+	case Level_LOW:
+		return "LOW"
+	case Level_HIGH:
+		return "HIGH"
+	}
+	return "Level(" + strconv.FormatInt(int64(x), 10) + ")"
+}
@@ -0,0 +1,459 @@
+// Code generated by jce2go. DO NOT EDIT.
+// source: demo.jce
+
+package demo
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	jce "github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce-codec/cmd/jce2go/internal/golden/common"
+)
+
+// 颜色
+type Color int32
+
+const (
+	Color_RED   Color = 0
+	Color_GREEN Color = 2 // 绿色
+	Color_BLUE  Color = 3
+	Color_CYAN  Color = 2
+)
+
+func (x Color) String() string {
+	switch x {
+	case Color_RED:
+		return "RED"
+	case Color_GREEN:
+		return "GREEN"
+	case Color_BLUE:
+		return "BLUE"
+	}
+	return "Color(" + strconv.FormatInt(int64(x), 10) + ")"
+}
+
+const MAX_COUNT int32 = 100
+
+const NAME string = "demo\t\"jce\""
+
+type Point struct {
+	X int32 `jce:"0,required" json:"x"`
+	Y int32 `jce:"1,required" json:"y"`
+}
+
+// ResetDefault 把所有字段设置为默认值
+func (m *Point) ResetDefault() {
+	*m = Point{}
+}
+
+// WriteTo 实现 jce.Messager
+func (m *Point) WriteTo(w io.Writer) (n int64, err error) {
+	e := jce.NewEncoder(w)
+	start := e.Offset()
+	if err = m.jceWriteFields(e); err == nil {
+		err = e.Flush()
+	}
+	return e.Offset() - start, err
+}
+
+func (m *Point) jceWriteFields(e *jce.Encoder) (err error) {
+	if err = e.WriteInt32(m.X, 0); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(m.Y, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值
+func (m *Point) ReadFrom(r io.Reader) (n int64, err error) {
+	d := jce.NewDecoder(r)
+	start := d.Offset()
+	m.ResetDefault()
+	err = m.jceReadFields(d)
+	return d.Offset() - start, err
+}
+
+func (m *Point) jceReadFields(d *jce.Decoder) (err error) {
+	if err = d.ReadInt32(&m.X, 0, true); err != nil {
+		return err
+	}
+	if err = d.ReadInt32(&m.Y, 1, true); err != nil {
+		return err
+	}
+	return d.SkipToStructEnd()
+}
+
+// 用户信息
+type User struct {
+	Id      int64              `jce:"0,required" json:"id"`
+	Name    string             `jce:"1,optional" json:"name"`
+	Age     uint32             `jce:"2,optional" json:"age"` // 年龄
+	Color   Color              `jce:"3,optional" json:"color"`
+	Tags    []string           `jce:"4,optional" json:"tags"`
+	Scores  map[string][]int32 `jce:"5,optional" json:"scores"`
+	Address common.Address     `jce:"6,optional" json:"address"`
+	Avatar  []byte             `jce:"7,optional" json:"avatar"`
+	Weight  float64            `jce:"8,optional" json:"weight"`
+	Vip     bool               `jce:"9,optional" json:"vip"`
+	// 轨迹
+	Track []Point         `jce:"10,optional" json:"track"`
+	Marks map[Color]Point `jce:"11,optional" json:"marks"`
+	Level common.Level    `jce:"12,optional" json:"level"`
+	Limit int32           `jce:"13,optional" json:"limit"`
+	Ratio float32         `jce:"14,optional" json:"ratio"`
+	Flag  int8            `jce:"15,optional" json:"flag"`
+	Port  uint16          `jce:"16,optional" json:"port"`
+	Blobs [][]byte        `jce:"20,optional" json:"blobs"`
+}
+
+// ResetDefault 把所有字段设置为默认值
+func (m *User) ResetDefault() {
+	*m = User{}
+	m.Name = "guest"
+	m.Age = 18
+	m.Color = Color_GREEN
+	m.Address.ResetDefault()
+	m.Weight = -1.5
+	m.Vip = true
+	m.Level = common.Level_HIGH
+	m.Limit = MAX_COUNT
+	m.Ratio = 0.5
+	m.Flag = -1
+	m.Port = 0xffff
+}
+
+// WriteTo 实现 jce.Messager
+func (m *User) WriteTo(w io.Writer) (n int64, err error) {
+	e := jce.NewEncoder(w)
+	start := e.Offset()
+	if err = m.jceWriteFields(e); err == nil {
+		err = e.Flush()
+	}
+	return e.Offset() - start, err
+}
+
+func (m *User) jceWriteFields(e *jce.Encoder) (err error) {
+	if err = e.WriteInt64(m.Id, 0); err != nil {
+		return err
+	}
+	if err = e.WriteString(m.Name, 1); err != nil {
+		return err
+	}
+	if err = e.WriteUint32(m.Age, 2); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(int32(m.Color), 3); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 4); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Tags))); err != nil {
+		return err
+	}
+	for i0 := range m.Tags {
+		if err = e.WriteString(m.Tags[i0], 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteHead(jce.Map, 5); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Scores))); err != nil {
+		return err
+	}
+	for k0, v0 := range m.Scores {
+		if err = e.WriteString(k0, 0); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.List, 1); err != nil {
+			return err
+		}
+		if err = e.WriteLength(uint32(len(v0))); err != nil {
+			return err
+		}
+		for i1 := range v0 {
+			if err = e.WriteInt32(v0[i1], 0); err != nil {
+				return err
+			}
+		}
+	}
+	if err = e.WriteHead(jce.StructBegin, 6); err != nil {
+		return err
+	}
+	if _, err = m.Address.WriteTo(e); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+		return err
+	}
+	if err = e.WriteSliceUint8(m.Avatar, 7); err != nil {
+		return err
+	}
+	if err = e.WriteFloat64(m.Weight, 8); err != nil {
+		return err
+	}
+	if err = e.WriteBool(m.Vip, 9); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 10); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Track))); err != nil {
+		return err
+	}
+	for i0 := range m.Track {
+		if err = e.WriteHead(jce.StructBegin, 0); err != nil {
+			return err
+		}
+		if _, err = m.Track[i0].WriteTo(e); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteHead(jce.Map, 11); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Marks))); err != nil {
+		return err
+	}
+	for k0, v0 := range m.Marks {
+		if err = e.WriteInt32(int32(k0), 0); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructBegin, 1); err != nil {
+			return err
+		}
+		if _, err = v0.WriteTo(e); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteInt32(int32(m.Level), 12); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(m.Limit, 13); err != nil {
+		return err
+	}
+	if err = e.WriteFloat32(m.Ratio, 14); err != nil {
+		return err
+	}
+	if err = e.WriteInt8(m.Flag, 15); err != nil {
+		return err
+	}
+	if err = e.WriteUint16(m.Port, 16); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 20); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Blobs))); err != nil {
+		return err
+	}
+	for i0 := range m.Blobs {
+		if err = e.WriteSliceUint8(m.Blobs[i0], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值
+func (m *User) ReadFrom(r io.Reader) (n int64, err error) {
+	d := jce.NewDecoder(r)
+	start := d.Offset()
+	m.ResetDefault()
+	err = m.jceReadFields(d)
+	return d.Offset() - start, err
+}
+
+func (m *User) jceReadFields(d *jce.Decoder) (err error) {
+	if err = d.ReadInt64(&m.Id, 0, true); err != nil {
+		return err
+	}
+	if err = d.ReadString(&m.Name, 1, false); err != nil {
+		return err
+	}
+	if err = d.ReadUint32(&m.Age, 2, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt32((*int32)(&m.Color), 3, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(4, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.tags failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Tags = make([]string, 0, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var v0 string
+			if err = d.ReadString(&v0, 0, true); err != nil {
+				return err
+			}
+			m.Tags = append(m.Tags, v0)
+		}
+	}
+	if ty, have, err := d.ReadHead(5, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.Map {
+			return fmt.Errorf("read User.scores failed, want Map, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Scores = make(map[string][]int32, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var k0 string
+			var v0 []int32
+			if err = d.ReadString(&k0, 0, true); err != nil {
+				return err
+			}
+			if ty, have, err := d.ReadHead(1, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.List {
+					return fmt.Errorf("read User.scores.value failed, want List, but got %s", ty)
+				}
+				length, err := d.ReadLength()
+				if err != nil {
+					return err
+				}
+				v0 = make([]int32, 0, jce.SizeHint(length))
+				for i1 := uint32(0); i1 < length; i1++ {
+					var v1 int32
+					if err = d.ReadInt32(&v1, 0, true); err != nil {
+						return err
+					}
+					v0 = append(v0, v1)
+				}
+			}
+			m.Scores[k0] = v0
+		}
+	}
+	if ty, have, err := d.ReadHead(6, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.StructBegin {
+			return fmt.Errorf("read User.address failed, want StructBegin, but got %s", ty)
+		}
+		if _, err = m.Address.ReadFrom(d); err != nil {
+			return err
+		}
+	}
+	if err = d.ReadSliceUint8(&m.Avatar, 7, false); err != nil {
+		return err
+	}
+	if err = d.ReadFloat64(&m.Weight, 8, false); err != nil {
+		return err
+	}
+	if err = d.ReadBool(&m.Vip, 9, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(10, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.track failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Track = make([]Point, 0, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var v0 Point
+			if ty, have, err := d.ReadHead(0, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.StructBegin {
+					return fmt.Errorf("read User.track[] failed, want StructBegin, but got %s", ty)
+				}
+				if _, err = v0.ReadFrom(d); err != nil {
+					return err
+				}
+			}
+			m.Track = append(m.Track, v0)
+		}
+	}
+	if ty, have, err := d.ReadHead(11, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.Map {
+			return fmt.Errorf("read User.marks failed, want Map, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Marks = make(map[Color]Point, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var k0 Color
+			var v0 Point
+			if err = d.ReadInt32((*int32)(&k0), 0, true); err != nil {
+				return err
+			}
+			if ty, have, err := d.ReadHead(1, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.StructBegin {
+					return fmt.Errorf("read User.marks.value failed, want StructBegin, but got %s", ty)
+				}
+				if _, err = v0.ReadFrom(d); err != nil {
+					return err
+				}
+			}
+			m.Marks[k0] = v0
+		}
+	}
+	if err = d.ReadInt32((*int32)(&m.Level), 12, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt32(&m.Limit, 13, false); err != nil {
+		return err
+	}
+	if err = d.ReadFloat32(&m.Ratio, 14, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt8(&m.Flag, 15, false); err != nil {
+		return err
+	}
+	if err = d.ReadUint16(&m.Port, 16, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(20, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.blobs failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Blobs = make([][]byte, 0, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var v0 []byte
+			if err = d.ReadSliceUint8(&v0, 0, true); err != nil {
+				return err
+			}
+			m.Blobs = append(m.Blobs, v0)
+		}
+	}
+	return d.SkipToStructEnd()
+}
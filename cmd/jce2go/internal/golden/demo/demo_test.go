@@ -0,0 +1,123 @@
+package demo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	jce "github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce-codec/cmd/jce2go/internal/golden/common"
+)
+
+func TestRoundTrip(t *testing.T) {
+	in := User{
+		Id:      42,
+		Name:    "alice",
+		Age:     30,
+		Color:   Color_BLUE,
+		Tags:    []string{"a", "b"},
+		Scores:  map[string][]int32{"math": {90, 95}, "art": {}},
+		Address: common.Address{City: "beijing", Street: "chang'an"},
+		Avatar:  []byte{1, 2, 3},
+		Weight:  60.5,
+		Vip:     false,
+		Track:   []Point{{X: 1, Y: 2}, {X: -3, Y: 300}},
+		Marks:   map[Color]Point{Color_RED: {X: 7}},
+		Level:   common.Level_LOW,
+		Limit:   5,
+		Ratio:   0.25,
+		Flag:    3,
+		Port:    8080,
+		Blobs:   [][]byte{{0xff}, {}},
+	}
+
+	var b bytes.Buffer
+	n, err := in.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo n = %d, want %d", n, b.Len())
+	}
+
+	var out User
+	if n, err = out.ReadFrom(bytes.NewReader(b.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("ReadFrom n = %d, want %d", n, b.Len())
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", out, in)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	var b bytes.Buffer
+	if _, err := (&Point{X: 1, Y: 300}).WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	// int1 1 at tag 0, int2 300 at tag 1
+	if want := []byte{0x00, 0x01, 0x11, 0x01, 0x2c}; !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("data = % x, want % x", b.Bytes(), want)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	// 只有 require 的 id，其他字段都是默认值
+	var b bytes.Buffer
+	e := jce.NewEncoder(&b)
+	e.WriteInt64(1, 0)
+	e.Flush()
+
+	u := User{Tags: []string{"stale"}}
+	if _, err := u.ReadFrom(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := User{
+		Id:      1,
+		Name:    "guest",
+		Age:     18,
+		Color:   Color_GREEN,
+		Address: common.Address{City: "shenzhen"},
+		Weight:  -1.5,
+		Vip:     true,
+		Level:   common.Level_HIGH,
+		Limit:   MAX_COUNT,
+		Ratio:   0.5,
+		Flag:    -1,
+		Port:    0xffff,
+	}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("defaults:\n got %+v\nwant %+v", u, want)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "missing require", data: []byte{}, err: "tag:0"},
+		{name: "wrong type", data: []byte{0x00, 0x01, 0x04, 0x02}, err: "read User.tags failed, want List, but got Int1"},
+		// 长度来自数据，数据不完整时返回错误，不能按长度预分配内存
+		{name: "huge list length", data: []byte{0x00, 0x01, 0xa4, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+		{name: "huge map length", data: []byte{0x00, 0x01, 0x85, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+	}
+	for _, tt := range tests {
+		var u User
+		_, err := u.ReadFrom(bytes.NewReader(tt.data))
+		if err == nil || !bytes.Contains([]byte(err.Error()), []byte(tt.err)) {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestColorString(t *testing.T) {
+	for c, want := range map[Color]string{Color_RED: "RED", Color_CYAN: "GREEN", 9: "Color(9)"} {
+		if got := c.String(); got != want {
+			t.Errorf("%d.String() = %s, want %s", int32(c), got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// jce2go 根据 .jce 文件生成 go 代码，生成的 struct 实现 jce.Messager，直接使用 Encoder、Decoder 读写字段
+//
+// 用法：
+//
+//	jce2go [flags] file.jce...
+//
+// 每个 module 生成一个 go 包，写入 输出目录/module 名字的小写/文件名.go，include 的文件也会生成；
+// 引用其他 module 中的类型时需要 -import-prefix，比如输出目录 gen 对应的 import 路径为 example.com/proto/gen，
+// 则 Common 对应的包为 example.com/proto/gen/common
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+var (
+	outDir       = flag.String("o", ".", "output directory")
+	importPrefix = flag.String("import-prefix", "", "import path of the output directory")
+	includeDirs  stringList
+)
+
+// 可以重复的参数
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+func main() {
+	flag.Var(&includeDirs, "I", "directory to search for included files, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jce2go [flags] file.jce...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "jce2go: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(names []string) (err error) {
+	// [step 1] 加载所有文件以及 include 的文件，同一个文件只保留一次
+	var files []*idl.File
+	seen := map[string]bool{}
+	for _, name := range names {
+		loaded, err := idl.Load(name, includeDirs...)
+		if err != nil {
+			return err
+		}
+		for _, f := range loaded {
+			abs, err := filepath.Abs(f.Name)
+			if err != nil {
+				return err
+			}
+			if !seen[abs] {
+				seen[abs] = true
+				files = append(files, f)
+			}
+		}
+	}
+
+	// [step 2] 生成，全部成功之后再写入
+	g, err := newGenerator(files, *importPrefix)
+	if err != nil {
+		return
+	}
+	var outs []output
+	for _, f := range files {
+		o, err := g.generate(f)
+		if err != nil {
+			return err
+		}
+		outs = append(outs, o...)
+	}
+	for _, o := range outs {
+		path := filepath.Join(*outDir, filepath.FromSlash(o.Path))
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return
+		}
+		if err = os.WriteFile(path, o.Src, 0o644); err != nil {
+			return
+		}
+	}
+	return
+}
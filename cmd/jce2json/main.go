@@ -0,0 +1,110 @@
+// jce2json 把 jce 编码数据转换为以 tag 为 key 的 JSON，编码类型原样保留，格式见 jce.ToJSON
+//
+// 用法：
+//
+//	jce2json [flags] [file]
+//
+// file 为空或者 "-" 时读取 stdin，结果写入 stdout；数据有误时输出失败的偏移，并以 1 退出
+// -lines 时每读到一行就输出对应的 JSON，可以用于处理管道中持续产生的数据
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	jce "github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce-codec/internal/cmdutil"
+)
+
+var (
+	input  = flag.String("input", cmdutil.FormatRaw, "input format: raw, hex or base64")
+	lines  = flag.Bool("lines", false, "treat each line of hex or base64 input as a separate message")
+	pretty = flag.Bool("pretty", false, "indent the JSON output")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jce2json [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 || *lines && *input == cmdutil.FormatRaw {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	err := run(w)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jce2json: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(w *bufio.Writer) (err error) {
+	// [step 1] 整个输入为一个消息
+	if !*lines {
+		data, err := cmdutil.ReadInput(flag.Arg(0), *input)
+		if err != nil {
+			return err
+		}
+		return convert(w, data)
+	}
+
+	// [step 2] 每行一个消息，逐行读取、转换并输出，不需要等到输入结束
+	var r io.Reader = os.Stdin
+	if name := flag.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		data, err := cmdutil.Decode(s.Bytes(), *input)
+		if err == nil {
+			err = convert(w, data)
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s", line, err)
+		}
+	}
+	return s.Err()
+}
+
+// 把一个消息转换为 JSON 并写入 w
+func convert(w io.Writer, data []byte) (err error) {
+	out, err := jce.ToJSON(data)
+	if err != nil {
+		return
+	}
+	if *pretty {
+		var b bytes.Buffer
+		if err = json.Indent(&b, out, "", "  "); err != nil {
+			return
+		}
+		out = b.Bytes()
+	}
+	if _, err = w.Write(out); err != nil {
+		return
+	}
+	_, err = io.WriteString(w, "\n")
+	return
+}
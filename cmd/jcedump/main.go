@@ -0,0 +1,71 @@
+// jcedump 输出 jce 编码数据的字段树，用于排查抓包得到的数据
+//
+// 用法：
+//
+//	jcedump [flags] [file]
+//
+// file 为空或者 "-" 时读取 stdin，解析失败时在失败的位置输出 "!!" 开头的行，并以 1 退出
+// -format 可以把数据输出为文本格式、go 代码，用于把抓到的数据放到测试中：
+//   - tree：字段树，默认的格式
+//   - text：jce.FormatText 的文本格式
+//   - go：用 Encoder 重新生成数据的 go 代码
+//   - bytes：带注释的 []byte 字面量
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	jce "github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce-codec/internal/cmdutil"
+)
+
+func main() {
+	var opts jce.DumpOptions
+	input := flag.String("input", cmdutil.FormatRaw, "input format: raw, hex or base64")
+	output := flag.String("format", "tree", "output format: tree, text, go or bytes")
+	flag.IntVar(&opts.MaxDepth, "depth", 0, "max depth to expand, 0 means no limit")
+	flag.IntVar(&opts.Offset, "offset", 0, "start parsing at this byte offset")
+	flag.IntVar(&opts.MaxString, "max-string", 64, "max bytes of string and SimpleList to print, 0 means no limit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jcedump [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := cmdutil.ReadInput(flag.Arg(0), *input)
+	if err == nil && (opts.Offset < 0 || opts.Offset > len(data)) {
+		err = fmt.Errorf("offset %d out of range, length:%d", opts.Offset, len(data))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jcedump: %s\n", err)
+		os.Exit(1)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var out []byte
+	switch *output {
+	case "tree":
+		err = jce.Dump(w, data, opts)
+	case "text":
+		out, err = jce.FormatText(data[opts.Offset:])
+	case "go":
+		out, err = jce.FormatGoEncoder(data[opts.Offset:], "jce")
+	case "bytes":
+		out, err = jce.FormatGoBytes(data[opts.Offset:])
+	default:
+		err = fmt.Errorf("unknown format %q, want tree, text, go or bytes", *output)
+	}
+	w.Write(out)
+	w.Flush()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jcedump: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 上下文的行数
+const diffContext = 3
+
+// 按行比较 a、b，输出 unified 格式的 diff，相同时为空
+func unifiedDiff(nameA, nameB string, a, b []byte) []byte {
+	x, y := splitLines(a), splitLines(b)
+
+	// [step 1] 最长公共子序列，lcs[i][j] 为 x[i:]、y[j:] 的结果
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// [step 2] 转换为编辑序列，' ' 为相同的行，'-' 为删除，'+' 为添加
+	type edit struct {
+		op   byte
+		text string
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			edits = append(edits, edit{' ', x[i]})
+			i, j = i+1, j+1
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', x[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', y[j]})
+			j++
+		}
+	}
+
+	// [step 3] 按上下文分成多个 hunk 输出
+	var out bytes.Buffer
+	lineA, lineB := 1, 1 // edits[k] 对应的行号
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			lineA, lineB, k = lineA+1, lineB+1, k+1
+			continue
+		}
+
+		// [step 3.1] hunk 的范围：前后各 diffContext 行，两个修改之间的相同行不超过 2*diffContext 时合并
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for same := 0; end < len(edits) && same <= 2*diffContext; end++ {
+			if edits[end].op == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+		}
+		for end > k && edits[end-1].op == ' ' {
+			end--
+		}
+		if end += diffContext; end > len(edits) {
+			end = len(edits)
+		}
+
+		// [step 3.2] hunk 的头和内容
+		startA, startB := lineA-(k-start), lineB-(k-start)
+		var countA, countB int
+		var body strings.Builder
+		for _, e := range edits[start:end] {
+			body.WriteString(string(e.op) + e.text + "\n")
+			if e.op != '+' {
+				countA++
+			}
+			if e.op != '-' {
+				countB++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(startA, countA), hunkRange(startB, countB))
+		out.WriteString(body.String())
+
+		lineA, lineB = startA+countA, startB+countB
+		k = end
+	}
+	return out.Bytes()
+}
+
+// hunk 中的行号范围，没有行时行号为前一行
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// 按行切分，最后一行没有换行符时加上标记
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := string(data)
+	noEOL := !strings.HasSuffix(s, "\n")
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if noEOL {
+		lines[len(lines)-1] += "\n\\ No newline at end of file"
+	}
+	return lines
+}
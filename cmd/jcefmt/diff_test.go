@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if s, ok := change[i]; ok {
+				b.WriteString(s)
+				continue
+			}
+			b.WriteString("line" + string(rune('a'+i-1)) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "same", a: "x\n", b: "x\n", want: ""},
+		{
+			name: "change",
+			a:    lines(5, nil),
+			b:    lines(5, map[int]string{3: "LINEC\n"}),
+			want: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n linea\n lineb\n-linec\n+LINEC\n lined\n linee\n",
+		},
+		{
+			name: "two hunks",
+			a:    lines(20, nil),
+			b:    lines(20, map[int]string{2: "", 18: "liner\nextra\n"}),
+			want: "--- a\n+++ b\n@@ -1,5 +1,4 @@\n linea\n-lineb\n linec\n lined\n linee\n" +
+				"@@ -16,5 +15,6 @@\n linep\n lineq\n liner\n+extra\n lines\n linet\n",
+		},
+		{
+			name: "no newline",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{name: "empty", a: "", b: "x\n", want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n"},
+	}
+	for _, tt := range tests {
+		if got := string(unifiedDiff("a", "b", []byte(tt.a), []byte(tt.b))); got != tt.want {
+			t.Errorf("%s:\n got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
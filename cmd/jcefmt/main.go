@@ -0,0 +1,118 @@
+// jcefmt 按统一的风格格式化 .jce 文件，格式见 idl.Format
+//
+// 用法：
+//
+//	jcefmt [flags] [path ...]
+//
+// path 可以是文件或者目录，目录中的 .jce 文件会被递归处理；没有 path 时读取 stdin，结果写入 stdout。
+// 默认输出格式化之后的内容，-l 只列出格式不一致的文件，-d 输出 diff，-w 直接写回文件
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+var (
+	list  = flag.Bool("l", false, "list files whose formatting differs from jcefmt's")
+	diff  = flag.Bool("d", false, "display diffs instead of rewriting files")
+	write = flag.Bool("w", false, "write result to (source) file instead of stdout")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jcefmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	failed := false
+	report := func(err error) {
+		fmt.Fprintf(os.Stderr, "jcefmt: %s\n", err)
+		failed = true
+	}
+
+	// [step 1] 没有 path 时处理 stdin
+	if flag.NArg() == 0 {
+		if *write {
+			report(fmt.Errorf("cannot use -w with standard input"))
+		} else if err := process("<standard input>", os.Stdin, os.Stdout); err != nil {
+			report(err)
+		}
+	}
+
+	// [step 2] 文件、目录中的 .jce 文件
+	for _, path := range flag.Args() {
+		err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || name != path && !strings.HasSuffix(name, ".jce") {
+				return nil
+			}
+			if err = processFile(name, os.Stdout); err != nil {
+				report(err)
+			}
+			return nil
+		})
+		if err != nil {
+			report(err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func processFile(name string, out io.Writer) (err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return process(name, f, out)
+}
+
+// 格式化一个文件，按参数输出结果
+func process(name string, in io.Reader, out io.Writer) (err error) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	res, err := idl.FormatSource(name, src)
+	if err != nil {
+		return
+	}
+
+	if bytes.Equal(src, res) {
+		if !*list && !*diff && !*write {
+			_, err = out.Write(res)
+		}
+		return
+	}
+	if *list {
+		fmt.Fprintln(out, name)
+	}
+	if *diff {
+		_, err = out.Write(unifiedDiff(name+".orig", name, src, res))
+	}
+	if *write {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, res, info.Mode().Perm())
+	}
+	if !*list && !*diff {
+		_, err = out.Write(res)
+	}
+	return
+}
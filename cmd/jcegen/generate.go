@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/internal/codegen"
+)
+
+// ---------------------------------------------------------------------------
+// go struct -> 读写方法
+// 只做语法上的分析，不做类型检查：字段的类型只能是基础类型、同一个包中定义的类型，以及它们组成的
+// slice、数组、map，同一个包中的 struct 需要也带有 jce 的 tag，这样它也会生成方法；
+// 类型和编码的对应见 README 中的 go 序列化方案映射，不支持的类型在生成时报错
+// ---------------------------------------------------------------------------
+
+// 读取 dir 中的 go 文件，不包括测试文件和 skip（即上次生成的文件）
+func parseDir(fset *token.FileSet, dir, skip string) (files []*ast.File, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			return nil, fmt.Errorf("found packages %s and %s in %s", files[0].Name.Name, f.Name.Name, dir)
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+	return
+}
+
+// 基础类型，byte、rune 为别名
+var basicKinds = map[string]codegen.Kind{
+	"bool": codegen.Bool, "string": codegen.String,
+	"int8": codegen.Int8, "int16": codegen.Int16, "int32": codegen.Int32, "int64": codegen.Int64,
+	"uint8": codegen.Uint8, "uint16": codegen.Uint16, "uint32": codegen.Uint32, "uint64": codegen.Uint64,
+	"byte": codegen.Uint8, "rune": codegen.Int32,
+	"float32": codegen.Float32, "float64": codegen.Float64,
+}
+
+// 生成的方法，struct 中不能已经有同名的方法、字段
+var generatedMethods = []string{"ResetDefault", "WriteTo", "ReadFrom", "jceWriteFields", "jceReadFields"}
+
+type generator struct {
+	fset     *token.FileSet
+	specs    map[string]*ast.TypeSpec   // 包中所有的类型
+	methods  map[string]map[string]bool // 类型 -> 已经有的方法
+	messages map[string]bool            // 需要生成方法的 struct
+
+	resolving map[string]bool // 正在解析的类型，用于发现循环定义
+}
+
+// 为 files 中带有 jce tag 的 struct 生成方法，names 不为空时只生成这些 struct
+func generate(fset *token.FileSet, files []*ast.File, names []string) (src []byte, err error) {
+	g := &generator{
+		fset:      fset,
+		specs:     map[string]*ast.TypeSpec{},
+		methods:   map[string]map[string]bool{},
+		messages:  map[string]bool{},
+		resolving: map[string]bool{},
+	}
+
+	// [step 1] 收集类型和方法
+	var order []*ast.TypeSpec
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						g.specs[ts.Name.Name] = ts
+						order = append(order, ts)
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && len(decl.Recv.List) == 1 {
+					recv := receiverName(decl.Recv.List[0].Type)
+					if g.methods[recv] == nil {
+						g.methods[recv] = map[string]bool{}
+					}
+					g.methods[recv][decl.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	// [step 2] 选出需要生成的 struct
+	if len(names) > 0 {
+		for _, name := range names {
+			ts, ok := g.specs[name]
+			if !ok {
+				return nil, fmt.Errorf("type %s not found", name)
+			}
+			if _, ok = ts.Type.(*ast.StructType); !ok {
+				return nil, g.errorf(ts.Pos(), "type %s is not a struct", name)
+			}
+			g.messages[name] = true
+		}
+	} else {
+		for _, ts := range order {
+			if st, ok := ts.Type.(*ast.StructType); ok && hasJceTag(st) {
+				g.messages[ts.Name.Name] = true
+			}
+		}
+	}
+	if len(g.messages) == 0 {
+		return nil, fmt.Errorf("no struct with jce tags found")
+	}
+
+	// [step 3] 按源码中的顺序生成
+	out := &codegen.File{Package: files[0].Name.Name, Header: "Code generated by jcegen. DO NOT EDIT."}
+	for _, ts := range order {
+		if !g.messages[ts.Name.Name] {
+			continue
+		}
+		msg, err := g.message(ts)
+		if err != nil {
+			return nil, err
+		}
+		out.Methods(msg)
+	}
+	return out.Source()
+}
+
+func (g *generator) errorf(pos token.Pos, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", g.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+// 方法的接收者的类型名，比如 *User、User[T]
+func receiverName(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func hasJceTag(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := fieldTag(field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 字段的 jce tag
+func fieldTag(field *ast.Field) (tag string, ok bool) {
+	if field.Tag == nil {
+		return
+	}
+	s, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return
+	}
+	return reflect.StructTag(s).Lookup("jce")
+}
+
+// 解析 jce tag：tag[,required|optional]，默认为 optional
+func parseTag(s string) (tag int, require bool, err error) {
+	parts := strings.Split(s, ",")
+	if tag, err = strconv.Atoi(parts[0]); err != nil || tag < 0 || tag > 255 {
+		return 0, false, fmt.Errorf("invalid jce tag %q, tag must be in [0, 255]", s)
+	}
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 2 && parts[1] == "required":
+		require = true
+	case len(parts) == 2 && parts[1] == "optional":
+	default:
+		return 0, false, fmt.Errorf("invalid jce tag %q, want \"tag,required\" or \"tag,optional\"", s)
+	}
+	return
+}
+
+// 把一个 struct 转换为 codegen 的模型
+func (g *generator) message(ts *ast.TypeSpec) (msg *codegen.Message, err error) {
+	name := ts.Name.Name
+	if ts.TypeParams != nil {
+		return nil, g.errorf(ts.Pos(), "generic type %s is not supported", name)
+	}
+	for _, method := range generatedMethods {
+		if g.methods[name][method] {
+			return nil, g.errorf(ts.Pos(), "%s already has method %s", name, method)
+		}
+	}
+
+	msg = &codegen.Message{Name: name}
+	tags := map[int]string{}
+	for _, field := range ts.Type.(*ast.StructType).Fields.List {
+		// [step 1] 没有 jce tag，或者 tag 为 "-" 的字段不参与序列化
+		s, ok := fieldTag(field)
+		if !ok || s == "-" {
+			continue
+		}
+		if len(field.Names) == 0 {
+			return nil, g.errorf(field.Pos(), "embedded field in %s is not supported", name)
+		}
+		tag, require, err := parseTag(s)
+		if err != nil {
+			return nil, g.errorf(field.Tag.Pos(), "%s", err)
+		}
+
+		// [step 2] 类型
+		t, err := g.goType(field.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		// [step 3] 检查 tag、名字
+		for _, ident := range field.Names {
+			if prev, ok := tags[tag]; ok {
+				return nil, g.errorf(ident.Pos(), "duplicate tag %d in %s, used by %s", tag, name, prev)
+			}
+			tags[tag] = ident.Name
+			for _, method := range generatedMethods {
+				if ident.Name == method {
+					return nil, g.errorf(ident.Pos(), "field %s.%s conflicts with the generated method", name, ident.Name)
+				}
+			}
+			msg.Fields = append(msg.Fields, &codegen.Field{Name: ident.Name, Tag: byte(tag), Require: require, Type: t})
+		}
+	}
+
+	// 按 tag 排序，这样写入的顺序和 tag 一致，读取时不需要回退
+	sort.SliceStable(msg.Fields, func(i, j int) bool {
+		return msg.Fields[i].Tag < msg.Fields[j].Tag
+	})
+	return
+}
+
+// 把 go 的类型转换为 codegen 的类型
+func (g *generator) goType(expr ast.Expr) (t *codegen.Type, err error) {
+	switch e := expr.(type) {
+	// [step 1] 基础类型、包中定义的类型
+	case *ast.Ident:
+		if kind, ok := basicKinds[e.Name]; ok {
+			return codegen.Basic(kind, ""), nil
+		}
+		if ts, ok := g.specs[e.Name]; ok {
+			return g.namedType(ts, e)
+		}
+		return nil, g.errorf(e.Pos(), "unsupported type %s", e.Name)
+
+	// [step 2] slice、数组，[]byte、[]int8 为 SimpleList
+	case *ast.ArrayType:
+		elem, err := g.goType(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if e.Len == nil {
+			if elem.GoType == "uint8" {
+				return codegen.Basic(codegen.Bytes, ""), nil
+			}
+			if elem.GoType == "int8" {
+				return codegen.Basic(codegen.Int8s, ""), nil
+			}
+			return &codegen.Type{Kind: codegen.Slice, GoType: "[]" + elem.GoType, Elem: elem}, nil
+		}
+		lit, ok := e.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, g.errorf(e.Len.Pos(), "array length must be an integer literal")
+		}
+		n, err := strconv.ParseInt(lit.Value, 0, 32)
+		if err != nil {
+			return nil, g.errorf(e.Len.Pos(), "invalid array length %s", lit.Value)
+		}
+		return &codegen.Type{Kind: codegen.Array, GoType: "[" + lit.Value + "]" + elem.GoType, Elem: elem, Len: int(n)}, nil
+
+	// [step 3] map，key 只能是基础类型
+	case *ast.MapType:
+		key, err := g.goType(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !key.Kind.IsBasic() || key.Kind == codegen.Bytes || key.Kind == codegen.Int8s {
+			return nil, g.errorf(e.Key.Pos(), "unsupported map key type %s", key.GoType)
+		}
+		elem, err := g.goType(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &codegen.Type{Kind: codegen.Map, GoType: "map[" + key.GoType + "]" + elem.GoType, Key: key, Elem: elem}, nil
+
+	case *ast.StarExpr:
+		return nil, g.errorf(e.Pos(), "pointer type is not supported")
+	case *ast.SelectorExpr:
+		return nil, g.errorf(e.Pos(), "type %s from another package is not supported", exprString(e))
+	case *ast.ChanType:
+		return nil, g.errorf(e.Pos(), "unsupported type chan")
+	default:
+		return nil, g.errorf(expr.Pos(), "unsupported type %s", exprString(expr))
+	}
+}
+
+// 包中定义的类型：需要生成方法的 struct，或者基于支持的类型定义的类型，比如 type Color int32
+func (g *generator) namedType(ts *ast.TypeSpec, use *ast.Ident) (t *codegen.Type, err error) {
+	name := ts.Name.Name
+	if _, ok := ts.Type.(*ast.StructType); ok {
+		if !g.messages[name] {
+			return nil, g.errorf(use.Pos(), "struct %s has no jce tags", name)
+		}
+		return &codegen.Type{Kind: codegen.Struct, GoType: name}, nil
+	}
+	if ts.TypeParams != nil {
+		return nil, g.errorf(use.Pos(), "generic type %s is not supported", name)
+	}
+
+	// 底层的类型，定义为 struct 的类型没有生成的方法，别名除外
+	if g.resolving[name] {
+		return nil, g.errorf(ts.Pos(), "invalid recursive type %s", name)
+	}
+	g.resolving[name] = true
+	defer delete(g.resolving, name)
+	if t, err = g.goType(ts.Type); err != nil {
+		return
+	}
+	if t.Kind == codegen.Struct && !ts.Assign.IsValid() {
+		return nil, g.errorf(use.Pos(), "type %s has no generated methods, use %s instead", name, t.GoType)
+	}
+	named := *t
+	named.GoType = name
+	return &named, nil
+}
+
+// 类型在源码中的写法，用于错误信息
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.StructType:
+		return "struct"
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGolden(t *testing.T) {
+	const dir, golden = "internal/golden", "internal/golden/jce_gen.go"
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, dir, "jce_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err = os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("%s differs from the golden file, run go test -update to update it:\n%s", golden, src)
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	tests := []struct {
+		src   string
+		types []string
+		err   string
+	}{
+		{src: "type S struct { A int32 `jce:\"0\"`; B int32 `jce:\"0\"` }", err: "t.go:3:36: duplicate tag 0 in S, used by A"},
+		{src: "type S struct { A, B int32 `jce:\"0\"` }", err: "t.go:3:20: duplicate tag 0 in S, used by A"},
+		{src: "type S struct { A int `jce:\"0\"` }", err: "t.go:3:19: unsupported type int"},
+		{src: "type S struct { A uint `jce:\"0\"` }", err: "t.go:3:19: unsupported type uint"},
+		{src: "type S struct { A uintptr `jce:\"0\"` }", err: "t.go:3:19: unsupported type uintptr"},
+		{src: "type S struct { A complex128 `jce:\"0\"` }", err: "t.go:3:19: unsupported type complex128"},
+		{src: "type S struct { A chan int32 `jce:\"0\"` }", err: "t.go:3:19: unsupported type chan"},
+		{src: "type S struct { A any `jce:\"0\"` }", err: "t.go:3:19: unsupported type any"},
+		{src: "type S struct { A interface{} `jce:\"0\"` }", err: "t.go:3:19: unsupported type interface"},
+		{src: "type S struct { A []int `jce:\"0\"` }", err: "t.go:3:21: unsupported type int"},
+		{src: "type S struct { A *S `jce:\"0\"` }", err: "t.go:3:19: pointer type is not supported"},
+		{src: "type S struct { A time.Time `jce:\"0\"` }", err: "t.go:3:19: type time.Time from another package is not supported"},
+		{src: "type S struct { A map[T]int32 `jce:\"0\"` }; type T struct { X int32 `jce:\"0\"` }", err: "t.go:3:23: unsupported map key type T"},
+		{src: "type S struct { A T `jce:\"0\"` }; type T struct { X int32 }", err: "t.go:3:19: struct T has no jce tags"},
+		{src: "type S struct { A U `jce:\"0\"` }; type T struct { X int32 `jce:\"0\"` }; type U T", err: "t.go:3:19: type U has no generated methods, use T instead"},
+		{src: "type S struct { A U `jce:\"0\"` }; type U V; type V U", err: "t.go:3:39: invalid recursive type U"},
+		{src: "type S struct { A int32 `jce:\"256\"` }", err: `t.go:3:25: invalid jce tag "256", tag must be in [0, 255]`},
+		{src: "type S struct { A int32 `jce:\"1,maybe\"` }", err: `t.go:3:25: invalid jce tag "1,maybe", want "tag,required" or "tag,optional"`},
+		{src: "type S struct { T `jce:\"0\"` }; type T struct{}", err: "t.go:3:17: embedded field in S is not supported"},
+		{src: "type S struct { WriteTo int32 `jce:\"0\"` }", err: "t.go:3:17: field S.WriteTo conflicts with the generated method"},
+		{src: "type S struct { A int32 `jce:\"0\"` }; func (s *S) ReadFrom() {}", err: "t.go:3:6: S already has method ReadFrom"},
+		{src: "type S[T any] struct { A int32 `jce:\"0\"` }", err: "t.go:3:6: generic type S is not supported"},
+		{src: "type S struct { A [n]int32 `jce:\"0\"` }", err: "t.go:3:20: array length must be an integer literal"},
+		{src: "type S struct { A int32 }", err: "no struct with jce tags found"},
+		{src: "type S struct { A int32 }", types: []string{"T"}, err: "type T not found"},
+		{src: "type S int32", types: []string{"S"}, err: "t.go:3:6: type S is not a struct"},
+	}
+	for _, tt := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "t.go", "package p\n\n"+tt.src+"\n", 0)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.src, err)
+		}
+		_, err = generate(fset, []*ast.File{f}, tt.types)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: err = %v, want %s", tt.src, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package golden
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	jce "github.com/erpc-go/jce-codec"
+)
+
+func TestRoundTrip(t *testing.T) {
+	in := User{
+		ID:     42,
+		Name:   "alice",
+		Age:    200,
+		Color:  -7,
+		Tags:   Tags{"a", "b"},
+		Scores: map[string][]int16{"math": {90, -95}},
+		Home:   Point{X: 1, Y: 2},
+		Avatar: []byte{1, 2, 3},
+		Raw:    []int8{-1, 0, 1},
+		Track:  []Point{{X: 3, Y: 4}},
+		Marks:  map[Color]Point{1: {X: 5}},
+		Ratio:  0.5,
+		Weight: -60.25,
+		Vip:    true,
+		Letter: '中',
+		Pair:   [2]uint64{1, 1 << 63},
+		Blob:   Blob("blob"),
+		Bits:   0xffff,
+		Level:  -300,
+	}
+
+	var b bytes.Buffer
+	n, err := in.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo n = %d, want %d", n, b.Len())
+	}
+
+	out := User{Note: "stale", Cache: "stale"}
+	if _, err = out.ReadFrom(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", out, in)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	// 字段按 tag 的顺序写入，和手写 Encoder 调用的结果一致
+	var want bytes.Buffer
+	e := jce.NewEncoder(&want)
+	e.WriteInt32(1, 0)
+	e.WriteInt32(300, 1)
+	e.Flush()
+
+	var got bytes.Buffer
+	if _, err := (&Point{X: 1, Y: 300}).WriteTo(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("data = % x, want % x", got.Bytes(), want.Bytes())
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		// tag 0 为 int1 1，tag 15 为只有一个 zero 的 list
+		{name: "array length", data: []byte{0x00, 0x01, 0xaf, 0x0f, 0x01, 0x60}, err: "read User.Pair failed, want 2 items, but got 1"},
+		// tag 6 为 int1，应该是 struct
+		{name: "struct type", data: []byte{0x00, 0x01, 0x06, 0x01}, err: "read User.Home failed, want StructBegin, but got Int1"},
+		// tag 4、5、9、10 为 list、map，长度来自数据，数据不完整时返回错误，不能按长度预分配内存
+		{name: "huge Tags length", data: []byte{0x00, 0x01, 0xa4, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+		{name: "huge Scores length", data: []byte{0x00, 0x01, 0x85, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+		{name: "huge Track length", data: []byte{0x00, 0x01, 0xa9, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+		{name: "huge Marks length", data: []byte{0x00, 0x01, 0x8a, 0xff, 0xff, 0xff, 0xff}, err: "missing required tags: 0"},
+	}
+	for _, tt := range tests {
+		var u User
+		_, err := u.ReadFrom(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, tt.err)
+		}
+	}
+}
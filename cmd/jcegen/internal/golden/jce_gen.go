@@ -0,0 +1,398 @@
+// Code generated by jcegen. DO NOT EDIT.
+
+package golden
+
+import (
+	"fmt"
+	"io"
+
+	jce "github.com/erpc-go/jce-codec"
+)
+
+// ResetDefault 把所有字段设置为默认值
+func (m *Point) ResetDefault() {
+	*m = Point{}
+}
+
+// WriteTo 实现 jce.Messager
+func (m *Point) WriteTo(w io.Writer) (n int64, err error) {
+	e := jce.NewEncoder(w)
+	start := e.Offset()
+	if err = m.jceWriteFields(e); err == nil {
+		err = e.Flush()
+	}
+	return e.Offset() - start, err
+}
+
+func (m *Point) jceWriteFields(e *jce.Encoder) (err error) {
+	if err = e.WriteInt32(m.X, 0); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(m.Y, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值
+func (m *Point) ReadFrom(r io.Reader) (n int64, err error) {
+	d := jce.NewDecoder(r)
+	start := d.Offset()
+	m.ResetDefault()
+	err = m.jceReadFields(d)
+	return d.Offset() - start, err
+}
+
+func (m *Point) jceReadFields(d *jce.Decoder) (err error) {
+	if err = d.ReadInt32(&m.X, 0, true); err != nil {
+		return err
+	}
+	if err = d.ReadInt32(&m.Y, 1, true); err != nil {
+		return err
+	}
+	return d.SkipToStructEnd()
+}
+
+// ResetDefault 把所有字段设置为默认值
+func (m *User) ResetDefault() {
+	*m = User{}
+	m.Home.ResetDefault()
+}
+
+// WriteTo 实现 jce.Messager
+func (m *User) WriteTo(w io.Writer) (n int64, err error) {
+	e := jce.NewEncoder(w)
+	start := e.Offset()
+	if err = m.jceWriteFields(e); err == nil {
+		err = e.Flush()
+	}
+	return e.Offset() - start, err
+}
+
+func (m *User) jceWriteFields(e *jce.Encoder) (err error) {
+	if err = e.WriteInt64(m.ID, 0); err != nil {
+		return err
+	}
+	if err = e.WriteString(m.Name, 1); err != nil {
+		return err
+	}
+	if err = e.WriteUint8(m.Age, 2); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(int32(m.Color), 3); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 4); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Tags))); err != nil {
+		return err
+	}
+	for i0 := range m.Tags {
+		if err = e.WriteString(m.Tags[i0], 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteHead(jce.Map, 5); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Scores))); err != nil {
+		return err
+	}
+	for k0, v0 := range m.Scores {
+		if err = e.WriteString(k0, 0); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.List, 1); err != nil {
+			return err
+		}
+		if err = e.WriteLength(uint32(len(v0))); err != nil {
+			return err
+		}
+		for i1 := range v0 {
+			if err = e.WriteInt16(v0[i1], 0); err != nil {
+				return err
+			}
+		}
+	}
+	if err = e.WriteHead(jce.StructBegin, 6); err != nil {
+		return err
+	}
+	if _, err = m.Home.WriteTo(e); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+		return err
+	}
+	if err = e.WriteSliceUint8(m.Avatar, 7); err != nil {
+		return err
+	}
+	if err = e.WriteSliceInt8(m.Raw, 8); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 9); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Track))); err != nil {
+		return err
+	}
+	for i0 := range m.Track {
+		if err = e.WriteHead(jce.StructBegin, 0); err != nil {
+			return err
+		}
+		if _, err = m.Track[i0].WriteTo(e); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteHead(jce.Map, 10); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Marks))); err != nil {
+		return err
+	}
+	for k0, v0 := range m.Marks {
+		if err = e.WriteInt32(int32(k0), 0); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructBegin, 1); err != nil {
+			return err
+		}
+		if _, err = v0.WriteTo(e); err != nil {
+			return err
+		}
+		if err = e.WriteHead(jce.StructEnd, 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteFloat32(m.Ratio, 11); err != nil {
+		return err
+	}
+	if err = e.WriteFloat64(m.Weight, 12); err != nil {
+		return err
+	}
+	if err = e.WriteBool(m.Vip, 13); err != nil {
+		return err
+	}
+	if err = e.WriteInt32(m.Letter, 14); err != nil {
+		return err
+	}
+	if err = e.WriteHead(jce.List, 15); err != nil {
+		return err
+	}
+	if err = e.WriteLength(uint32(len(m.Pair))); err != nil {
+		return err
+	}
+	for i0 := range m.Pair {
+		if err = e.WriteUint64(m.Pair[i0], 0); err != nil {
+			return err
+		}
+	}
+	if err = e.WriteSliceUint8([]byte(m.Blob), 16); err != nil {
+		return err
+	}
+	if err = e.WriteInt16(m.Level, 17); err != nil {
+		return err
+	}
+	if err = e.WriteUint16(m.Bits, 18); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值
+func (m *User) ReadFrom(r io.Reader) (n int64, err error) {
+	d := jce.NewDecoder(r)
+	start := d.Offset()
+	m.ResetDefault()
+	err = m.jceReadFields(d)
+	return d.Offset() - start, err
+}
+
+func (m *User) jceReadFields(d *jce.Decoder) (err error) {
+	if err = d.ReadInt64(&m.ID, 0, true); err != nil {
+		return err
+	}
+	if err = d.ReadString(&m.Name, 1, false); err != nil {
+		return err
+	}
+	if err = d.ReadUint8(&m.Age, 2, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt32((*int32)(&m.Color), 3, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(4, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.Tags failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Tags = make(Tags, 0, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var v0 string
+			if err = d.ReadString(&v0, 0, true); err != nil {
+				return err
+			}
+			m.Tags = append(m.Tags, v0)
+		}
+	}
+	if ty, have, err := d.ReadHead(5, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.Map {
+			return fmt.Errorf("read User.Scores failed, want Map, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Scores = make(map[string][]int16, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var k0 string
+			var v0 []int16
+			if err = d.ReadString(&k0, 0, true); err != nil {
+				return err
+			}
+			if ty, have, err := d.ReadHead(1, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.List {
+					return fmt.Errorf("read User.Scores.value failed, want List, but got %s", ty)
+				}
+				length, err := d.ReadLength()
+				if err != nil {
+					return err
+				}
+				v0 = make([]int16, 0, jce.SizeHint(length))
+				for i1 := uint32(0); i1 < length; i1++ {
+					var v1 int16
+					if err = d.ReadInt16(&v1, 0, true); err != nil {
+						return err
+					}
+					v0 = append(v0, v1)
+				}
+			}
+			m.Scores[k0] = v0
+		}
+	}
+	if ty, have, err := d.ReadHead(6, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.StructBegin {
+			return fmt.Errorf("read User.Home failed, want StructBegin, but got %s", ty)
+		}
+		if _, err = m.Home.ReadFrom(d); err != nil {
+			return err
+		}
+	}
+	if err = d.ReadSliceUint8(&m.Avatar, 7, false); err != nil {
+		return err
+	}
+	if err = d.ReadSliceInt8(&m.Raw, 8, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(9, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.Track failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Track = make([]Point, 0, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var v0 Point
+			if ty, have, err := d.ReadHead(0, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.StructBegin {
+					return fmt.Errorf("read User.Track[] failed, want StructBegin, but got %s", ty)
+				}
+				if _, err = v0.ReadFrom(d); err != nil {
+					return err
+				}
+			}
+			m.Track = append(m.Track, v0)
+		}
+	}
+	if ty, have, err := d.ReadHead(10, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.Map {
+			return fmt.Errorf("read User.Marks failed, want Map, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		m.Marks = make(map[Color]Point, jce.SizeHint(length))
+		for i0 := uint32(0); i0 < length; i0++ {
+			var k0 Color
+			var v0 Point
+			if err = d.ReadInt32((*int32)(&k0), 0, true); err != nil {
+				return err
+			}
+			if ty, have, err := d.ReadHead(1, true); err != nil {
+				return err
+			} else if have {
+				if ty != jce.StructBegin {
+					return fmt.Errorf("read User.Marks.value failed, want StructBegin, but got %s", ty)
+				}
+				if _, err = v0.ReadFrom(d); err != nil {
+					return err
+				}
+			}
+			m.Marks[k0] = v0
+		}
+	}
+	if err = d.ReadFloat32(&m.Ratio, 11, false); err != nil {
+		return err
+	}
+	if err = d.ReadFloat64(&m.Weight, 12, false); err != nil {
+		return err
+	}
+	if err = d.ReadBool(&m.Vip, 13, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt32(&m.Letter, 14, false); err != nil {
+		return err
+	}
+	if ty, have, err := d.ReadHead(15, false); err != nil {
+		return err
+	} else if have {
+		if ty != jce.List {
+			return fmt.Errorf("read User.Pair failed, want List, but got %s", ty)
+		}
+		length, err := d.ReadLength()
+		if err != nil {
+			return err
+		}
+		if length != 2 {
+			return fmt.Errorf("read User.Pair failed, want 2 items, but got %d", length)
+		}
+		for i0 := range m.Pair {
+			if err = d.ReadUint64(&m.Pair[i0], 0, true); err != nil {
+				return err
+			}
+		}
+	}
+	if err = d.ReadSliceUint8((*[]byte)(&m.Blob), 16, false); err != nil {
+		return err
+	}
+	if err = d.ReadInt16(&m.Level, 17, false); err != nil {
+		return err
+	}
+	if err = d.ReadUint16(&m.Bits, 18, false); err != nil {
+		return err
+	}
+	return d.SkipToStructEnd()
+}
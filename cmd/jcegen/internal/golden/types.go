@@ -0,0 +1,43 @@
+// golden 为 jcegen 的测试用例，jce_gen.go 由 jcegen 生成，go test -update 时更新
+package golden
+
+//go:generate go run github.com/erpc-go/jce-codec/cmd/jcegen
+
+// Color 基于基础类型定义的类型，按 int32 读写
+type Color int32
+
+// Tags 基于 slice 定义的类型
+type Tags []string
+
+// Blob 基于 []byte 定义的类型，按 SimpleList 读写
+type Blob []byte
+
+type Point struct {
+	X int32 `jce:"0,required"`
+	Y int32 `jce:"1,required"`
+}
+
+type User struct {
+	ID     int64              `jce:"0,required"`
+	Name   string             `jce:"1"`
+	Age    uint8              `jce:"2,optional"`
+	Color  Color              `jce:"3"`
+	Tags   Tags               `jce:"4"`
+	Scores map[string][]int16 `jce:"5"`
+	Home   Point              `jce:"6"`
+	Avatar []byte             `jce:"7"`
+	Raw    []int8             `jce:"8"`
+	Track  []Point            `jce:"9"`
+	Marks  map[Color]Point    `jce:"10"`
+	Ratio  float32            `jce:"11"`
+	Weight float64            `jce:"12"`
+	Vip    bool               `jce:"13"`
+	Letter rune               `jce:"14"`
+	Pair   [2]uint64          `jce:"15"`
+	Blob   Blob               `jce:"16"`
+	Bits   uint16             `jce:"18"`
+	Level  int16              `jce:"17"`
+
+	Note  string `jce:"-"`
+	Cache string // 没有 jce tag，不参与序列化
+}
@@ -0,0 +1,70 @@
+// jcegen 为带有 jce tag 的 go struct 生成 ResetDefault、WriteTo、ReadFrom 方法，生成的代码直接调用
+// Encoder、Decoder 读写字段，不使用反射
+//
+// 用法：
+//
+//	jcegen [flags] [dir]
+//
+// 字段的 tag 为 `jce:"tag,required"` 或者 `jce:"tag,optional"`，optional 可以省略，"-" 表示不参与序列化：
+//
+//	type User struct {
+//		ID   int64  `jce:"0,required"`
+//		Name string `jce:"1"`
+//	}
+//
+// dir 默认为当前目录，一般在包中加上 //go:generate jcegen，由 go generate 调用；
+// tag 重复、字段的类型不支持（见 README 中的映射表）时报错，不生成任何文件
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	output   = flag.String("output", "jce_gen.go", "output file name, relative to dir")
+	typeList = flag.String("type", "", "comma-separated list of struct names, default is all structs with jce tags")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jcegen [flags] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "jcegen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() (err error) {
+	dir := flag.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	var names []string
+	if *typeList != "" {
+		names = strings.Split(*typeList, ",")
+	}
+
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, dir, filepath.Base(*output))
+	if err != nil {
+		return
+	}
+	src, err := generate(fset, files, names)
+	if err != nil {
+		return
+	}
+	return os.WriteFile(filepath.Join(dir, *output), src, 0o644)
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+// issue 检查出的一个问题
+type issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i *issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", i.File, i.Line, i.Column, i.Rule, i.Message)
+}
+
+// rule 一条检查规则，可以用 -disable 或者 // jcelint:ignore 单独关闭
+type rule struct {
+	name  string
+	doc   string
+	check func(l *linter, f *idl.File)
+}
+
+var rules = []*rule{
+	{"duplicate-tag", "two fields of a struct use the same tag", checkDuplicateTag},
+	{"tag-order", "field tags are not ascending in declaration order", checkTagOrder},
+	{"tag-range", "field tag is 255 or greater", checkTagRange},
+	{"new-require", "require field added to a struct that exists in the -baseline files", checkNewRequire},
+	{"enum-value", "enum member reuses the value of another member", checkEnumValue},
+	{"map-key", "map key is not a basic type or an enum", checkMapKey},
+	{"unsupported-type", "type is a go kind the codec does not support, such as uint or complex128", checkUnsupportedType},
+}
+
+// 按名字查找规则，没有时返回 nil
+func findRule(name string) *rule {
+	for _, r := range rules {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+type linter struct {
+	disabled map[string]bool
+	decls    map[string]idl.Decl    // 所有加载的 struct、enum，key 为 Module::Name
+	baseline map[string]*idl.Struct // -baseline 中的 struct，key 为 Module::Name
+
+	// 当前检查的文件
+	rule   string
+	issues []*issue
+}
+
+// all 为检查的文件以及 include 的文件，用于查找类型；baseline 为旧版本的文件，没有时不检查 new-require
+func newLinter(all, baseline []*idl.File, disabled map[string]bool) *linter {
+	l := &linter{disabled: disabled, decls: map[string]idl.Decl{}, baseline: map[string]*idl.Struct{}}
+	for _, f := range all {
+		for _, m := range f.Modules {
+			for _, decl := range m.Decls {
+				switch decl.(type) {
+				case *idl.Struct, *idl.Enum:
+					l.decls[m.Name+"::"+decl.DeclName()] = decl
+				}
+			}
+		}
+	}
+	for _, f := range baseline {
+		for _, m := range f.Modules {
+			for _, decl := range m.Decls {
+				if s, ok := decl.(*idl.Struct); ok {
+					l.baseline[m.Name+"::"+s.Name] = s
+				}
+			}
+		}
+	}
+	return l
+}
+
+// 检查一个文件，src 为文件的内容，用于判断 // jcelint:ignore 是否单独占一行
+func (l *linter) lint(f *idl.File, src []byte) (issues []*issue) {
+	// [step 1] 执行没有关闭的规则
+	l.issues = nil
+	for _, r := range rules {
+		if !l.disabled[r.name] {
+			l.rule = r.name
+			r.check(l, f)
+		}
+	}
+
+	// [step 2] 去掉注释中忽略的问题
+	ignored := ignores(f, src)
+	for _, i := range l.issues {
+		if names, ok := ignored[i.Line]; ok && (len(names) == 0 || names[i.Rule]) {
+			continue
+		}
+		issues = append(issues, i)
+	}
+	sort.SliceStable(issues, func(a, b int) bool {
+		if issues[a].Line != issues[b].Line {
+			return issues[a].Line < issues[b].Line
+		}
+		return issues[a].Column < issues[b].Column
+	})
+	return
+}
+
+func (l *linter) report(pos idl.Pos, format string, args ...any) {
+	l.issues = append(l.issues, &issue{
+		File:    pos.Filename,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Rule:    l.rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// 查找 struct、enum，没有带 module 时在当前 module 中查找；找不到时返回 nil
+func (l *linter) lookup(m *idl.Module, t *idl.Type) idl.Decl {
+	module := t.Module
+	if module == "" {
+		module = m.Name
+	}
+	return l.decls[module+"::"+t.Name]
+}
+
+// 注释 // jcelint:ignore rule1,rule2 忽略的问题，没有规则时忽略所有的规则
+// 单独占一行的注释作用于下一行，否则作用于注释所在的行；返回 行号 -> 忽略的规则
+func ignores(f *idl.File, src []byte) map[int]map[string]bool {
+	const prefix = "jcelint:ignore"
+	ignored := map[int]map[string]bool{}
+	for _, c := range f.Comments {
+		text := strings.TrimPrefix(c.Text, "//")
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+
+		line := c.Pos.Line
+		if start := strings.LastIndexByte(string(src[:c.Pos.Offset]), '\n') + 1; strings.TrimSpace(string(src[start:c.Pos.Offset])) == "" {
+			line = c.End.Line + 1
+		}
+		names := map[string]bool{}
+		if fields := strings.Fields(text[len(prefix):]); len(fields) > 0 {
+			for _, name := range strings.Split(fields[0], ",") {
+				names[name] = true
+			}
+		}
+		ignored[line] = names
+	}
+	return ignored
+}
+
+// ---------------------------------------------------------------------------
+// 规则
+// ---------------------------------------------------------------------------
+
+func eachStruct(f *idl.File, fn func(m *idl.Module, s *idl.Struct)) {
+	for _, m := range f.Modules {
+		for _, decl := range m.Decls {
+			if s, ok := decl.(*idl.Struct); ok {
+				fn(m, s)
+			}
+		}
+	}
+}
+
+// 字段、常量、方法中用到的所有类型，包括 vector、map 中的类型
+func eachType(f *idl.File, fn func(m *idl.Module, t *idl.Type)) {
+	var walk func(m *idl.Module, t *idl.Type)
+	walk = func(m *idl.Module, t *idl.Type) {
+		if t == nil {
+			return
+		}
+		fn(m, t)
+		walk(m, t.Key)
+		walk(m, t.Elem)
+	}
+
+	for _, m := range f.Modules {
+		for _, decl := range m.Decls {
+			switch d := decl.(type) {
+			case *idl.Struct:
+				for _, field := range d.Fields {
+					walk(m, field.Type)
+				}
+			case *idl.Const:
+				walk(m, d.Type)
+			case *idl.Interface:
+				for _, method := range d.Methods {
+					walk(m, method.Result)
+					for _, p := range method.Params {
+						walk(m, p.Type)
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkDuplicateTag(l *linter, f *idl.File) {
+	eachStruct(f, func(m *idl.Module, s *idl.Struct) {
+		tags := map[int]*idl.Field{}
+		for _, field := range s.Fields {
+			if prev, ok := tags[field.Tag]; ok {
+				l.report(field.TagPos, "tag %d of %s.%s is already used by %s", field.Tag, s.Name, field.Name, prev.Name)
+				continue
+			}
+			tags[field.Tag] = field
+		}
+	})
+}
+
+// 重复的 tag 由 duplicate-tag 报告，这里不再报告
+func checkTagOrder(l *linter, f *idl.File) {
+	eachStruct(f, func(m *idl.Module, s *idl.Struct) {
+		var last *idl.Field
+		tags := map[int]bool{}
+		for _, field := range s.Fields {
+			if last != nil && field.Tag < last.Tag && !tags[field.Tag] {
+				l.report(field.TagPos, "tag %d of %s.%s is less than tag %d of %s declared before it",
+					field.Tag, s.Name, field.Name, last.Tag, last.Name)
+			}
+			if last == nil || field.Tag > last.Tag {
+				last = field
+			}
+			tags[field.Tag] = true
+		}
+	})
+}
+
+func checkTagRange(l *linter, f *idl.File) {
+	eachStruct(f, func(m *idl.Module, s *idl.Struct) {
+		for _, field := range s.Fields {
+			if field.Tag >= 255 {
+				l.report(field.TagPos, "tag %d of %s.%s is out of range, must be less than 255", field.Tag, s.Name, field.Name)
+			}
+		}
+	})
+}
+
+// 旧版本的数据中没有新加的 require 字段，新版本读取时会失败
+func checkNewRequire(l *linter, f *idl.File) {
+	eachStruct(f, func(m *idl.Module, s *idl.Struct) {
+		old := l.baseline[m.Name+"::"+s.Name]
+		if old == nil {
+			return
+		}
+		for _, field := range s.Fields {
+			if !field.Require {
+				continue
+			}
+			prev := fieldByTag(old, field.Tag)
+			switch {
+			case prev == nil:
+				l.report(field.Pos, "require field %s is added to existing struct %s, make it optional", field.Name, s.Name)
+			case !prev.Require:
+				l.report(field.Pos, "field %s of %s is changed from optional to require", field.Name, s.Name)
+			}
+		}
+	})
+}
+
+func fieldByTag(s *idl.Struct, tag int) *idl.Field {
+	for _, f := range s.Fields {
+		if f.Tag == tag {
+			return f
+		}
+	}
+	return nil
+}
+
+func checkEnumValue(l *linter, f *idl.File) {
+	for _, m := range f.Modules {
+		for _, decl := range m.Decls {
+			e, ok := decl.(*idl.Enum)
+			if !ok {
+				continue
+			}
+			values, err := e.Values()
+			if err != nil {
+				var ierr *idl.Error
+				if errors.As(err, &ierr) {
+					l.report(ierr.Pos, "%s", ierr.Msg)
+				}
+				continue
+			}
+
+			used := map[int64]*idl.EnumMember{}
+			for i, member := range e.Members {
+				if prev, ok := used[values[i]]; ok {
+					l.report(member.Pos, "value %d of %s.%s is already used by %s", values[i], e.Name, member.Name, prev.Name)
+					continue
+				}
+				used[values[i]] = member
+			}
+		}
+	}
+}
+
+// go 的 map key 需要可以比较，和 jce2go 一致只支持基础类型和 enum；找不到的类型不在这里报告
+func checkMapKey(l *linter, f *idl.File) {
+	eachType(f, func(m *idl.Module, t *idl.Type) {
+		if t.Kind != idl.TypeMap {
+			return
+		}
+		switch key := t.Key; key.Kind {
+		case idl.TypeVector, idl.TypeMap:
+			l.report(key.Pos, "unsupported map key type %s, must be a basic type or an enum", key)
+		case idl.TypeNamed:
+			if _, ok := l.lookup(m, key).(*idl.Struct); ok {
+				l.report(key.Pos, "unsupported map key type %s, must be a basic type or an enum", key)
+			}
+		}
+	})
+}
+
+// README 中 go 不支持序列化的类型，值为可以替代的 IDL 类型
+var goUnsupported = map[string]string{
+	"uint":       "unsigned int",
+	"uintptr":    "long",
+	"complex64":  "",
+	"complex128": "",
+	"any":        "",
+	"comparable": "",
+	"chan":       "",
+}
+
+// IDL 的 int 就是 int32，这里检查的是写成了 uint、complex128 等 go 类型名字的字段
+func checkUnsupportedType(l *linter, f *idl.File) {
+	eachType(f, func(m *idl.Module, t *idl.Type) {
+		if t.Kind != idl.TypeNamed || t.Module != "" || l.lookup(m, t) != nil {
+			return
+		}
+		alt, ok := goUnsupported[t.Name]
+		switch {
+		case !ok:
+		case alt != "":
+			l.report(t.Pos, "type %s is not supported by the codec, use %s instead", t.Name, alt)
+		default:
+			l.report(t.Pos, "type %s is not supported by the codec", t.Name)
+		}
+	})
+}
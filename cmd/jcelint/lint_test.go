@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+const baseline = `module M
+{
+    struct User
+    {
+        0 require long id;
+        1 optional string name;
+    };
+};
+`
+
+func lintSource(t *testing.T, src string, disabled map[string]bool) string {
+	t.Helper()
+	f, err := idl.ParseFile("t.jce", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := idl.ParseFile("old.jce", []byte(baseline))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, i := range newLinter([]*idl.File{f}, []*idl.File{old}, disabled).lint(f, []byte(src)) {
+		lines = append(lines, i.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "tags",
+			src: `module M { struct S {
+0 optional int a;
+2 optional int b;
+1 optional int c;
+2 optional int d;
+255 optional int e;
+}; };`,
+			want: `t.jce:4:1: tag-order: tag 1 of S.c is less than tag 2 of b declared before it
+t.jce:5:1: duplicate-tag: tag 2 of S.d is already used by b
+t.jce:6:1: tag-range: tag 255 of S.e is out of range, must be less than 255`,
+		},
+		{
+			name: "new require",
+			src: `module M { struct User {
+0 require long id;
+1 require string name;
+2 require int age;
+}; struct Other { 0 require int a; }; };`,
+			want: `t.jce:3:1: new-require: field name of User is changed from optional to require
+t.jce:4:1: new-require: require field age is added to existing struct User, make it optional`,
+		},
+		{
+			name: "enum",
+			src:  `module M { enum E { A, B, C = 1, D = A }; enum F { X = Y }; };`,
+			want: `t.jce:1:27: enum-value: value 1 of E.C is already used by B
+t.jce:1:34: enum-value: value 0 of E.D is already used by A
+t.jce:1:56: enum-value: undefined enum member Y`,
+		},
+		{
+			name: "types",
+			src: `module M {
+  enum E { A };
+  struct K { 0 optional int a; };
+  struct S {
+    0 optional map<E, string> a;
+    1 optional map<K, string> b;
+    2 optional vector<map<vector<int>, int>> c;
+    3 optional uint d;
+    4 optional map<string, complex128> e;
+  };
+  interface I { any get(uintptr x); };
+};`,
+			want: `t.jce:6:20: map-key: unsupported map key type K, must be a basic type or an enum
+t.jce:7:27: map-key: unsupported map key type vector<int>, must be a basic type or an enum
+t.jce:8:16: unsupported-type: type uint is not supported by the codec, use unsigned int instead
+t.jce:9:28: unsupported-type: type complex128 is not supported by the codec
+t.jce:11:17: unsupported-type: type any is not supported by the codec
+t.jce:11:25: unsupported-type: type uintptr is not supported by the codec, use long instead`,
+		},
+		{
+			name: "ignore",
+			src: `module M { struct S {
+1 optional int a;
+0 optional int b; // jcelint:ignore tag-order
+// jcelint:ignore
+0 optional int c;
+/* jcelint:ignore tag-range,tag-order reason */
+255 optional int d;
+3 optional int e; // jcelint:ignore tag-range
+}; };`,
+			want: `t.jce:8:1: tag-order: tag 3 of S.e is less than tag 255 of d declared before it`,
+		},
+	}
+	for _, tt := range tests {
+		if got := lintSource(t, tt.src, nil); got != tt.want {
+			t.Errorf("%s:\n got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLintDisable(t *testing.T) {
+	src := `module M { struct S { 1 optional int a; 0 optional int b; 1 optional uint c; }; };`
+	got := lintSource(t, src, map[string]bool{"tag-order": true, "unsupported-type": true})
+	want := "t.jce:1:59: duplicate-tag: tag 1 of S.c is already used by a"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
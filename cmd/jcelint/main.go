@@ -0,0 +1,167 @@
+// jcelint 检查 .jce 文件中编解码发现不了的问题，比如重复的 tag、旧版本的 struct 中新加的 require 字段等
+//
+// 用法：
+//
+//	jcelint [flags] [path ...]
+//
+// path 可以是文件或者目录，目录中的 .jce 文件会被递归检查，include 的文件只用于查找类型，不检查。
+// 每条规则都可以用 -disable 关闭，或者在源码中用注释忽略：
+//
+//	0 require int id; // jcelint:ignore new-require,tag-order
+//
+// 单独占一行的注释作用于下一行；不写规则时忽略所有的规则。-rules 列出所有的规则。
+// 发现问题时退出码为 1，加载、解析文件出错时为 2；-format json 输出 JSON 数组，便于其他工具处理
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erpc-go/jce-codec/idl"
+)
+
+var (
+	format    = flag.String("format", "text", "output format: text (file:line:col: rule: message) or json")
+	disable   = flag.String("disable", "", "comma separated rules to disable")
+	listRules = flag.Bool("rules", false, "list all rules and exit")
+
+	includeDirs stringList
+	baselines   stringList
+)
+
+// 可以重复的参数
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+func main() {
+	flag.Var(&includeDirs, "I", "directory to search for included files, can be repeated")
+	flag.Var(&baselines, "baseline", "previous version of the files, used by new-require, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: jcelint [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *listRules {
+		for _, r := range rules {
+			fmt.Printf("%-16s %s\n", r.name, r.doc)
+		}
+		return
+	}
+	if *format != "text" && *format != "json" {
+		fatal(fmt.Errorf("unknown output format %q, want text or json", *format))
+	}
+	disabled := map[string]bool{}
+	for _, name := range strings.Split(*disable, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if findRule(name) == nil {
+			fatal(fmt.Errorf("unknown rule %q, run jcelint -rules to list all rules", name))
+		}
+		disabled[name] = true
+	}
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	issues, err := run(paths, disabled)
+	if err != nil {
+		fatal(err)
+	}
+	if *format == "json" {
+		if issues == nil {
+			issues = []*issue{}
+		}
+		out, _ := json.MarshalIndent(issues, "", "  ")
+		fmt.Printf("%s\n", out)
+	} else {
+		for _, i := range issues {
+			fmt.Println(i)
+		}
+	}
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "jcelint: %s\n", err)
+	os.Exit(2)
+}
+
+func run(paths []string, disabled map[string]bool) (issues []*issue, err error) {
+	// [step 1] 需要检查的文件
+	var names []string
+	seen := map[string]bool{}
+	for _, path := range paths {
+		err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (name == path || strings.HasSuffix(name, ".jce")) && !seen[filepath.Clean(name)] {
+				seen[filepath.Clean(name)] = true
+				names = append(names, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	// [step 2] 加载检查的文件、include 的文件以及 baseline
+	all, err := load(names)
+	if err != nil {
+		return
+	}
+	old, err := load(baselines)
+	if err != nil {
+		return
+	}
+
+	// [step 3] 只检查参数中的文件
+	l := newLinter(all, old, disabled)
+	for _, name := range names {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := idl.ParseFile(name, src)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, l.lint(f, src)...)
+	}
+	return
+}
+
+// 加载文件以及 include 的文件，同一个文件只保留一次
+func load(names []string) (files []*idl.File, err error) {
+	seen := map[string]bool{}
+	for _, name := range names {
+		loaded, err := idl.Load(name, includeDirs...)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range loaded {
+			abs, err := filepath.Abs(f.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[abs] {
+				seen[abs] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return
+}
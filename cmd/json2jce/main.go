@@ -0,0 +1,74 @@
+// json2jce 把 jce2json 格式的 JSON 转换为 jce 编码数据，格式见 jce.ToJSON
+//
+// 用法：
+//
+//	json2jce [flags] [file]
+//
+// file 为空或者 "-" 时读取 stdin，结果写入 stdout；输入可以是多个连续的 JSON 消息，逐个转换，
+// hex、base64 的输出每个消息一行；JSON 有误时输出失败的偏移，并以 1 退出
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	jce "github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce-codec/internal/cmdutil"
+)
+
+var output = flag.String("output", cmdutil.FormatRaw, "output format: raw, hex or base64")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: json2jce [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	err := run(w)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "json2jce: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer) (err error) {
+	var r io.Reader = os.Stdin
+	if name := flag.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	d := json.NewDecoder(bufio.NewReader(r))
+	for i := 1; ; i++ {
+		data, err := jce.ReadJSON(d)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("message %d: %s", i, err)
+		}
+		if data, err = cmdutil.Encode(data, *output); err != nil {
+			return err
+		}
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	}
+}
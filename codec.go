@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"time"
 )
 
 type Messager interface {
@@ -18,7 +19,15 @@ func MarshalTo(v any, w io.Writer) (err error) {
 	if !ok {
 		return errors.New("not jce Messager type")
 	}
-	_, err = m.WriteTo(w)
+	mt := metrics.Load()
+	var start time.Time
+	if mt != nil {
+		start = time.Now()
+	}
+	n, err := m.WriteTo(w)
+	if mt != nil {
+		mt.encoded(n, start, err)
+	}
 	return
 }
 
@@ -39,7 +48,15 @@ func UnmarshalFrom(r io.Reader, v any) (err error) {
 	if !ok {
 		return errors.New("not jce Messager type")
 	}
-	_, err = m.ReadFrom(r)
+	mt := metrics.Load()
+	var start time.Time
+	if mt != nil {
+		start = time.Now()
+	}
+	n, err := m.ReadFrom(r)
+	if mt != nil {
+		mt.decoded(n, start, err)
+	}
 	return
 }
 
@@ -394,6 +394,111 @@ func TestBool(t *testing.T) {
 	}
 }
 
+// 和其他类型一致，不存在的 optional 字段不修改原来的值，用于默认值；存在时覆盖原来的值
+func TestBoolAbsent(t *testing.T) {
+	data := []byte{0x00, 0x01} // tag 0: Int1(1)
+	for _, def := range []bool{true, false} {
+		got := def
+		d := NewDecoder(bytes.NewReader(data))
+		if err := d.ReadBool(&got, 10, false); err != nil {
+			t.Fatal(err)
+		}
+		if got != def {
+			t.Errorf("absent bool overwrote the default value %v", def)
+		}
+
+		got = def
+		d = NewDecoder(bytes.NewReader(data))
+		if err := d.ReadBool(&got, 0, false); err != nil {
+			t.Fatal(err)
+		}
+		if !got {
+			t.Errorf("present bool did not overwrite the default value %v", def)
+		}
+	}
+}
+
+// 查找不存在的 tag 时遇到两个字节的 head（tag >= 15），需要完整地留给后面的读取
+func TestAbsentBeforeLargeTag(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.WriteInt32(7, 20)
+	e.WriteInt32(8, 21)
+	e.Flush()
+
+	var absent, got20, got21 int32
+	d := NewDecoder(&b)
+	if err := d.ReadInt32(&absent, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReadInt32(&got20, 20, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReadInt32(&got21, 21, true); err != nil {
+		t.Fatal(err)
+	}
+	if absent != 0 || got20 != 7 || got21 != 8 {
+		t.Errorf("got %d, %d, %d, want 0, 7, 8", absent, got20, got21)
+	}
+}
+
+// tag >= 15 的 head 只有第一个字节时数据不完整，需要报错，不能当作 tag 不存在
+func TestTruncatedLargeTagHead(t *testing.T) {
+	var got int32
+	d := NewDecoder(bytes.NewReader([]byte{0x2f}))
+	if err := d.ReadInt32(&got, 1, false); err == nil {
+		t.Errorf("truncated head is treated as an absent field")
+	}
+}
+
+// 数据在需要的 tag 之前结束：optional 字段不存在，require 字段报错
+func TestAbsentAtEOF(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.WriteInt32(7, 0)
+	e.Flush()
+
+	for _, require := range []bool{false, true} {
+		a, c := int32(0), int32(3)
+		d := NewDecoder(bytes.NewReader(b.Bytes()))
+		if err := d.ReadInt32(&a, 0, true); err != nil {
+			t.Fatal(err)
+		}
+		err := d.ReadInt32(&c, 1, require)
+		if require && err == nil {
+			t.Errorf("missing require field at EOF, want error")
+		}
+		if !require && (err != nil || c != 3) {
+			t.Errorf("optional field at EOF: got %d, err = %v, want 3 and no error", c, err)
+		}
+	}
+}
+
+// 跳过 SimpleList、List 字段之后，后面的字段可以正常读取；List 中的元素不完整时报错
+func TestSkipLists(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.WriteSliceUint8([]byte{1, 2, 3}, 0)
+	e.WriteHead(List, 1)
+	e.WriteLength(2)
+	e.WriteInt32(5, 0)
+	e.WriteString("hi", 0)
+	e.WriteInt32(7, 2)
+	e.Flush()
+
+	var got int32
+	d := NewDecoder(bytes.NewReader(b.Bytes()))
+	if err := d.ReadInt32(&got, 2, true); err != nil || got != 7 {
+		t.Errorf("got %d, err = %v, want 7", got, err)
+	}
+
+	// tag 0: List，1 个元素，元素为 Int4 但只有 1 个字节
+	d = NewDecoder(bytes.NewReader([]byte{0xa0, 0x01, 0x20, 0x00}))
+	if err := d.ReadInt32(&got, 1, false); err == nil {
+		t.Errorf("truncated list element is skipped without error")
+	}
+}
+
 func TestString(t *testing.T) {
 	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	m := make(map[int]string, 0)
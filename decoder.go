@@ -21,12 +21,58 @@ import (
 type Decoder struct {
 	buf   *bufio.Reader
 	order binary.ByteOrder
+	off   *int64 // 已经读取的字节数，父子 Decoder 共享
+
+	// 字段出现情况的记录，只记录当前这一层 struct
+	trackPresence bool
+	presence      FieldSet
+
+	// 跳过的未知字段保存的位置，为 nil 时不保存
+	unknown *UnknownFields
+
+	// 是否正在记录读到的原始字节，以及记录下来的数据
+	recording bool
+	rec       []byte
+
+	// 字段掩码，sub 为最近读到的字段的子字段掩码，items 为还没读的 list、map 元素个数
+	mask     *FieldMask
+	sub      *FieldMask
+	items    int64
+	lastType JceEncodeType
+
+	// 事件的接收者，以及最近一次 ReadHead 的结果
+	obs  Observer
+	head Event
+
+	// 嵌套深度，最外层为 0
+	depth int64
 }
 
+// 如果 r 本身就是一个 Decoder，则返回一个共享底层缓冲区的子 Decoder，
+// 用于嵌套 struct 的反序列化，子 Decoder 会继承父 Decoder 的配置
 func NewDecoder(r io.Reader) *Decoder {
+	if p, ok := r.(*Decoder); ok {
+		c := &Decoder{
+			buf:           p.buf,
+			order:         p.order,
+			off:           p.off,
+			trackPresence: p.trackPresence,
+			obs:           p.obs,
+			depth:         p.depth + 1,
+		}
+		if mt := metrics.Load(); mt != nil {
+			mt.depth(c.depth)
+		}
+		if p.mask != nil {
+			c.mask = p.sub
+		}
+		return c
+	}
+
 	return &Decoder{
 		buf:   bufio.NewReader(r),
 		order: defulatByteOrder,
+		off:   new(int64),
 	}
 }
 
@@ -39,90 +85,137 @@ func (d *Decoder) ReadHead(tag byte, require bool) (t JceEncodeType, have bool,
 
 // 反序列化一个长度字段
 func (d *Decoder) ReadLength() (length uint32, err error) {
-	return d.readLength()
+	if length, err = d.readLength(); err != nil {
+		return
+	}
+	d.enterItems(length)
+	if d.obs != nil {
+		d.observeLength(length)
+	}
+	return
 }
 
 // 反序列化 int8
 func (d *Decoder) ReadInt8(data *int8, tag byte, require bool) (err error) {
-	return d.readInt1((*uint8)(unsafe.Pointer(data)), tag, require)
+	if err = d.readInt1((*uint8)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 uint8
 func (d *Decoder) ReadUint8(data *uint8, tag byte, require bool) (err error) {
-	return d.readInt1(data, tag, require)
+	if err = d.readInt1(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 int16
 func (d *Decoder) ReadInt16(data *int16, tag byte, require bool) (err error) {
-	return d.readInt2((*uint16)(unsafe.Pointer(data)), tag, require)
+	if err = d.readInt2((*uint16)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 uint16
 func (d *Decoder) ReadUint16(data *uint16, tag byte, require bool) (err error) {
-	return d.readInt2(data, tag, require)
+	if err = d.readInt2(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 int32
 func (d *Decoder) ReadInt32(data *int32, tag byte, require bool) (err error) {
-	return d.readInt4((*uint32)(unsafe.Pointer(data)), tag, require)
+	if err = d.readInt4((*uint32)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 uint32
 func (d *Decoder) ReadUint32(data *uint32, tag byte, require bool) (err error) {
-	return d.readInt4(data, tag, require)
+	if err = d.readInt4(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 int64
 func (d *Decoder) ReadInt64(data *int64, tag byte, require bool) (err error) {
-	return d.readInt8((*uint64)(unsafe.Pointer(data)), tag, require)
+	if err = d.readInt8((*uint64)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 uint64
 func (d *Decoder) ReadUint64(data *uint64, tag byte, require bool) (err error) {
-	return d.readInt8(data, tag, require)
+	if err = d.readInt8(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 float32
 func (d *Decoder) ReadFloat32(data *float32, tag byte, require bool) (err error) {
-	return d.readFloat4(data, tag, require)
+	if err = d.readFloat4(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 float64
 func (d *Decoder) ReadFloat64(data *float64, tag byte, require bool) (err error) {
-	return d.readFloat8((*float64)(unsafe.Pointer(data)), tag, require)
+	if err = d.readFloat8((*float64)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, 0)
+	}
+	return
 }
 
 // 反序列化 bool
 func (d *Decoder) ReadBool(data *bool, tag byte, require bool) (err error) {
-	// [step 1] 读取
+	// [step 1] 读取，字段不存在时 tmp 不变，保留原来的值
 	var tmp uint8
+	if *data {
+		tmp = 1
+	}
 	if err = d.readInt1(&tmp, tag, require); err != nil {
-		return fmt.Errorf("read bool failed, err: %s", err)
+		return fmt.Errorf("read bool failed, err: %w", err)
 	}
 
 	// [step 2] 如果为 0，则为 false
-	if tmp == 0 {
-		*data = false
-		return
+	*data = tmp != 0
+	if d.obs != nil {
+		d.observeValue(tag, *data, 0)
 	}
-
-	*data = true
 	return
 }
 
 // 反序列化 string
 func (d *Decoder) ReadString(data *string, tag byte, require bool) (err error) {
-	return d.readString(data, tag, require)
+	if err = d.readString(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, len(*data))
+	}
+	return
 }
 
 // 反序列化 []uint8
 func (d *Decoder) ReadSliceUint8(data *[]uint8, tag byte, require bool) (err error) {
-	return d.readSimpleList(data, tag, require)
+	if err = d.readSimpleList(data, tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, len(*data))
+	}
+	return
 }
 
 // 反序列化 []int8
 func (d *Decoder) ReadSliceInt8(data *[]int8, tag byte, require bool) (err error) {
-	return d.readSimpleList((*[]uint8)(unsafe.Pointer(data)), tag, require)
+	if err = d.readSimpleList((*[]uint8)(unsafe.Pointer(data)), tag, require); err == nil && d.obs != nil {
+		d.observeValue(tag, *data, len(*data))
+	}
+	return
 }
 
 // return reader
@@ -130,6 +223,18 @@ func (d *Decoder) Reader() (reader *bufio.Reader) {
 	return d.buf
 }
 
+// 实现 io.Reader，这样嵌套 struct 可以直接 ReadFrom(d)，从而共享 Decoder 的配置
+func (d *Decoder) Read(p []byte) (n int, err error) {
+	n, err = d.buf.Read(p)
+	*d.off += int64(n)
+	return
+}
+
+// 返回从开始读取以来已经消费的字节数，嵌套的子 Decoder 和父 Decoder 共享这个计数
+func (d *Decoder) Offset() int64 {
+	return *d.off
+}
+
 // read struct begin type
 func (d *Decoder) ReadStructBegin() (err error) {
 	return d.readStructBegin()
@@ -2,6 +2,7 @@ package jce
 
 import (
 	"fmt"
+	"io"
 	"math"
 )
 
@@ -9,14 +10,44 @@ import (
 // 通用类型编码函数
 // ---------------------------------------------------------------------------
 
+// TypeMismatchError 字段的类型和读取的类型不匹配
+type TypeMismatchError struct {
+	Name string // 读取的类型，比如 int32、string
+	Tag  byte
+	Type JceEncodeType // 数据中实际的类型
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("read '%s' type mismatch, tag:%d, get type:%s", e.Name, e.Tag, e.Type)
+}
+
 // readHead
 //
 //go:nosplit
 func (d *Decoder) readHeadC(tag byte, require bool) (t JceEncodeType, have bool, err error) {
+	// [step 0] 被掩码过滤掉的 tag 当作不存在，数据留给后面的读取跳过
+	if d.masked(tag) {
+		if d.obs != nil {
+			d.observeHead(EventAbsent, 0, tag, *d.off)
+		}
+		return t, false, nil
+	}
+
 	for {
-		// [step 1] 读取一个 head
-		curType, curTag, err := d.readHead()
+		// [step 1] 先 peek 一个 head，tag 不存在时不需要回退
+		start := *d.off
+		curType, curTag, n, err := d.peekHead()
 		if err != nil {
+			// 数据已经结束，说明 tag 不存在，和读到 StructEnd 时一样处理
+			if err == io.EOF && require {
+				return curType, false, &MissingFieldsError{Tags: []byte{tag}}
+			}
+			if err == io.EOF {
+				if d.obs != nil {
+					d.observeHead(EventAbsent, 0, tag, start)
+				}
+				return curType, false, nil
+			}
 			return curType, false, err
 		}
 
@@ -24,22 +55,40 @@ func (d *Decoder) readHeadC(tag byte, require bool) (t JceEncodeType, have bool,
 		if curType == StructEnd || curTag > tag {
 			// [step 2.1] 如果需要存在，但是却不存在，则返回错误
 			if require {
-				return curType, false, fmt.Errorf("can not find Tag %d. get tag: %d, get type: %d", tag, curTag, curType)
+				return curType, false, &MissingFieldsError{Tags: []byte{tag}}
+			}
+			// [step 2.2] 如果虽然不存在，但是不是必须的，则只返回读取失败即可，head 留给后面的读取
+			if d.obs != nil {
+				d.observeHead(EventAbsent, 0, tag, start)
 			}
-			// [step 2.2] 如果虽然不存在，但是不是必须的，则只返回读取失败即可
-			// 多读了一个head, 退回去.
-			d.unreadHead(curTag)
 			return curType, false, nil
 		}
 
 		// [step 3] 如果找到了对应的 tag
+		if err = d.skip(n); err != nil {
+			return curType, false, err
+		}
 		if curTag == tag {
+			d.markPresent(curTag)
+			d.enterField(curType, curTag)
+			if d.obs != nil {
+				d.observeHead(EventFound, curType, curTag, start)
+			}
 			return curType, true, nil
 		}
 
+		// 比需要的 tag 小的字段虽然会被跳过，但也是出现过的
+		d.markPresent(curTag)
+
 		// [step 4]  如果现在的 tag 比需要的 tag 小，则需要继续读取，先跳过当前 tag 余下的数据
-		if err = d.skipField(curType); err != nil {
-			return curType, false, fmt.Errorf("skip type  %s'data filed, err:%s", curType, err)
+		if d.obs != nil {
+			d.observeHead(EventSkip, curType, curTag, start)
+		}
+		if mt := metrics.Load(); mt != nil {
+			mt.fieldsSkipped.Add(1)
+		}
+		if err = d.skipUnknown(curType, curTag); err != nil {
+			return curType, false, fmt.Errorf("skip type  %s'data filed, err:%w", curType, err)
 		}
 
 		// [step 5] 继续读取下一个 tag
@@ -80,7 +129,7 @@ func (d *Decoder) readInt1(data *uint8, tag byte, require bool) (err error) {
 	// [step 1] 读取 head
 	t, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 	if !have { // tag 不存在,但是不要求必须存在
 		return nil
@@ -95,7 +144,7 @@ func (d *Decoder) readInt1(data *uint8, tag byte, require bool) (err error) {
 		*data, err = d.readByte()
 		return
 	default: // 如果不是支持的 type
-		return fmt.Errorf("read 'int1' type mismatch, tag:%d, get type:%s", tag, t)
+		return &TypeMismatchError{Name: "int1", Tag: tag, Type: t}
 	}
 }
 
@@ -106,7 +155,7 @@ func (d *Decoder) readInt2(data *uint16, tag byte, require bool) (err error) {
 	// [step 1] 读取 head
 	ty, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -122,7 +171,7 @@ func (d *Decoder) readInt2(data *uint16, tag byte, require bool) (err error) {
 		var tmp uint8
 		tmp, err = d.readByte()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int2'data length is 1byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int2'data length is 1byte, err:%w", err)
 		}
 		*data = uint16(tmp)
 		return
@@ -130,7 +179,7 @@ func (d *Decoder) readInt2(data *uint16, tag byte, require bool) (err error) {
 		*data, err = d.readByte2()
 		return
 	default:
-		return fmt.Errorf("read 'int2' type mismatch, tag:%d, get type:%s", tag, ty)
+		return &TypeMismatchError{Name: "int2", Tag: tag, Type: ty}
 	}
 }
 
@@ -141,7 +190,7 @@ func (d *Decoder) readInt4(data *uint32, tag byte, require bool) (err error) {
 	// [step 1] 读取 head
 	ty, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -157,7 +206,7 @@ func (d *Decoder) readInt4(data *uint32, tag byte, require bool) (err error) {
 		var tmp uint8
 		tmp, err = d.readByte()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int32'data length is 1byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int32'data length is 1byte, err:%w", err)
 		}
 		*data = uint32(tmp)
 		return
@@ -165,7 +214,7 @@ func (d *Decoder) readInt4(data *uint32, tag byte, require bool) (err error) {
 		var tmp uint16
 		tmp, err = d.readByte2()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int32'data length is 2byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int32'data length is 2byte, err:%w", err)
 		}
 		*data = uint32(tmp)
 		return
@@ -173,12 +222,12 @@ func (d *Decoder) readInt4(data *uint32, tag byte, require bool) (err error) {
 		var tmp uint32
 		tmp, err = d.readByte4()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int32'data length is 4byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int32'data length is 4byte, err:%w", err)
 		}
 		*data = tmp
 		return
 	default:
-		return fmt.Errorf("read 'int32' type mismatch, tag:%d, get type:%s", tag, ty)
+		return &TypeMismatchError{Name: "int32", Tag: tag, Type: ty}
 	}
 }
 
@@ -189,7 +238,7 @@ func (d *Decoder) readInt8(data *uint64, tag byte, require bool) (err error) {
 	// [step 1] 读取 head
 	ty, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -205,7 +254,7 @@ func (d *Decoder) readInt8(data *uint64, tag byte, require bool) (err error) {
 		var tmp uint8
 		tmp, err = d.readByte()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int64'data length is 1byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int64'data length is 1byte, err:%w", err)
 		}
 		*data = uint64(tmp)
 		return
@@ -213,7 +262,7 @@ func (d *Decoder) readInt8(data *uint64, tag byte, require bool) (err error) {
 		var tmp uint16
 		tmp, err = d.readByte2()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int64'data length is 2byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int64'data length is 2byte, err:%w", err)
 		}
 		*data = uint64(tmp)
 		return
@@ -221,7 +270,7 @@ func (d *Decoder) readInt8(data *uint64, tag byte, require bool) (err error) {
 		var tmp uint32
 		tmp, err = d.readByte4()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int64'data length is 4byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int64'data length is 4byte, err:%w", err)
 		}
 		*data = uint64(tmp)
 		return
@@ -229,12 +278,12 @@ func (d *Decoder) readInt8(data *uint64, tag byte, require bool) (err error) {
 		var tmp uint64
 		tmp, err = d.readByte8()
 		if err != nil {
-			return fmt.Errorf("read data failed, when int64'data length is 8byte, err:%s", err)
+			return fmt.Errorf("read data failed, when int64'data length is 8byte, err:%w", err)
 		}
 		*data = tmp
 		return
 	default:
-		return fmt.Errorf("read 'int64' type mismatch, tag:%d, get type:%s", tag, ty)
+		return &TypeMismatchError{Name: "int64", Tag: tag, Type: ty}
 	}
 }
 
@@ -245,7 +294,7 @@ func (d *Decoder) readFloat4(data *float32, tag byte, require bool) (err error)
 	// [step 1] 读取 head
 	ty, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -261,12 +310,12 @@ func (d *Decoder) readFloat4(data *float32, tag byte, require bool) (err error)
 		var tmp uint32
 		tmp, err = d.readByte4()
 		if err != nil {
-			return fmt.Errorf("read data failed, when float32'data length is 4byte, err:%s", err)
+			return fmt.Errorf("read data failed, when float32'data length is 4byte, err:%w", err)
 		}
 		*data = math.Float32frombits(tmp)
 		return
 	default:
-		return fmt.Errorf("read 'float' type mismatch, tag:%d, get type:%s", tag, ty)
+		return &TypeMismatchError{Name: "float", Tag: tag, Type: ty}
 	}
 }
 
@@ -277,7 +326,7 @@ func (d *Decoder) readFloat8(data *float64, tag byte, require bool) (err error)
 	// [step 1] 读取 head
 	ty, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -305,12 +354,12 @@ func (d *Decoder) readFloat8(data *float64, tag byte, require bool) (err error)
 		var tmp uint64
 		tmp, err = d.readByte8()
 		if err != nil {
-			return fmt.Errorf("read data failed, when float64'data length is 8byte, err:%s", err)
+			return fmt.Errorf("read data failed, when float64'data length is 8byte, err:%w", err)
 		}
 		*data = math.Float64frombits(tmp)
 		return
 	default:
-		return fmt.Errorf("read 'double' type mismatch, tag:%d, get type:%s", tag, ty)
+		return &TypeMismatchError{Name: "double", Tag: tag, Type: ty}
 	}
 }
 
@@ -321,7 +370,7 @@ func (d *Decoder) readString(data *string, tag byte, require bool) (err error) {
 	// [step 1] 读取 head
 	t, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -329,20 +378,20 @@ func (d *Decoder) readString(data *string, tag byte, require bool) (err error) {
 	}
 
 	if t != String {
-		return fmt.Errorf("want type %s, but got %s", String, JceEncodeType(t))
+		return &TypeMismatchError{Name: "string", Tag: tag, Type: t}
 	}
 
 	// [step 2] 读长度
-	length, err := d.ReadLength()
+	length, err := d.readLength()
 	if err != nil {
-		return fmt.Errorf("read string length failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read string length failed, tag:%d, err:%w", tag, err)
 	}
 
 	var buff []byte
 
 	// [step 3] 读具体数据
 	if buff, err = d.readByteN(int(length)); err != nil {
-		return fmt.Errorf("read string1' data failed, tag,:%d error:%v", tag, err)
+		return fmt.Errorf("read string1' data failed, tag,:%d error:%w", tag, err)
 	}
 
 	*data = string(buff)
@@ -356,7 +405,7 @@ func (d *Decoder) readSimpleList(data *[]byte, tag byte, require bool) (err erro
 	// [step 1] 读取 head
 	t, have, err := d.ReadHead(tag, require)
 	if err != nil { // 读取失败
-		return fmt.Errorf("read head failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
 	}
 
 	if !have { // tag 不存在,但是不要求必须存在
@@ -364,19 +413,19 @@ func (d *Decoder) readSimpleList(data *[]byte, tag byte, require bool) (err erro
 	}
 
 	if JceEncodeType(t) != SimpleList {
-		return fmt.Errorf("need simpleList type, but %s, tag:%d", t, tag)
+		return &TypeMismatchError{Name: "simpleList", Tag: tag, Type: t}
 	}
 
 	// [step 2] 读数据长度
 	length, err := d.readByte4()
 	if err != nil {
-		return fmt.Errorf("read data item length failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read data item length failed, tag:%d, err:%w", tag, err)
 	}
 
 	// [setp 3] 读 item type
 	itemType, err := d.readByte()
 	if err != nil {
-		return fmt.Errorf("read item type failed, tag:%d, err:%s", tag, err)
+		return fmt.Errorf("read item type failed, tag:%d, err:%w", tag, err)
 	}
 
 	if JceEncodeType(itemType) != Int1 {
@@ -385,7 +434,7 @@ func (d *Decoder) readSimpleList(data *[]byte, tag byte, require bool) (err erro
 
 	// [setp 4] 读数据
 	if *data, err = d.readByteN(int(length)); err != nil {
-		err = fmt.Errorf("read []uint8 error:%v", err)
+		err = fmt.Errorf("read []uint8 error:%w", err)
 	}
 
 	return
@@ -13,7 +13,15 @@ import (
 //
 //go:nosplit
 func (d *Decoder) readByte() (data uint8, err error) {
-	return d.buf.ReadByte()
+	if data, err = d.buf.ReadByte(); err != nil {
+		return
+	}
+	*d.off++
+
+	if d.recording {
+		d.recordByte(data)
+	}
+	return
 }
 
 // 读取两个字节
@@ -27,6 +35,8 @@ func (d *Decoder) readByte2() (data uint16, err error) {
 	if _, err = io.ReadFull(d.buf, b); err != nil {
 		return
 	}
+	*d.off += int64(len(b))
+	d.record(b)
 
 	// [step 3] 转换字节序
 	return d.order.Uint16(b), nil
@@ -43,6 +53,8 @@ func (d *Decoder) readByte4() (data uint32, err error) {
 	if _, err = io.ReadFull(d.buf, b); err != nil {
 		return
 	}
+	*d.off += int64(len(b))
+	d.record(b)
 
 	// [step 3] 转换字节序
 	return d.order.Uint32(b), nil
@@ -59,6 +71,8 @@ func (d *Decoder) readByte8() (data uint64, err error) {
 	if _, err = io.ReadFull(d.buf, b); err != nil {
 		return
 	}
+	*d.off += int64(len(b))
+	d.record(b)
 
 	// [step 3] 转换字节序
 	return d.order.Uint64(b), nil
@@ -73,7 +87,41 @@ func (d *Decoder) readByteN(n int) (data []byte, err error) {
 
 	// [step 2] 开始读
 	if _, err = io.ReadFull(d.buf, data); err != nil {
-		return nil, fmt.Errorf("read n bytes failed, err:%s", err)
+		return nil, fmt.Errorf("read n bytes failed, err:%w", err)
+	}
+	*d.off += int64(n)
+	d.record(data)
+
+	return
+}
+
+// 如果正在记录原始字节，则把读到的数据追加到记录中
+func (d *Decoder) record(data []byte) {
+	if d.recording {
+		d.rec = append(d.rec, data...)
+	}
+}
+
+// 记录一个字节
+func (d *Decoder) recordByte(data byte) {
+	d.rec = append(d.rec, data)
+}
+
+// 读取 type 类型字段的数据部分（不包括 head）的原始字节
+// 复用 skipField 的逻辑找到字段的结尾，跳过的同时记录下读到的字节
+func (d *Decoder) readFieldRaw(ty JceEncodeType) (raw []byte, err error) {
+	// [step 1] 保存外层的记录状态，开始新的记录
+	recording, rec := d.recording, d.rec
+	d.recording, d.rec = true, nil
+
+	// [step 2] 跳过字段
+	err = d.skipField(ty)
+	raw = d.rec
+
+	// [step 3] 恢复外层的记录，外层也需要这部分数据
+	d.recording, d.rec = recording, append(rec, raw...)
+	if !recording {
+		d.rec = nil
 	}
 
 	return
@@ -106,14 +154,28 @@ func (d *Decoder) readHead() (ty JceEncodeType, tag byte, err error) {
 	return
 }
 
-// unreadHead 回退一个head byte， curTag 为当前读到的tag信息，当tag超过4位时则回退两个head byte
+// peekHead 读取下一个 head 但不移动位置，n 为 head 占用的字节数
+// bufio.Reader 只能回退一个字节，tag 超过 4 位的 head 有两个字节，所以先 peek，需要时再跳过
 //
 //go:nosplit
-func (d *Decoder) unreadHead(curTag byte) {
-	_ = d.buf.UnreadByte()
-	if curTag >= 15 {
-		_ = d.buf.UnreadByte()
+func (d *Decoder) peekHead() (ty JceEncodeType, tag byte, n int, err error) {
+	data, err := d.buf.Peek(1)
+	if err != nil {
+		return
+	}
+	ty, tag, n = JceEncodeType(data[0]>>4), data[0]&0x0f, 1
+	if tag != 15 {
+		return
+	}
+
+	// 两个字节的 head 只有第一个字节时数据不完整，不能当作数据已经结束
+	if data, err = d.buf.Peek(2); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
 	}
+	return ty, data[1], 2, nil
 }
 
 // 跳过 type 类型个字节, 不包括 head 部分
@@ -156,7 +218,14 @@ func (d *Decoder) skipField(ty JceEncodeType) (err error) {
 //
 //go:nosplit
 func (d *Decoder) skip(n int) (err error) {
-	_, err = d.buf.Discard(n)
+	// 记录原始字节时，不能直接丢弃
+	if d.recording {
+		_, err = d.readByteN(n)
+		return
+	}
+
+	n, err = d.buf.Discard(n)
+	*d.off += int64(n)
 	return
 }
 
@@ -165,7 +234,7 @@ func (d *Decoder) skip(n int) (err error) {
 //go:nosplit
 func (d *Decoder) skipFieldString() (err error) {
 	// [step 1] 读长度
-	length, err := d.ReadLength()
+	length, err := d.readLength()
 	if err != nil {
 		return
 	}
@@ -178,7 +247,7 @@ func (d *Decoder) skipFieldString() (err error) {
 //go:nosplit
 func (d *Decoder) skipFieldMap() (err error) {
 	// [step 1] 读 item 的 长度
-	length, err := d.ReadLength()
+	length, err := d.readLength()
 	if err != nil {
 		return err
 	}
@@ -208,7 +277,7 @@ func (d *Decoder) skipFieldMap() (err error) {
 //go:nosplit
 func (d *Decoder) skipFieldList() (err error) {
 	// [step 1] 读长度
-	length, err := d.ReadLength()
+	length, err := d.readLength()
 	if err != nil {
 		return err
 	}
@@ -223,7 +292,9 @@ func (d *Decoder) skipFieldList() (err error) {
 		}
 
 		// [step 2.2] 跳 data
-		_ = d.skipField(t)
+		if err = d.skipField(t); err != nil {
+			return
+		}
 	}
 
 	return
@@ -233,8 +304,8 @@ func (d *Decoder) skipFieldList() (err error) {
 //
 //go:nosplit
 func (d *Decoder) skipFieldSimpleList() error {
-	// [step 1] 读数据长度
-	length, err := d.ReadLength()
+	// [step 1] 读数据长度，和 writeSimpleList 一致，固定为 4B
+	length, err := d.readByte4()
 	if err != nil {
 		return err
 	}
@@ -272,3 +343,20 @@ func (d *Decoder) skipToStructEnd() (err error) {
 
 	return
 }
+
+// 从 b 的开头解析一个 head，规则同 readHead，返回 head 占用的字节数
+func parseHead(b []byte) (ty JceEncodeType, tag byte, n int, err error) {
+	if len(b) < 1 {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+
+	ty, tag = JceEncodeType(b[0]>>4), b[0]&0x0f
+	if tag != 15 {
+		return ty, tag, 1, nil
+	}
+
+	if len(b) < 2 {
+		return 0, 0, 0, io.ErrUnexpectedEOF
+	}
+	return ty, b[1], 2, nil
+}
@@ -0,0 +1,271 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ---------------------------------------------------------------------------
+// 两个版本消息之间的差量
+// 差量本身也是 jce 编码的 struct：
+//   - tag 0: struct，新增或者修改的字段，保持原来的 tag
+//   - tag 1: SimpleList，被删除的 tag
+//   - tag 2: map<uint8, struct>，嵌套 struct 的差量，key 为 tag，value 为同样格式的差量
+// 没有内容的部分不写，两个消息完全一致时差量为空
+// ---------------------------------------------------------------------------
+
+// 差量中各部分的 tag
+const (
+	deltaTagChanged byte = 0
+	deltaTagDeleted byte = 1
+	deltaTagNested  byte = 2
+)
+
+// 一层 struct 的差量
+type structDelta struct {
+	changed []byte          // 新增或者修改的字段
+	deleted []byte          // 删除的 tag
+	nested  map[byte][]byte // 嵌套 struct 的差量（已编码）
+	order   []byte          // nested 的 key，按 tag 从小到大
+}
+
+// 计算从 old 到 new 的差量
+func Delta(old, new []byte) (patch []byte, err error) {
+	return deltaStruct(old, new)
+}
+
+// 把差量应用到 old 上，ApplyDelta(old, Delta(old, new)) 和 new 语义一致
+func ApplyDelta(old, patch []byte) (out []byte, err error) {
+	return applyStruct(old, patch)
+}
+
+// 计算一层 struct 的差量，并编码
+func deltaStruct(old, new []byte) (patch []byte, err error) {
+	// [step 1] 拆分字段
+	of, err := splitFields(old)
+	if err != nil {
+		return nil, fmt.Errorf("split old failed, err:%s", err)
+	}
+	nf, err := splitFields(new)
+	if err != nil {
+		return nil, fmt.Errorf("split new failed, err:%s", err)
+	}
+	sortFields(of)
+	sortFields(nf)
+
+	// [step 2] 按 tag 归并比较
+	var sd structDelta
+	i, j := 0, 0
+	for i < len(of) || j < len(nf) {
+		switch {
+		// old 中有，new 中没有，删除
+		case j == len(nf) || (i < len(of) && of[i].Tag < nf[j].Tag):
+			sd.deleted = append(sd.deleted, of[i].Tag)
+			i++
+
+		// new 中新增
+		case i == len(of) || nf[j].Tag < of[i].Tag:
+			sd.changed = append(sd.changed, nf[j].Data...)
+			j++
+
+		// 都有
+		default:
+			if err = sd.compare(of[i], nf[j]); err != nil {
+				return nil, fmt.Errorf("delta tag %d failed, err:%s", nf[j].Tag, err)
+			}
+			i, j = i+1, j+1
+		}
+	}
+
+	return sd.encode()
+}
+
+// 比较 tag 相同的两个字段
+func (sd *structDelta) compare(o, n RawField) (err error) {
+	// [step 1] 完全一样，不需要记录
+	if bytes.Equal(o.Data, n.Data) {
+		return
+	}
+
+	// [step 2] 都是 struct，尝试递归计算差量，比整个字段小时才使用
+	if o.Type == StructBegin && n.Type == StructBegin {
+		nested, err := deltaStruct(o.Payload(), n.Payload())
+		if err != nil {
+			return err
+		}
+		if len(nested) < len(n.Data) {
+			if sd.nested == nil {
+				sd.nested = make(map[byte][]byte)
+			}
+			sd.nested[n.Tag] = nested
+			sd.order = append(sd.order, n.Tag)
+			return nil
+		}
+	}
+
+	// [step 3] 否则整个字段替换
+	sd.changed = append(sd.changed, n.Data...)
+	return
+}
+
+// 编码差量
+func (sd *structDelta) encode() (patch []byte, err error) {
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+
+	// [step 1] 修改的字段
+	if len(sd.changed) > 0 {
+		if err = e.WriteHead(StructBegin, deltaTagChanged); err != nil {
+			return
+		}
+		if err = e.writeByteN(sd.changed); err != nil {
+			return
+		}
+		if err = e.WriteHead(StructEnd, 0); err != nil {
+			return
+		}
+	}
+
+	// [step 2] 删除的 tag
+	if len(sd.deleted) > 0 {
+		if err = e.WriteSliceUint8(sd.deleted, deltaTagDeleted); err != nil {
+			return
+		}
+	}
+
+	// [step 3] 嵌套的差量
+	if len(sd.order) > 0 {
+		if err = e.WriteHead(Map, deltaTagNested); err != nil {
+			return
+		}
+		if err = e.WriteLength(uint32(len(sd.order))); err != nil {
+			return
+		}
+		for _, tag := range sd.order {
+			if err = e.WriteUint8(tag, 0); err != nil {
+				return
+			}
+			if err = e.WriteHead(StructBegin, 1); err != nil {
+				return
+			}
+			if err = e.writeByteN(sd.nested[tag]); err != nil {
+				return
+			}
+			if err = e.WriteHead(StructEnd, 0); err != nil {
+				return
+			}
+		}
+	}
+
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// 解析差量
+func decodeDelta(patch []byte) (sd structDelta, err error) {
+	d := NewDecoder(bytes.NewReader(patch))
+
+	// [step 1] 修改的字段
+	changed, _, err := d.ReadRaw(deltaTagChanged, false)
+	if err != nil {
+		return
+	}
+	if changed != nil {
+		sd.changed = RawField{Data: changed}.Payload()
+	}
+
+	// [step 2] 删除的 tag
+	if err = d.ReadSliceUint8(&sd.deleted, deltaTagDeleted, false); err != nil {
+		return
+	}
+
+	// [step 3] 嵌套的差量
+	_, have, err := d.ReadHead(deltaTagNested, false)
+	if err != nil || !have {
+		return
+	}
+	length, err := d.ReadLength()
+	if err != nil {
+		return
+	}
+	sd.nested = make(map[byte][]byte, length)
+	for i := uint32(0); i < length; i++ {
+		var tag uint8
+		if err = d.ReadUint8(&tag, 0, true); err != nil {
+			return
+		}
+		var nested []byte
+		if nested, _, err = d.ReadRaw(1, true); err != nil {
+			return
+		}
+		sd.nested[tag] = RawField{Data: nested}.Payload()
+	}
+
+	return
+}
+
+// 把差量应用到一层 struct 上
+func applyStruct(old, patch []byte) (out []byte, err error) {
+	// [step 1] 解析差量
+	sd, err := decodeDelta(patch)
+	if err != nil {
+		return nil, fmt.Errorf("decode delta failed, err:%s", err)
+	}
+	changed, err := splitFields(sd.changed)
+	if err != nil {
+		return nil, fmt.Errorf("split changed fields failed, err:%s", err)
+	}
+
+	// [step 2] 拆分 old
+	of, err := splitFields(old)
+	if err != nil {
+		return nil, fmt.Errorf("split old failed, err:%s", err)
+	}
+
+	// [step 3] 去掉删除和修改的字段，嵌套的 struct 递归应用
+	var removed FieldSet
+	for _, tag := range sd.deleted {
+		removed.Add(tag)
+	}
+	for _, f := range changed {
+		removed.Add(f.Tag)
+	}
+
+	applied := 0
+	fields := make([]RawField, 0, len(of)+len(changed))
+	for _, f := range of {
+		if removed.Has(f.Tag) {
+			continue
+		}
+
+		if nested, ok := sd.nested[f.Tag]; ok {
+			applied++
+			if f.Type != StructBegin {
+				return nil, fmt.Errorf("apply delta to tag %d failed, want %s but got %s", f.Tag, StructBegin, f.Type)
+			}
+			body, err := applyStruct(f.Payload(), nested)
+			if err != nil {
+				return nil, fmt.Errorf("apply delta to tag %d failed, err:%s", f.Tag, err)
+			}
+			data := appendHead(nil, StructBegin, f.Tag)
+			f.Data = appendHead(append(data, body...), StructEnd, 0)
+		}
+		fields = append(fields, f)
+	}
+
+	if applied != len(sd.nested) {
+		return nil, fmt.Errorf("apply delta failed, nested struct not found in old")
+	}
+
+	// [step 4] 加上修改的字段，按 tag 排序输出
+	fields = append(fields, changed...)
+	sortFields(fields)
+
+	out = make([]byte, 0, len(old)+len(sd.changed))
+	for _, f := range fields {
+		out = append(out, f.Data...)
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package jce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	long := stringValue(strings.Repeat("x", 200))
+	old := structValue(
+		Field{Tag: 0, Value: intValue(1)},
+		Field{Tag: 1, Value: long},
+		Field{Tag: 2, Value: structValue(
+			Field{Tag: 0, Value: long},
+			Field{Tag: 1, Value: intValue(2)},
+			Field{Tag: 2, Value: structValue(
+				Field{Tag: 0, Value: long},
+				Field{Tag: 5, Value: stringValue("deep")},
+			)},
+		)},
+		Field{Tag: 3, Value: stringValue("removed")},
+		Field{Tag: 4, Value: intValue(4)},
+	)
+
+	tests := []struct {
+		name    string
+		new     Value
+		compact bool // 差量是否应该比 new 小
+	}{
+		{
+			name:    "same",
+			new:     old,
+			compact: true,
+		},
+		{
+			name:    "changed",
+			compact: true,
+			new: structValue(
+				Field{Tag: 0, Value: intValue(10)},
+				Field{Tag: 1, Value: long},
+				Field{Tag: 2, Value: structValue(
+					Field{Tag: 0, Value: long},
+					Field{Tag: 1, Value: stringValue("type changed")},
+					Field{Tag: 2, Value: structValue(
+						Field{Tag: 0, Value: long},
+						Field{Tag: 6, Value: stringValue("added")},
+					)},
+				)},
+				Field{Tag: 4, Value: intValue(4)},
+				Field{Tag: 9, Value: stringValue("added")},
+			),
+		},
+		{
+			name: "replace struct",
+			new: structValue(
+				Field{Tag: 2, Value: structValue()},
+				Field{Tag: 3, Value: Value{Type: List, List: []Value{intValue(1)}}},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldData := mustEncodeValue(t, old)
+			newData := mustEncodeValue(t, tt.new)
+
+			patch, err := Delta(oldData, newData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.compact && len(patch) >= len(newData) {
+				t.Errorf("delta too large, patch:%d, new:%d", len(patch), len(newData))
+			}
+
+			out, err := ApplyDelta(oldData, patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodeValue(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.new.String() {
+				t.Errorf("apply delta failed\nwant:%s\ngot: %s", tt.new, got)
+			}
+		})
+	}
+}
+
+func TestDeltaSame(t *testing.T) {
+	data := mustEncodeValue(t, structValue(Field{Tag: 1, Value: stringValue("a")}))
+	patch, err := Delta(data, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 0 {
+		t.Errorf("want empty patch, got:%v", patch)
+	}
+}
+
+func TestApplyDeltaMismatch(t *testing.T) {
+	long := Field{Tag: 1, Value: stringValue(strings.Repeat("x", 100))}
+	old := mustEncodeValue(t, structValue(Field{Tag: 1, Value: structValue(Field{Tag: 0, Value: intValue(1)}, long)}))
+	new := mustEncodeValue(t, structValue(Field{Tag: 1, Value: structValue(Field{Tag: 0, Value: intValue(2)}, long)}))
+	patch, err := Delta(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 嵌套的差量应用到不是 struct 的字段上
+	other := mustEncodeValue(t, structValue(Field{Tag: 1, Value: intValue(1)}))
+	if _, err := ApplyDelta(other, patch); err == nil {
+		t.Error("want error when applying nested delta to non struct")
+	}
+}
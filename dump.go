@@ -0,0 +1,244 @@
+package jce
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 以文本的形式输出编码数据的字段树，用于排查抓包得到的数据，每行的格式为：
+//
+//	偏移  缩进 tag 或者元素的位置  类型  长度  值
+//
+// 解析失败时会输出失败的位置以及附近的字节，并返回错误
+// ---------------------------------------------------------------------------
+
+// DumpOptions Dump 的选项
+type DumpOptions struct {
+	Offset    int // 从 data 的这个位置开始解析，输出的偏移都是相对 data 开头的
+	MaxDepth  int // 最多输出的层数，最外层为第 1 层，更深的 struct、list、map 只输出大小，0 表示不限制
+	MaxString int // string、SimpleList 最多输出的字节数，0 表示不限制
+}
+
+// 把 data 的字段树输出到 w
+func Dump(w io.Writer, data []byte, opts DumpOptions) (err error) {
+	if opts.Offset < 0 || opts.Offset > len(data) {
+		return fmt.Errorf("offset %d out of range, length:%d", opts.Offset, len(data))
+	}
+
+	p := &dumper{
+		w:    w,
+		d:    NewDecoder(bytes.NewReader(data[opts.Offset:])),
+		data: data,
+		opts: opts,
+	}
+	if err = p.dumpStruct(0, false); err == nil {
+		return nil
+	}
+
+	// 解析失败，输出失败的位置以及当前字段开始的字节
+	at := p.pos(p.d.Offset())
+	p.printf("%06d  !! parse error: %s\n", at, err)
+	if start := p.pos(p.start); start < len(data) {
+		end := start + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		p.printf("%06d  !! field bytes: % x\n", start, data[start:end])
+	}
+	return fmt.Errorf("dump failed at offset %d, err:%s", at, err)
+}
+
+type dumper struct {
+	w     io.Writer
+	d     *Decoder
+	data  []byte
+	opts  DumpOptions
+	start int64 // 正在解析的字段的开始位置
+}
+
+// 相对 data 开头的偏移
+func (p *dumper) pos(off int64) int {
+	return p.opts.Offset + int(off)
+}
+
+func (p *dumper) printf(format string, args ...any) {
+	fmt.Fprintf(p.w, format, args...)
+}
+
+// 输出一行，off 为相对解析开始的位置
+func (p *dumper) line(off int64, depth int, format string, args ...any) {
+	p.printf("%06d  %s%s\n", p.pos(off), strings.Repeat("  ", depth), fmt.Sprintf(format, args...))
+}
+
+// 输出 struct 内的字段，直到 StructEnd 或者数据结束
+func (p *dumper) dumpStruct(depth int, nested bool) (err error) {
+	for {
+		// [step 1] 读 head
+		start := p.d.Offset()
+		p.start = start
+		ty, tag, err := p.d.readHead()
+		if err == io.EOF && !nested {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// [step 2] struct 结束
+		if ty == StructEnd {
+			if nested {
+				p.line(start, depth-1, "}")
+				return nil
+			}
+			p.line(start, depth, "StructEnd")
+			continue
+		}
+
+		// [step 3] 输出字段
+		if err = p.dumpField(start, depth, "tag:"+strconv.Itoa(int(tag)), ty); err != nil {
+			return err
+		}
+	}
+}
+
+// 输出一个字段，head 已经读取，start 为 head 的位置，label 为 tag 或者元素的位置
+func (p *dumper) dumpField(start int64, depth int, label string, ty JceEncodeType) (err error) {
+	p.start = start
+
+	// [step 1] 超过最大层数的容器只输出大小
+	if ty == StructBegin || ty == List || ty == Map {
+		if p.opts.MaxDepth > 0 && depth+1 >= p.opts.MaxDepth {
+			if err = p.d.skipField(ty); err != nil {
+				return
+			}
+			p.line(start, depth, "%s %s ... (%d bytes)", label, ty, p.d.Offset()-start)
+			return
+		}
+	}
+
+	switch ty {
+	// [step 2] struct
+	case StructBegin:
+		p.line(start, depth, "%s %s {", label, ty)
+		return p.dumpStruct(depth+1, true)
+
+	// [step 3] list
+	case List:
+		length, err := p.d.readLength()
+		if err != nil {
+			return err
+		}
+		p.line(start, depth, "%s %s len:%d [", label, ty, length)
+		for i := uint32(0); i < length; i++ {
+			if err = p.dumpItem(depth+1, "["+strconv.Itoa(int(i))+"]"); err != nil {
+				return err
+			}
+		}
+		p.line(p.d.Offset(), depth, "]")
+
+	// [step 4] map，key、value 分别输出
+	case Map:
+		length, err := p.d.readLength()
+		if err != nil {
+			return err
+		}
+		p.line(start, depth, "%s %s len:%d {", label, ty, length)
+		for i := uint32(0); i < length; i++ {
+			index := strconv.Itoa(int(i))
+			if err = p.dumpItem(depth+1, "key["+index+"]"); err != nil {
+				return err
+			}
+			if err = p.dumpItem(depth+1, "value["+index+"]"); err != nil {
+				return err
+			}
+		}
+		p.line(p.d.Offset(), depth, "}")
+
+	// [step 5] string
+	case String:
+		length, err := p.d.readLength()
+		if err != nil {
+			return err
+		}
+		data, err := p.readN(length)
+		if err != nil {
+			return err
+		}
+		s, more := p.truncate(data)
+		p.line(start, depth, "%s %s len:%d %q%s", label, ty, length, s, more)
+
+	// [step 6] SimpleList，按 16 进制输出
+	case SimpleList:
+		length, err := p.d.readByte4()
+		if err != nil {
+			return err
+		}
+		if _, err = p.d.readByte(); err != nil {
+			return err
+		}
+		data, err := p.readN(length)
+		if err != nil {
+			return err
+		}
+		s, more := p.truncate(data)
+		p.line(start, depth, "%s %s len:%d %s%s", label, ty, length, hex.EncodeToString(s), more)
+
+	// [step 7] 数字
+	default:
+		v, err := p.d.readValue(ty)
+		if err != nil {
+			return err
+		}
+		p.line(start, depth, "%s %s %s", label, ty, formatNumber(v))
+	}
+	return
+}
+
+// 输出 list、map 中的一个元素
+func (p *dumper) dumpItem(depth int, label string) (err error) {
+	start := p.d.Offset()
+	p.start = start
+	ty, _, err := p.d.readHead()
+	if err != nil {
+		return
+	}
+	return p.dumpField(start, depth, label, ty)
+}
+
+// 读取 n 个字节，损坏的数据中长度可能很大，先检查剩余的数据是否足够
+func (p *dumper) readN(n uint32) (data []byte, err error) {
+	if remain := len(p.data) - p.pos(p.d.Offset()); int64(n) > int64(remain) {
+		return nil, fmt.Errorf("length %d exceeds remaining %d bytes", n, remain)
+	}
+	return p.d.readByteN(int(n))
+}
+
+// 按 MaxString 截断
+func (p *dumper) truncate(data []byte) (s []byte, more string) {
+	if p.opts.MaxString <= 0 || len(data) <= p.opts.MaxString {
+		return data, ""
+	}
+	return data[:p.opts.MaxString], fmt.Sprintf("...(+%d bytes)", len(data)-p.opts.MaxString)
+}
+
+// 数字的文本形式，整数的符号扩展和无符号的值不同时两个都输出
+func formatNumber(v Value) string {
+	switch v.Type {
+	case Zero:
+		return "0"
+	case Float4:
+		return strconv.FormatFloat(v.Float, 'g', -1, 32)
+	case Float8:
+		return strconv.FormatFloat(v.Float, 'g', -1, 64)
+	default:
+		if s := v.signed(); s < 0 {
+			return fmt.Sprintf("%d (%d)", v.Int, s)
+		}
+		return strconv.FormatUint(v.Int, 10)
+	}
+}
@@ -0,0 +1,95 @@
+package jce
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// tag 0 的 7，tag 1 的 "hello"，tag 2 的 struct，tag 3 的 list，tag 4 的 map
+var dumpData = []byte{
+	0x00, 7,
+	0x71, 5, 'h', 'e', 'l', 'l', 'o',
+	0xb2, 0x00, 1, 0xc0,
+	0xa3, 1, 0x00, 0xfe,
+	0x84, 1, 0x70, 1, 'k', 0x01, 9,
+}
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		opts DumpOptions
+		want string
+		err  bool
+	}{
+		{
+			name: "all",
+			data: dumpData,
+			want: `000000  tag:0 Int1 7
+000002  tag:1 String len:5 "hello"
+000009  tag:2 StructBegin {
+000010    tag:0 Int1 1
+000012  }
+000013  tag:3 List len:1 [
+000015    [0] Int1 254 (-2)
+000017  ]
+000017  tag:4 Map len:1 {
+000019    key[0] String len:1 "k"
+000022    value[0] Int1 9
+000024  }
+`,
+		},
+		{
+			name: "options",
+			data: dumpData,
+			opts: DumpOptions{Offset: 2, MaxDepth: 1, MaxString: 2},
+			want: `000002  tag:1 String len:5 "he"...(+3 bytes)
+000009  tag:2 StructBegin ... (4 bytes)
+000013  tag:3 List ... (4 bytes)
+000017  tag:4 Map ... (7 bytes)
+`,
+		},
+		{
+			name: "truncated",
+			data: dumpData[:len(dumpData)-3],
+			want: `000000  tag:0 Int1 7
+000002  tag:1 String len:5 "hello"
+000009  tag:2 StructBegin {
+000010    tag:0 Int1 1
+000012  }
+000013  tag:3 List len:1 [
+000015    [0] Int1 254 (-2)
+000017  ]
+000017  tag:4 Map len:1 {
+000021  !! parse error: length 1 exceeds remaining 0 bytes
+000019  !! field bytes: 70 01
+`,
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := Dump(&b, tt.data, tt.opts)
+			if (err != nil) != tt.err {
+				t.Fatalf("Dump() error = %v, want error %v", err, tt.err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Dump() output\nwant:\n%s\ngot:\n%s", tt.want, b.String())
+			}
+		})
+	}
+}
+
+func TestDumpError(t *testing.T) {
+	var b bytes.Buffer
+	if err := Dump(&b, dumpData, DumpOptions{Offset: len(dumpData) + 1}); err == nil {
+		t.Fatal("want error for offset out of range")
+	}
+	err := Dump(&b, dumpData[:len(dumpData)-3], DumpOptions{})
+	if err == nil || !strings.Contains(err.Error(), "offset 21") {
+		t.Fatalf("Dump() error = %v, want error at offset 21", err)
+	}
+}
@@ -11,12 +11,54 @@ import (
 type Encoder struct {
 	buf   *bufio.Writer
 	order binary.ByteOrder
+
+	// 等待写入的未知字段，按 tag 从小到大排列；unknownStack 为外层 struct 的未知字段
+	unknown      UnknownFields
+	unknownStack []UnknownFields
+
+	// 是否使用规范编码
+	canonical bool
+
+	// 字段掩码，buf 为当前的输出，未选中的字段写入 trash，out 为真正的输出
+	mask     *FieldMask
+	sub      *FieldMask
+	items    int64
+	lastType JceEncodeType
+	out      *bufio.Writer
+	trash    *bufio.Writer
+
+	// 已经写入的字节数，父子 Encoder 共享
+	off *int64
+
+	// 事件的接收者，以及最近写的 head
+	obs  Observer
+	head Event
 }
 
+// 如果 w 本身就是一个 Encoder，则返回一个共享底层缓冲区的子 Encoder，
+// 用于嵌套 struct 的序列化，子 Encoder 会继承父 Encoder 的配置
 func NewEncoder(w io.Writer) *Encoder {
+	if p, ok := w.(*Encoder); ok {
+		c := &Encoder{
+			buf:       p.buf,
+			order:     p.order,
+			canonical: p.canonical,
+			out:       p.out, // 父 Encoder 正在丢弃数据时，子 Encoder 写入的数据也不计入
+			off:       p.off,
+			obs:       p.obs,
+		}
+		if p.mask != nil {
+			c.mask = p.sub
+		}
+		return c
+	}
+
+	buf := bufio.NewWriter(w)
 	return &Encoder{
-		buf:   bufio.NewWriter(w),
+		buf:   buf,
 		order: defulatByteOrder,
+		out:   buf,
+		off:   new(int64),
 	}
 }
 
@@ -48,52 +90,82 @@ func (e *Encoder) WriteHead(t JceEncodeType, tag byte) (err error) {
 // | type  | tag |  data  |
 // |----------------------|
 func (e *Encoder) WriteInt8(data int8, tag byte) (err error) {
-	return e.writeInt1((uint8)(data), tag)
+	if err = e.writeInt1((uint8)(data), tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 uint8
 func (e *Encoder) WriteUint8(data uint8, tag byte) (err error) {
-	return e.writeInt1(data, tag)
+	if err = e.writeInt1(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 int16
 func (e *Encoder) WriteInt16(data int16, tag byte) (err error) {
-	return e.writeInt2((uint16)(data), tag)
+	if err = e.writeInt2((uint16)(data), tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 uint16
 func (e *Encoder) WriteUint16(data uint16, tag byte) (err error) {
-	return e.writeInt2(data, tag)
+	if err = e.writeInt2(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 int32
 func (e *Encoder) WriteInt32(data int32, tag byte) (err error) {
-	return e.writeInt4((uint32)(data), tag)
+	if err = e.writeInt4((uint32)(data), tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 uint32
 func (e *Encoder) WriteUint32(data uint32, tag byte) (err error) {
-	return e.writeInt4(data, tag)
+	if err = e.writeInt4(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 int64
 func (e *Encoder) WriteInt64(data int64, tag byte) (err error) {
-	return e.writeInt8((uint64)(data), tag)
+	if err = e.writeInt8((uint64)(data), tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 uint64
 func (e *Encoder) WriteUint64(data uint64, tag byte) (err error) {
-	return e.writeInt8(data, tag)
+	if err = e.writeInt8(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 float32
 func (e *Encoder) WriteFloat32(data float32, tag byte) (err error) {
-	return e.writeFloat4(data, tag)
+	if err = e.writeFloat4(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 float64
 func (e *Encoder) WriteFloat64(data float64, tag byte) (err error) {
-	return e.writeFloat8(data, tag)
+	if err = e.writeFloat8(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 序列化 bool
@@ -103,7 +175,10 @@ func (e *Encoder) WriteBool(data bool, tag byte) (err error) {
 	if data {
 		tmp = 1
 	}
-	return e.writeInt1(tmp, tag)
+	if err = e.writeInt1(tmp, tag); err == nil && e.obs != nil {
+		e.observeValue(data, 0)
+	}
+	return
 }
 
 // 主要是 vector<string> 这种情况，写内部 string 时，也每次都写了个 tag，都默认是 0，感觉不太好，这个是无效信息
@@ -114,7 +189,10 @@ func (e *Encoder) WriteBool(data bool, tag byte) (err error) {
 // |---------------------------------------|
 // 注意点在于根据长度选择 length 字段的字节数，这个主要是进行了优化
 func (e *Encoder) WriteString(data string, tag byte) (err error) {
-	return e.writeStringC(data, tag)
+	if err = e.writeStringC(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, len(data))
+	}
+	return
 }
 
 // []uint8 类型的序列化，方案如下：
@@ -122,22 +200,35 @@ func (e *Encoder) WriteString(data string, tag byte) (err error) {
 // | simpleList head | data length | data type | data |
 // ----------------------------------------------------
 func (e *Encoder) WriteSliceUint8(data []uint8, tag byte) (err error) {
-	return e.writeSimpleList(data, tag)
+	if err = e.writeSimpleList(data, tag); err == nil && e.obs != nil {
+		e.observeValue(data, len(data))
+	}
+	return
 }
 
 // []int8 类型的序列化，同 []uint8
 func (e *Encoder) WriteSliceInt8(data []int8, tag byte) (err error) {
-	return e.writeSimpleList(*(*[]uint8)(unsafe.Pointer(&data)), tag)
+	if err = e.writeSimpleList(*(*[]uint8)(unsafe.Pointer(&data)), tag); err == nil && e.obs != nil {
+		e.observeValue(data, len(data))
+	}
+	return
 }
 
 // 序列化一个长度字段
 func (e *Encoder) WriteLength(length uint32) (err error) {
-	return e.writeLength(length)
+	if err = e.writeLength(length); err != nil {
+		return
+	}
+	e.enterItems(length)
+	if e.obs != nil {
+		e.observeLength(length)
+	}
+	return
 }
 
 // 将缓存刷新到 writer 中，最后都要手动调这个函数
 func (e *Encoder) Flush() (err error) {
-	return e.buf.Flush()
+	return e.out.Flush()
 }
 
 // return writer
@@ -145,6 +236,19 @@ func (e *Encoder) Writer() (writer *bufio.Writer) {
 	return e.buf
 }
 
+// 实现 io.Writer，这样嵌套 struct 可以直接 WriteTo(e)，从而共享 Encoder 的配置
+func (e *Encoder) Write(p []byte) (n int, err error) {
+	n, err = e.buf.Write(p)
+	e.advance(n)
+	return
+}
+
+// 返回已经写入的字节数，包括还在缓冲区中的数据，嵌套的子 Encoder 和父 Encoder 共享这个计数
+// 直接通过 Writer() 写入的数据不计入
+func (e *Encoder) Offset() int64 {
+	return *e.off
+}
+
 // write struct begin type
 func (e *Encoder) WriteStructBegin() (err error) {
 	return e.writeByte(uint8(StructBegin))
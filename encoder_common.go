@@ -13,6 +13,31 @@ import (
 func (e *Encoder) writeHead(t JceEncodeType, tag byte) (err error) {
 	ty := byte(t)
 
+	// [step 0] 如果有 tag 更小的未知字段，先写入，保证 tag 是递增的
+	if len(e.unknown) > 0 && e.unknown[0].Tag < tag {
+		if err = e.writeUnknownBefore(int(tag)); err != nil {
+			return
+		}
+	}
+
+	// 未知字段只属于当前这一层 struct，嵌套的 struct 直接写在同一个 Encoder 上时，
+	// 进入时先保存起来，结束时写完里层剩余的未知字段，再恢复外层的
+	switch {
+	case t == StructBegin:
+		e.unknownStack = append(e.unknownStack, e.unknown)
+		e.unknown = nil
+	case t == StructEnd && len(e.unknownStack) > 0:
+		if err = e.WriteUnknownFields(); err != nil {
+			return
+		}
+		e.unknown = e.unknownStack[len(e.unknownStack)-1]
+		e.unknownStack = e.unknownStack[:len(e.unknownStack)-1]
+	}
+	e.enterField(t, tag)
+	if e.obs != nil {
+		e.observeHead(t, tag, *e.off)
+	}
+
 	// [setp 1] 如果 tag < 15,就直接写一个字节，即 type、tag 各占 4bit
 	if tag < 15 {
 		return e.writeByte((ty << 4) | tag)
@@ -118,7 +143,7 @@ func (e *Encoder) writeFloat4(data float32, tag byte) (err error) {
 	}
 
 	// [step 3] 然后写数据
-	return e.writeByte4(math.Float32bits(data))
+	return e.writeByte4(e.float32bits(data))
 }
 
 //go:nosplit
@@ -142,7 +167,7 @@ func (e *Encoder) writeFloat8(data float64, tag byte) (err error) {
 	}
 
 	// [step 3] 然后写数据
-	return e.writeByte8(math.Float64bits(data))
+	return e.writeByte8(e.float64bits(data))
 }
 
 //go:nosplit
@@ -153,7 +178,7 @@ func (e *Encoder) writeStringC(data string, tag byte) (err error) {
 	}
 
 	// [step 2] 写长度
-	if err = e.WriteLength(uint32(len(data))); err != nil {
+	if err = e.writeLength(uint32(len(data))); err != nil {
 		return
 	}
 
@@ -8,7 +8,8 @@ package jce
 //
 //go:nosplit
 func (e *Encoder) writeByteN(data []byte) (err error) {
-	_, err = e.buf.Write(data)
+	n, err := e.buf.Write(data)
+	e.advance(n)
 	return
 }
 
@@ -16,7 +17,10 @@ func (e *Encoder) writeByteN(data []byte) (err error) {
 //
 //go:nosplit
 func (e *Encoder) writeByte(data uint8) (err error) {
-	return e.buf.WriteByte(data)
+	if err = e.buf.WriteByte(data); err == nil {
+		e.advance(1)
+	}
+	return
 }
 
 // 写入两个字节
@@ -30,7 +34,8 @@ func (e *Encoder) writeByte2(data uint16) (err error) {
 	e.order.PutUint16(b, data)
 
 	// [step 3] 写
-	_, err = e.buf.Write(b)
+	n, err := e.buf.Write(b)
+	e.advance(n)
 	return
 }
 
@@ -45,7 +50,8 @@ func (e *Encoder) writeByte4(data uint32) (err error) {
 	e.order.PutUint32(b, data)
 
 	// [step 3] 写
-	_, err = e.buf.Write(b)
+	n, err := e.buf.Write(b)
+	e.advance(n)
 	return
 }
 
@@ -60,7 +66,8 @@ func (e *Encoder) writeByte8(data uint64) (err error) {
 	e.order.PutUint64(b, data)
 
 	// [step 3] 写
-	_, err = e.buf.Write(b)
+	n, err := e.buf.Write(b)
+	e.advance(n)
 	return
 }
 
@@ -68,6 +75,32 @@ func (e *Encoder) writeByte8(data uint64) (err error) {
 //
 //go:nosplit
 func (e *Encoder) writeString(s string) (err error) {
-	_, err = e.buf.WriteString(s)
+	n, err := e.buf.WriteString(s)
+	e.advance(n)
 	return err
 }
+
+// 累加写入的字节数，被字段掩码丢弃的数据不计入
+//
+//go:nosplit
+func (e *Encoder) advance(n int) {
+	if e.buf == e.out {
+		*e.off += int64(n)
+	}
+}
+
+// 把 head 编码追加到 b 后面，编码规则同 writeHead
+func appendHead(b []byte, t JceEncodeType, tag byte) []byte {
+	if tag < 15 {
+		return append(b, byte(t)<<4|tag)
+	}
+	return append(b, byte(t)<<4|15, tag)
+}
+
+// 把长度字段编码追加到 b 后面，编码规则同 writeLength
+func appendLength(b []byte, length uint32) []byte {
+	if length <= 127 {
+		return append(b, uint8(length))
+	}
+	return defulatByteOrder.AppendUint32(b, length|0x80000000)
+}
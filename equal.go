@@ -0,0 +1,222 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// 编码数据的语义比较
+// 同一个值可能有不同的编码（Int2 和 Int4、Zero 和 Int1(0)、map 的顺序不同），
+// 直接比较字节会误判，这里按值比较：
+//   1. 整数和 readInt* 一样按无符号零扩展后比较，Zero 等于任何数字 0
+//   2. Float4、Float8 只和同类型比较，NaN 等于 NaN
+//   3. map 不考虑顺序，struct 按 tag 比较，不考虑字段顺序
+// ---------------------------------------------------------------------------
+
+// Difference 两个值第一处不同的地方
+type Difference struct {
+	Path   Path   // 不同的字段的路径，map 中非字符串、整数的 key 使用其在 a 中的下标
+	Reason string // 不同的原因
+}
+
+func (d *Difference) String() string {
+	if len(d.Path) == 0 {
+		return d.Reason
+	}
+	return d.Path.String() + ": " + d.Reason
+}
+
+// 比较两个消息是否语义相等
+func Equal(a, b []byte) (equal bool, err error) {
+	diff, err := EqualReport(a, b)
+	return err == nil && diff == nil, err
+}
+
+// 比较两个消息，相等时返回 nil，否则返回第一处不同的地方
+func EqualReport(a, b []byte) (diff *Difference, err error) {
+	va, err := DecodeValue(a)
+	if err != nil {
+		return nil, fmt.Errorf("decode a failed, err:%s", err)
+	}
+	vb, err := DecodeValue(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode b failed, err:%s", err)
+	}
+	return compareValue(nil, va, vb), nil
+}
+
+// 比较两个值
+func compareValue(path Path, a, b Value) *Difference {
+	// [step 1] 数字单独比较
+	if a.IsNumber() && b.IsNumber() {
+		if numberEqual(a, b) {
+			return nil
+		}
+		return &Difference{Path: path, Reason: fmt.Sprintf("%s != %s", a, b)}
+	}
+
+	// [step 2] 其他类型必须一致
+	if a.Type != b.Type {
+		return &Difference{Path: path, Reason: fmt.Sprintf("type %s != %s", a.Type, b.Type)}
+	}
+
+	switch a.Type {
+	case String, SimpleList:
+		if !bytes.Equal(a.Bytes, b.Bytes) {
+			return &Difference{Path: path, Reason: fmt.Sprintf("%s != %s", a, b)}
+		}
+		return nil
+	case List:
+		return compareList(path, a.List, b.List)
+	case Map:
+		return compareMap(path, a.Map, b.Map)
+	case StructBegin:
+		return compareStruct(path, a.Fields, b.Fields)
+	default:
+		return nil
+	}
+}
+
+// 数字是否相等
+func numberEqual(a, b Value) bool {
+	// [step 1] 都是 0
+	if isZeroNumber(a) && isZeroNumber(b) {
+		return true
+	}
+
+	switch {
+	// [step 2] 整数按零扩展后的值比较
+	case a.Type <= Int8 && b.Type <= Int8:
+		return a.Int == b.Int
+	// [step 3] 浮点数只和同类型比较
+	case a.Type == b.Type && (a.Type == Float4 || a.Type == Float8):
+		return a.Float == b.Float || (math.IsNaN(a.Float) && math.IsNaN(b.Float))
+	default:
+		return false
+	}
+}
+
+// 是否为数字 0
+func isZeroNumber(v Value) bool {
+	switch v.Type {
+	case Zero:
+		return true
+	case Int1, Int2, Int4, Int8:
+		return v.Int == 0
+	case Float4, Float8:
+		return v.Float == 0
+	default:
+		return false
+	}
+}
+
+// 按顺序比较 list
+func compareList(path Path, a, b []Value) *Difference {
+	if len(a) != len(b) {
+		return &Difference{Path: path, Reason: fmt.Sprintf("length %d != %d", len(a), len(b))}
+	}
+	for i := range a {
+		if diff := compareValue(path.append(PathStep{Kind: StepIndex, Index: int64(i)}), a[i], b[i]); diff != nil {
+			return diff
+		}
+	}
+	return nil
+}
+
+// 不考虑顺序比较 map，key 按规范编码后的字节匹配
+func compareMap(path Path, a, b []MapEntry) *Difference {
+	// [step 1] 建立 b 的索引
+	index := make(map[string]int, len(b))
+	for i, entry := range b {
+		key, err := canonicalKey(entry.Key)
+		if err != nil {
+			return &Difference{Path: path, Reason: fmt.Sprintf("invalid key %s", entry.Key)}
+		}
+		index[key] = i
+	}
+
+	// [step 2] 逐个比较 a 中的 key
+	for i, entry := range a {
+		key, err := canonicalKey(entry.Key)
+		if err != nil {
+			return &Difference{Path: path, Reason: fmt.Sprintf("invalid key %s", entry.Key)}
+		}
+
+		sub := path.append(keyStep(entry.Key, i))
+		j, ok := index[key]
+		if !ok {
+			return &Difference{Path: sub, Reason: "key missing in b"}
+		}
+		if diff := compareValue(sub, entry.Value, b[j].Value); diff != nil {
+			return diff
+		}
+		delete(index, key)
+	}
+
+	// [step 3] b 中多出来的 key，按 b 中的顺序找第一个
+	if len(index) > 0 {
+		for j := range b {
+			key, _ := canonicalKey(b[j].Key)
+			if i, ok := index[key]; ok && i == j {
+				return &Difference{Path: path.append(keyStep(b[j].Key, j)), Reason: "key missing in a"}
+			}
+		}
+	}
+	return nil
+}
+
+// 按 tag 比较 struct，相同的 tag 出现多次时按顺序比较
+func compareStruct(path Path, a, b []Field) *Difference {
+	a, b = sortedFields(a), sortedFields(b)
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].Tag < b[j].Tag):
+			return &Difference{Path: path.append(PathStep{Kind: StepTag, Tag: a[i].Tag}), Reason: "tag missing in b"}
+		case i == len(a) || b[j].Tag < a[i].Tag:
+			return &Difference{Path: path.append(PathStep{Kind: StepTag, Tag: b[j].Tag}), Reason: "tag missing in a"}
+		default:
+			sub := path.append(PathStep{Kind: StepTag, Tag: a[i].Tag})
+			if diff := compareValue(sub, a[i].Value, b[j].Value); diff != nil {
+				return diff
+			}
+			i, j = i+1, j+1
+		}
+	}
+	return nil
+}
+
+// 返回按 tag 稳定排序后的字段，不修改原来的切片
+func sortedFields(fields []Field) []Field {
+	sorted := append([]Field(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Tag < sorted[j].Tag
+	})
+	return sorted
+}
+
+// map key 的规范编码，语义相等的 key 编码一定相同
+func canonicalKey(key Value) (s string, err error) {
+	c, err := canonicalValue(key)
+	if err != nil {
+		return
+	}
+	b, err := encodeCanonicalItem(c)
+	return string(b), err
+}
+
+// map key 对应的路径，字符串、整数 key 直接使用 key，其他类型使用下标
+func keyStep(key Value, i int) PathStep {
+	switch key.Type {
+	case String:
+		return PathStep{Kind: StepKey, Key: string(key.Bytes)}
+	case Zero, Int1, Int2, Int4, Int8:
+		return PathStep{Kind: StepIndex, Index: int64(key.Int)}
+	default:
+		return PathStep{Kind: StepIndex, Index: int64(i)}
+	}
+}
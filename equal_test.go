@@ -0,0 +1,139 @@
+package jce
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	base := structValue(
+		Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+		Field{Tag: 2, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("a"), Value: Value{Type: Zero}},
+			{Key: Value{Type: Int1, Int: 5}, Value: stringValue("five")},
+		}}},
+		Field{Tag: 3, Value: Value{Type: List, List: []Value{
+			structValue(Field{Tag: 0, Value: Value{Type: Float8, Float: math.NaN()}}),
+		}}},
+	)
+
+	tests := []struct {
+		name  string
+		other Value
+		diff  string
+	}{
+		{
+			name: "same value different encoding",
+			other: structValue(
+				Field{Tag: 3, Value: Value{Type: List, List: []Value{
+					structValue(Field{Tag: 0, Value: Value{Type: Float8, Float: math.NaN()}}),
+				}}},
+				Field{Tag: 1, Value: Value{Type: Int2, Int: 300}},
+				Field{Tag: 2, Value: Value{Type: Map, Map: []MapEntry{
+					{Key: Value{Type: Int8, Int: 5}, Value: stringValue("five")},
+					{Key: stringValue("a"), Value: Value{Type: Int1, Int: 0}},
+				}}},
+			),
+		},
+		{
+			name: "int changed",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 301}},
+			),
+			diff: "1: Int4(300) != Int4(301)",
+		},
+		{
+			name: "missing tag",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+				Field{Tag: 3, Value: Value{Type: Zero}},
+			),
+			diff: "2: tag missing in b",
+		},
+		{
+			name: "map value changed",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+				Field{Tag: 2, Value: Value{Type: Map, Map: []MapEntry{
+					{Key: stringValue("a"), Value: Value{Type: Zero}},
+					{Key: Value{Type: Int1, Int: 5}, Value: stringValue("six")},
+				}}},
+			),
+			diff: `2[5]: "five" != "six"`,
+		},
+		{
+			name: "map key missing",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+				Field{Tag: 2, Value: Value{Type: Map, Map: []MapEntry{
+					{Key: stringValue("b"), Value: Value{Type: Zero}},
+					{Key: Value{Type: Int1, Int: 5}, Value: stringValue("five")},
+				}}},
+			),
+			diff: `2["a"]: key missing in b`,
+		},
+		{
+			name: "map key missing in a",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+				Field{Tag: 2, Value: Value{Type: Map, Map: []MapEntry{
+					{Key: stringValue("a"), Value: Value{Type: Zero}},
+					{Key: Value{Type: Int1, Int: 5}, Value: stringValue("five")},
+					{Key: stringValue("b"), Value: Value{Type: Zero}},
+				}}},
+			),
+			diff: `2["b"]: key missing in a`,
+		},
+		{
+			name: "nested list",
+			other: structValue(
+				Field{Tag: 1, Value: Value{Type: Int4, Int: 300}},
+				Field{Tag: 2, Value: base.Fields[1].Value},
+				Field{Tag: 3, Value: Value{Type: List, List: []Value{
+					structValue(Field{Tag: 0, Value: Value{Type: Float4, Float: 1}}),
+				}}},
+			),
+			diff: "3[0].0: Float8(NaN) != Float4(1)",
+		},
+		{
+			name: "type changed",
+			other: structValue(
+				Field{Tag: 1, Value: stringValue("300")},
+			),
+			diff: "1: type Int4 != String",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := mustEncodeValue(t, base), mustEncodeValue(t, tt.other)
+
+			diff, err := EqualReport(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			equal, err := Equal(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.diff == "" {
+				if diff != nil || !equal {
+					t.Errorf("want equal, got diff:%s", diff)
+				}
+				return
+			}
+			if diff == nil || equal {
+				t.Fatalf("want diff %s, got equal", tt.diff)
+			}
+			if diff.String() != tt.diff {
+				t.Errorf("diff failed\nwant:%s\ngot: %s", tt.diff, diff)
+			}
+		})
+	}
+}
+
+func TestEqualInvalid(t *testing.T) {
+	if _, err := Equal([]byte{0x70, 0x05}, nil); err == nil {
+		t.Error("want error for invalid data")
+	}
+}
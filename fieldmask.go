@@ -0,0 +1,222 @@
+package jce
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ---------------------------------------------------------------------------
+// 字段掩码
+// 只需要少数字段时，在 Decoder 上设置掩码，未选中的 tag 会被当作不存在，
+// 数据直接通过 skipField 跳过，不会解析和分配内存；在 Encoder 上设置掩码则只输出选中的字段
+//
+//	m, _ := jce.ParseFieldMask("1", "3.2", "5[*].1")
+//	d.SetFieldMask(m)
+//
+// 掩码中的路径只包含 tag，list、map 对掩码是透明的：5.1 和 5[*].1 等价，
+// 都表示 tag 5 这个 list（或者 map 的 value）中每个 struct 只保留 tag 1
+// 嵌套 struct 需要使用 NewDecoder(d)、NewEncoder(e) 创建的子 Decoder、子 Encoder 读写，
+// 子 Decoder、子 Encoder 会自动使用对应字段的掩码
+// ---------------------------------------------------------------------------
+
+// FieldMask 选中的 tag 路径的集合，nil 表示选中所有字段
+type FieldMask struct {
+	tags     FieldSet
+	children map[byte]*FieldMask // 只选中了部分子字段的 tag，不在其中的 tag 选中整个子树
+}
+
+// 根据路径创建掩码
+func NewFieldMask(paths ...Path) (m *FieldMask, err error) {
+	m = &FieldMask{}
+	for _, path := range paths {
+		if err = m.Add(path); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// 解析路径并创建掩码，路径的语法同 ParsePath
+func ParseFieldMask(paths ...string) (m *FieldMask, err error) {
+	m = &FieldMask{}
+	for _, s := range paths {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		if err = m.Add(path); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// 选中 path 对应的整个子树，path 中只能包含 tag 和 [*]
+func (m *FieldMask) Add(path Path) (err error) {
+	// [step 1] 去掉 [*]，只保留 tag
+	tags := make([]byte, 0, len(path))
+	for _, step := range path {
+		switch step.Kind {
+		case StepTag:
+			tags = append(tags, step.Tag)
+		case StepAny:
+		default:
+			return fmt.Errorf("add %s to field mask failed, only tag and [*] are supported", path)
+		}
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("add %s to field mask failed, no tag in path", path)
+	}
+
+	// [step 2] 逐层添加
+	cur := m
+	for i, tag := range tags {
+		last := i == len(tags)-1
+
+		// [step 2.1] tag 已经选中
+		if cur.tags.Has(tag) {
+			child := cur.children[tag]
+			if child == nil {
+				return nil // 已经选中了整个子树
+			}
+			if last {
+				delete(cur.children, tag)
+				return nil
+			}
+			cur = child
+			continue
+		}
+
+		// [step 2.2] 新的 tag
+		cur.tags.Add(tag)
+		if last {
+			return nil
+		}
+		if cur.children == nil {
+			cur.children = make(map[byte]*FieldMask)
+		}
+		child := &FieldMask{}
+		cur.children[tag] = child
+		cur = child
+	}
+	return nil
+}
+
+// tag 是否被选中，nil 选中所有的 tag
+func (m *FieldMask) Has(tag byte) bool {
+	return m == nil || m.tags.Has(tag)
+}
+
+// tag 对应的子字段的掩码，返回 nil 表示选中整个子树
+func (m *FieldMask) Child(tag byte) *FieldMask {
+	if m == nil {
+		return nil
+	}
+	return m.children[tag]
+}
+
+// 设置 Decoder 的字段掩码，nil 表示读取所有字段
+// 未选中的 tag 即使是 require 的，ReadHead 也会返回不存在，需要检查时使用 RequireAll；未选中的字段也不会被当作未知字段保存
+// 读取任何字段之前创建的子 Decoder 使用整个掩码，所以可以直接传给 ReadFrom
+func (d *Decoder) SetFieldMask(m *FieldMask) {
+	d.mask, d.sub, d.items = m, m, 0
+}
+
+// tag 是否被掩码过滤掉，list、map 的元素不会被过滤
+func (d *Decoder) masked(tag byte) bool {
+	return d.mask != nil && d.items == 0 && !d.mask.Has(tag)
+}
+
+// 读到一个字段的 head 之后更新掩码的状态
+// struct 中的字段记录其子字段的掩码，list、map 的元素沿用所在字段的掩码
+func (d *Decoder) enterField(ty JceEncodeType, tag byte) {
+	if d.mask == nil {
+		return
+	}
+	d.lastType = ty
+	if d.items > 0 {
+		d.items--
+		return
+	}
+	d.sub = d.mask.Child(tag)
+}
+
+// 读到 list、map 的长度之后，记录接下来要读的元素个数，map 的 key、value 各算一个
+func (d *Decoder) enterItems(length uint32) {
+	if d.mask == nil {
+		return
+	}
+	switch d.lastType {
+	case List:
+		d.items += int64(length)
+	case Map:
+		d.items += 2 * int64(length)
+	}
+	d.lastType = Zero
+}
+
+// 设置 Encoder 的字段掩码，nil 表示输出所有字段
+// 未选中的字段依然可以正常调用 Write* 系列函数，只是数据会被丢弃
+// 写入任何字段之前创建的子 Encoder 使用整个掩码，所以可以直接传给 WriteTo
+func (e *Encoder) SetFieldMask(m *FieldMask) {
+	e.mask, e.sub, e.items = m, m, 0
+	e.buf = e.out
+}
+
+// 写一个字段的 head 之前更新掩码的状态，未选中的字段写入 discard
+func (e *Encoder) enterField(ty JceEncodeType, tag byte) {
+	// [step 1] StructEnd 和 StructBegin 属于同一个字段，不改变状态
+	if e.mask == nil || ty == StructEnd {
+		return
+	}
+
+	// [step 2] list、map 的元素
+	e.lastType = ty
+	if e.items > 0 {
+		e.items--
+		return
+	}
+
+	// [step 3] struct 中的字段
+	e.sub = e.mask.Child(tag)
+	if e.mask.Has(tag) {
+		e.buf = e.out
+	} else {
+		e.buf = e.discard()
+	}
+}
+
+// 写 list、map 的长度之后，记录接下来要写的元素个数
+func (e *Encoder) enterItems(length uint32) {
+	if e.mask == nil {
+		return
+	}
+	switch e.lastType {
+	case List:
+		e.items += int64(length)
+	case Map:
+		e.items += 2 * int64(length)
+	}
+	e.lastType = Zero
+}
+
+// 未知字段是否需要写入，需要时恢复真正的输出
+func (e *Encoder) selectUnknown(tag byte) bool {
+	if e.mask == nil {
+		return true
+	}
+	if !e.mask.Has(tag) {
+		return false
+	}
+	e.buf = e.out
+	return true
+}
+
+// 丢弃数据的缓冲区
+func (e *Encoder) discard() *bufio.Writer {
+	if e.trash == nil {
+		e.trash = bufio.NewWriterSize(io.Discard, 64)
+	}
+	return e.trash
+}
@@ -0,0 +1,283 @@
+package jce
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// 测试用的消息，嵌套 struct 都通过子 Decoder、子 Encoder 读写
+type maskItem struct {
+	Name string
+	ID   int64
+}
+
+type maskRecord struct {
+	ID    int32
+	Name  string
+	Items []maskItem
+	Attrs map[string]maskItem
+	Note  string
+}
+
+func (m *maskItem) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	if err = d.ReadString(&m.Name, 1, true); err != nil {
+		return
+	}
+	if err = d.ReadInt64(&m.ID, 2, true); err != nil {
+		return
+	}
+	return 0, d.SkipToStructEnd()
+}
+
+func (m *maskItem) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteString(m.Name, 1); err != nil {
+		return
+	}
+	return 0, e.WriteInt64(m.ID, 2)
+}
+
+func (m *maskRecord) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	if err = d.ReadInt32(&m.ID, 0, true); err != nil {
+		return
+	}
+	if err = d.ReadString(&m.Name, 1, true); err != nil {
+		return
+	}
+
+	readItem := func(d *Decoder, item *maskItem, tag byte, require bool) (err error) {
+		if _, _, err = d.ReadHead(tag, require); err != nil {
+			return
+		}
+		_, err = item.ReadFrom(d)
+		return
+	}
+	if _, have, err := d.ReadHead(2, false); err != nil || !have {
+		return 0, err
+	}
+	length, err := d.ReadLength()
+	if err != nil {
+		return
+	}
+	m.Items = make([]maskItem, length)
+	for i := range m.Items {
+		if err = readItem(d, &m.Items[i], 0, true); err != nil {
+			return
+		}
+	}
+	if err = ReadMap(d, &m.Attrs, 3, false, (*Decoder).ReadString, readItem); err != nil {
+		return
+	}
+
+	if err = d.ReadString(&m.Note, 4, false); err != nil {
+		return
+	}
+	return 0, d.SkipToStructEnd()
+}
+
+func (m *maskRecord) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteInt32(m.ID, 0); err != nil {
+		return
+	}
+	if err = e.WriteString(m.Name, 1); err != nil {
+		return
+	}
+
+	writeItem := func(e *Encoder, item maskItem, tag byte) (err error) {
+		if err = e.WriteHead(StructBegin, tag); err != nil {
+			return
+		}
+		if _, err = item.WriteTo(e); err != nil {
+			return
+		}
+		return e.WriteHead(StructEnd, 0)
+	}
+	if err = e.WriteHead(List, 2); err != nil {
+		return
+	}
+	if err = e.WriteLength(uint32(len(m.Items))); err != nil {
+		return
+	}
+	for _, item := range m.Items {
+		if err = writeItem(e, item, 0); err != nil {
+			return
+		}
+	}
+	e.SetCanonical(true)
+	if err = WriteMap(e, m.Attrs, 3, (*Encoder).WriteString, writeItem); err != nil {
+		return
+	}
+
+	if err = e.WriteString(m.Note, 4); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+func newMaskRecord() *maskRecord {
+	return &maskRecord{
+		ID:    7,
+		Name:  "record",
+		Items: []maskItem{{Name: "a", ID: 1}, {Name: "b", ID: 2}},
+		Attrs: map[string]maskItem{"x": {Name: "c", ID: 3}, "y": {Name: "d", ID: 4}},
+		Note:  "note",
+	}
+}
+
+func TestDecoderFieldMask(t *testing.T) {
+	var b bytes.Buffer
+	if _, err := newMaskRecord().WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	mask, err := ParseFieldMask("0", "2.2", "3[*].1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDecoder(&b)
+	d.SetFieldMask(mask)
+
+	// tag 1 虽然是 require 的，但是没有选中，当作不存在
+	var got maskRecord
+	if _, err = got.ReadFrom(d); err != nil {
+		t.Fatal(err)
+	}
+	want := maskRecord{
+		ID:    7,
+		Items: []maskItem{{ID: 1}, {ID: 2}},
+		Attrs: map[string]maskItem{"x": {Name: "c"}, "y": {Name: "d"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("masked decode failed\nwant:%+v\ngot: %+v", want, got)
+	}
+}
+
+// 被掩码过滤掉的字段不会当作未知字段保存，require 字段只能通过 RequireAll 检查
+func TestFieldMaskUnknown(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	for tag := byte(0); tag < 4; tag++ {
+		e.WriteInt32(int32(tag), tag)
+	}
+	e.Flush()
+
+	// 代码中只有 tag 0、1、3，tag 2 是新版本增加的字段
+	mask, err := ParseFieldMask("1", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unknown UnknownFields
+	d := NewDecoder(&b)
+	d.SetFieldMask(mask)
+	d.CaptureUnknownFields(&unknown)
+	d.TrackPresence(true)
+
+	var a, c int32
+	if raw, _, err := d.ReadRaw(0, true); err != nil || raw != nil {
+		t.Errorf("masked raw field, want nil, got:%x, err:%v", raw, err)
+	}
+	if err = d.ReadInt32(&a, 1, true); err != nil || a != 1 {
+		t.Errorf("read tag 1 failed, got:%d, err:%v", a, err)
+	}
+	if err = d.ReadInt32(&c, 3, true); err != nil || c != 0 {
+		t.Errorf("masked require tag 3, want absent, got:%d, err:%v", c, err)
+	}
+	if err = d.SkipToStructEnd(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unknown) != 1 || unknown[0].Tag != 2 {
+		t.Errorf("want only tag 2 captured, got:%+v", unknown)
+	}
+	if err = d.RequireAll(0, 1, 2, 3); err != nil {
+		t.Errorf("masked fields are present in data, got err:%v", err)
+	}
+}
+
+func TestEncoderFieldMask(t *testing.T) {
+	mask, err := ParseFieldMask("1", "2.1", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.SetFieldMask(mask)
+	if _, err = newMaskRecord().WriteTo(e); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := DecodeValue(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{1: "record", 2: [{1: "a"}, {1: "b"}], 4: "note"}`
+	if v.String() != want {
+		t.Errorf("masked encode failed\nwant:%s\ngot: %s", want, v)
+	}
+}
+
+// 被掩码丢弃的嵌套 struct 通过子 Encoder 写入，不计入 Offset
+func TestEncoderFieldMaskOffset(t *testing.T) {
+	mask, err := ParseFieldMask("0", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.SetFieldMask(mask)
+	e.WriteInt32(1, 0)
+	e.WriteHead(StructBegin, 1)
+	if _, err = (&maskItem{Name: "item", ID: 3}).WriteTo(e); err != nil {
+		t.Fatal(err)
+	}
+	e.WriteHead(StructEnd, 0)
+	e.WriteInt32(2, 2)
+	if err = e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Offset() != int64(b.Len()) {
+		t.Errorf("offset %d, want %d", e.Offset(), b.Len())
+	}
+}
+
+func TestFieldMaskAdd(t *testing.T) {
+	// 先选中整个子树，再选中子字段，依然是整个子树
+	m, err := ParseFieldMask("2", "2.1", "3.1", "3.4.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Has(2) || m.Child(2) != nil {
+		t.Error("tag 2 should select whole subtree")
+	}
+	if c := m.Child(3); c == nil || !c.Has(1) || !c.Has(4) || c.Has(2) || c.Child(4) == nil {
+		t.Error("tag 3 should select 3.1 and 3.4.5")
+	}
+	if m.Has(0) {
+		t.Error("tag 0 should not be selected")
+	}
+
+	// 再选中整个子树
+	if err = m.Add(MustParsePath("3")); err != nil {
+		t.Fatal(err)
+	}
+	if m.Child(3) != nil {
+		t.Error("tag 3 should select whole subtree")
+	}
+
+	for _, path := range []string{"2[0]", `2["a"]`, "[*]"} {
+		if _, err = ParseFieldMask(path); err == nil {
+			t.Errorf("want error for %s", path)
+		}
+	}
+}
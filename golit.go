@@ -0,0 +1,292 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 以 go 代码的形式输出编码数据，用于把线上抓到的数据直接贴到测试中
+//   - FormatGoEncoder：用 Encoder 的调用重新生成数据，可以在测试中修改某个字段
+//   - FormatGoBytes：[]byte 的字面量，每个字段一行，后面的注释为字段的 tag、类型和值
+// ---------------------------------------------------------------------------
+
+// 输出用 Encoder 重新生成 data 的代码，qualifier 为 jce 包在代码中的名字，为空时不加前缀
+//
+//	var b bytes.Buffer
+//	e := jce.NewEncoder(&b)
+//	e.WriteInt32(42, 1)
+//	e.WriteString("abc", 2)
+//	e.WriteHead(jce.StructBegin, 3)
+//	e.WriteInt32(1, 0)
+//	e.WriteHead(jce.StructEnd, 0)
+//	e.Flush()
+//	data := b.Bytes()
+//
+// Encoder 会把整数压缩为最小的类型，没有压缩的整数、NaN 等 Encoder 写不出来的字段用 WriteRaw 原样写入，
+// 保证生成的数据和 data 完全一致
+func FormatGoEncoder(data []byte, qualifier string) (code []byte, err error) {
+	v, err := decodeMessage(data)
+	if err != nil {
+		return
+	}
+	if qualifier != "" {
+		qualifier += "."
+	}
+
+	g := &goEncoderPrinter{pkg: qualifier}
+	g.printf("var b bytes.Buffer\n")
+	g.printf("e := %sNewEncoder(&b)\n", g.pkg)
+	for _, field := range v.Fields {
+		if err = g.value(field.Value, field.Tag); err != nil {
+			return
+		}
+	}
+	g.printf("e.Flush()\n")
+	g.printf("data := b.Bytes()\n")
+	return format.Source(g.b.Bytes())
+}
+
+type goEncoderPrinter struct {
+	b   bytes.Buffer
+	pkg string
+}
+
+func (g *goEncoderPrinter) printf(format string, args ...any) {
+	fmt.Fprintf(&g.b, format, args...)
+}
+
+// 输出写一个值的代码
+func (g *goEncoderPrinter) value(v Value, tag byte) (err error) {
+	switch v.Type {
+	// [step 1] 数字，Encoder 写出的类型和原来的不一致时原样写入
+	case Zero:
+		g.printf("e.WriteInt32(0, %d)\n", tag)
+	case Int1, Int2, Int4, Int8:
+		switch {
+		case intValueOf(v.Int).Type != v.Type:
+			return g.raw(v, tag)
+		case v.Type == Int8:
+			g.printf("e.WriteInt64(%d, %d)\n", v.signed(), tag)
+		case v.Type == Int4:
+			g.printf("e.WriteInt32(%d, %d)\n", v.signed(), tag)
+		default:
+			// Int1、Int2 按有符号写会扩展为 Int4，这里使用无符号的值
+			g.printf("e.WriteInt32(%d, %d)\n", v.Int, tag)
+		}
+	case Float4, Float8:
+		if v.Float == 0 || math.IsNaN(v.Float) || math.IsInf(v.Float, 0) {
+			return g.raw(v, tag)
+		}
+		if v.Type == Float4 {
+			g.printf("e.WriteFloat32(%s, %d)\n", strconv.FormatFloat(v.Float, 'g', -1, 32), tag)
+		} else {
+			g.printf("e.WriteFloat64(%s, %d)\n", strconv.FormatFloat(v.Float, 'g', -1, 64), tag)
+		}
+
+	// [step 2] string、SimpleList
+	case String:
+		g.printf("e.WriteString(%s, %d)\n", strconv.Quote(string(v.Bytes)), tag)
+	case SimpleList:
+		g.printf("e.WriteSliceUint8(%s, %d)\n", goByteSlice(v.Bytes), tag)
+
+	// [step 3] 容器，元素的 tag 固定为 0，map 的 value 为 1
+	case List:
+		g.printf("e.WriteHead(%sList, %d)\n", g.pkg, tag)
+		g.printf("e.WriteLength(%d)\n", len(v.List))
+		for _, item := range v.List {
+			if err = g.value(item, 0); err != nil {
+				return
+			}
+		}
+	case Map:
+		g.printf("e.WriteHead(%sMap, %d)\n", g.pkg, tag)
+		g.printf("e.WriteLength(%d)\n", len(v.Map))
+		for _, entry := range v.Map {
+			if err = g.value(entry.Key, 0); err != nil {
+				return
+			}
+			if err = g.value(entry.Value, 1); err != nil {
+				return
+			}
+		}
+	case StructBegin:
+		g.printf("e.WriteHead(%sStructBegin, %d)\n", g.pkg, tag)
+		for _, field := range v.Fields {
+			if err = g.value(field.Value, field.Tag); err != nil {
+				return
+			}
+		}
+		g.printf("e.WriteHead(%sStructEnd, 0)\n", g.pkg)
+	default:
+		return fmt.Errorf("format go failed, invalid type %s", v.Type)
+	}
+	return
+}
+
+// 原样写入一个字段
+func (g *goEncoderPrinter) raw(v Value, tag byte) (err error) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	if err = e.writeValue(v, tag); err != nil {
+		return
+	}
+	if err = e.Flush(); err != nil {
+		return
+	}
+	g.printf("e.WriteRaw(%d, %s) // %s\n", tag, goByteSlice(b.Bytes()), formatTextScalar(v))
+	return
+}
+
+// []byte 的字面量
+func goByteSlice(data []byte) string {
+	var b strings.Builder
+	b.WriteString("[]byte{")
+	writeGoBytes(&b, data)
+	b.WriteString("}")
+	return b.String()
+}
+
+func writeGoBytes(w io.Writer, data []byte) {
+	for i, c := range data {
+		if i > 0 {
+			io.WriteString(w, ", ")
+		}
+		fmt.Fprintf(w, "0x%02x", c)
+	}
+}
+
+// 标量的文本格式，同 FormatText，用于注释，太长的 string、SimpleList 只输出开头的部分
+func formatTextScalar(v Value) string {
+	const max = 32
+	if (v.Type == String || v.Type == SimpleList) && len(v.Bytes) > max {
+		n := len(v.Bytes)
+		v.Bytes = v.Bytes[:max]
+		return fmt.Sprintf("%s...(%d bytes)", formatTextScalar(v), n)
+	}
+	var b bytes.Buffer
+	formatTextValue(&b, 0, v)
+	return b.String()
+}
+
+// ---------------------------------------------------------------------------
+// []byte 字面量
+// ---------------------------------------------------------------------------
+
+// 输出 data 的 []byte 字面量，每个字段的字节一行，注释为字段的位置、类型和值：
+//
+//	[]byte{
+//		0x01, 0x2a, // 1: int1 42
+//		0xb3, // 3: struct {
+//		0x00, 0x01, // 3.0: int1 1
+//		0xc0, // 3: }
+//	}
+//
+// 位置中的 list 元素为 [i]，map 的 key、value 为 [i].key、[i].value
+func FormatGoBytes(data []byte) (code []byte, err error) {
+	if _, err = decodeMessage(data); err != nil {
+		return
+	}
+
+	p := &goBytesPrinter{d: NewDecoder(bytes.NewReader(data)), data: data}
+	p.b.WriteString("[]byte{\n")
+	if err = p.fields(""); err != nil {
+		return
+	}
+	p.b.WriteString("}\n")
+	return format.Source(p.b.Bytes())
+}
+
+type goBytesPrinter struct {
+	b    bytes.Buffer
+	d    *Decoder
+	data []byte
+	last int64 // 已经输出的字节数
+}
+
+// 输出到当前位置为止的字节，后面加上注释，太长时每行 16 个字节
+func (p *goBytesPrinter) emit(comment string) {
+	data := p.data[p.last:p.d.Offset()]
+	p.last = p.d.Offset()
+	for i := 0; i == 0 || i < len(data); i += 16 {
+		line := data[i:]
+		if len(line) > 16 {
+			line = line[:16]
+		}
+		p.b.WriteByte('\t')
+		writeGoBytes(&p.b, line)
+		p.b.WriteByte(',')
+		if i == 0 {
+			p.b.WriteString(" // " + comment)
+		}
+		p.b.WriteByte('\n')
+	}
+}
+
+// 输出 struct 内的字段直到 StructEnd 或者数据结束，prefix 为 struct 的位置
+func (p *goBytesPrinter) fields(prefix string) (err error) {
+	for {
+		ty, tag, err := p.d.readHead()
+		if err == io.EOF && prefix == "" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ty == StructEnd {
+			p.emit(strings.TrimSuffix(prefix, ".") + ": }")
+			return nil
+		}
+		if err = p.field(prefix+strconv.Itoa(int(tag)), ty); err != nil {
+			return err
+		}
+	}
+}
+
+// 输出一个字段，head 已经读过了
+func (p *goBytesPrinter) field(label string, ty JceEncodeType) (err error) {
+	switch ty {
+	case StructBegin:
+		p.emit(label + ": struct {")
+		return p.fields(label + ".")
+	case List, Map:
+		length, err := p.d.readLength()
+		if err != nil {
+			return err
+		}
+		p.emit(fmt.Sprintf("%s: %s len:%d", label, textTypeName(ty), length))
+		for i := uint32(0); i < length; i++ {
+			index := label + "[" + strconv.Itoa(int(i)) + "]"
+			if ty == Map {
+				if err = p.item(index + ".key"); err != nil {
+					return err
+				}
+				index += ".value"
+			}
+			if err = p.item(index); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		v, err := p.d.readValue(ty)
+		if err != nil {
+			return err
+		}
+		p.emit(label + ": " + formatTextScalar(v))
+		return nil
+	}
+}
+
+func (p *goBytesPrinter) item(label string) (err error) {
+	ty, _, err := p.d.readHead()
+	if err != nil {
+		return
+	}
+	return p.field(label, ty)
+}
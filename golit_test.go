@@ -0,0 +1,82 @@
+package jce
+
+import (
+	"testing"
+)
+
+// 包括 Encoder 写不出来的 Int4 1、Float4 0
+var goLiteralData = []byte{
+	0x01, 0x2a,
+	0x72, 3, 'a', 'b', 'c',
+	0xb3, 0x00, 0xfe, 0xc0,
+	0xa4, 1, 0x10, 0xff, 0xfe,
+	0x85, 1, 0x70, 1, 'k', 0x21, 0, 0, 0, 0,
+	0x25, 0, 0, 0, 1,
+	0x46, 0, 0, 0, 0,
+}
+
+func TestFormatGoEncoder(t *testing.T) {
+	want := `var b bytes.Buffer
+e := jce.NewEncoder(&b)
+e.WriteInt32(42, 1)
+e.WriteString("abc", 2)
+e.WriteHead(jce.StructBegin, 3)
+e.WriteInt32(254, 0)
+e.WriteHead(jce.StructEnd, 0)
+e.WriteHead(jce.List, 4)
+e.WriteLength(1)
+e.WriteInt32(65534, 0)
+e.WriteHead(jce.Map, 5)
+e.WriteLength(1)
+e.WriteString("k", 0)
+e.WriteRaw(1, []byte{0x21, 0x00, 0x00, 0x00, 0x00}) // int4 0
+e.WriteRaw(5, []byte{0x25, 0x00, 0x00, 0x00, 0x01}) // int4 1
+e.WriteRaw(6, []byte{0x46, 0x00, 0x00, 0x00, 0x00}) // float4 0
+e.Flush()
+data := b.Bytes()
+`
+	got, err := FormatGoEncoder(goLiteralData, "jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("FormatGoEncoder()\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	got, err = FormatGoEncoder([]byte{0xb0, 0x60, 0xc0}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want = "var b bytes.Buffer\ne := NewEncoder(&b)\ne.WriteHead(StructBegin, 0)\ne.WriteInt32(0, 0)\ne.WriteHead(StructEnd, 0)\ne.Flush()\ndata := b.Bytes()\n"; string(got) != want {
+		t.Errorf("FormatGoEncoder() without qualifier\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestFormatGoBytes(t *testing.T) {
+	want := `[]byte{
+	0x01, 0x2a, // 1: int1 42
+	0x72, 0x03, 0x61, 0x62, 0x63, // 2: string "abc"
+	0xb3,       // 3: struct {
+	0x00, 0xfe, // 3.0: int1 -2
+	0xc0,       // 3: }
+	0xa4, 0x01, // 4: list len:1
+	0x10, 0xff, 0xfe, // 4[0]: int2 -2
+	0x85, 0x01, // 5: map len:1
+	0x70, 0x01, 0x6b, // 5[0].key: string "k"
+	0x21, 0x00, 0x00, 0x00, 0x00, // 5[0].value: int4 0
+	0x25, 0x00, 0x00, 0x00, 0x01, // 5: int4 1
+	0x46, 0x00, 0x00, 0x00, 0x00, // 6: float4 0
+}
+`
+	got, err := FormatGoBytes(goLiteralData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("FormatGoBytes()\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	if _, err = FormatGoBytes(goLiteralData[:3]); err == nil {
+		t.Error("want error for truncated data")
+	}
+}
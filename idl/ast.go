@@ -0,0 +1,291 @@
+package idl
+
+import (
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// IDL 的语法树
+// 每个节点都带有开始的位置，有 {} 的节点还带有 } 的位置；注释不挂在节点上，
+// 统一放在 File.Comments 中，需要的工具（比如格式化）按位置对应
+// ---------------------------------------------------------------------------
+
+// Node 语法树中的节点
+type Node interface {
+	Position() Pos
+}
+
+// Decl module 中的声明：*Struct、*Enum、*Const、*Key、*Interface
+type Decl interface {
+	Node
+	DeclName() string
+}
+
+// File 一个 .jce 文件
+type File struct {
+	Name     string
+	Includes []*IncludeDecl
+	Modules  []*Module
+	Comments []*CommentLine // 所有的注释，按位置排列
+}
+
+// IncludeDecl #include "path"
+type IncludeDecl struct {
+	Pos  Pos
+	Path string // 去掉引号之后的路径
+}
+
+// CommentLine 一个注释，// 注释为一行，/* */ 注释可以有多行
+type CommentLine struct {
+	Pos  Pos
+	End  Pos
+	Text string // 原文，包括 // 或者 /* */
+}
+
+// Module module Name { ... };
+type Module struct {
+	Pos   Pos
+	Name  string
+	Decls []Decl // 按声明的顺序排列
+	End   Pos    // } 的位置
+}
+
+// Struct struct Name { ... };
+type Struct struct {
+	Pos    Pos
+	Name   string
+	Fields []*Field
+	End    Pos
+}
+
+// Field struct 中的字段：tag require|optional type name [= default];
+type Field struct {
+	Pos     Pos
+	Tag     int // 不检查范围，超过 255 的 tag 由使用者报错
+	TagPos  Pos
+	Require bool
+	Type    *Type
+	Name    string
+	Default *Literal // 没有默认值时为 nil
+}
+
+// Enum enum Name { A, B = 2, ... };
+type Enum struct {
+	Pos     Pos
+	Name    string
+	Members []*EnumMember
+	End     Pos
+}
+
+// EnumMember enum 中的一个值
+type EnumMember struct {
+	Pos   Pos
+	Name  string
+	Value *Literal // 没有指定值时为 nil，值为前一个加 1
+}
+
+// Const const type Name = value;
+type Const struct {
+	Pos   Pos
+	Type  *Type
+	Name  string
+	Value *Literal
+}
+
+// Key key[Struct, field1, field2];
+type Key struct {
+	Pos       Pos
+	Struct    string
+	Fields    []string
+	FieldsPos []Pos
+}
+
+// Interface interface Name { ... };
+type Interface struct {
+	Pos     Pos
+	Name    string
+	Methods []*Method
+	End     Pos
+}
+
+// Method interface 中的方法：result name(params);
+type Method struct {
+	Pos    Pos
+	Result *Type // void 时为 nil
+	Name   string
+	Params []*Param
+}
+
+// Param 方法的参数
+type Param struct {
+	Pos      Pos
+	Out      bool // out 参数
+	RouteKey bool // routekey 参数
+	Type     *Type
+	Name     string
+}
+
+func (d *Struct) Position() Pos      { return d.Pos }
+func (d *Enum) Position() Pos        { return d.Pos }
+func (d *Const) Position() Pos       { return d.Pos }
+func (d *Key) Position() Pos         { return d.Pos }
+func (d *Interface) Position() Pos   { return d.Pos }
+func (n *File) Position() Pos        { return Pos{Filename: n.Name, Line: 1, Column: 1} }
+func (n *IncludeDecl) Position() Pos { return n.Pos }
+func (n *Module) Position() Pos      { return n.Pos }
+func (n *Field) Position() Pos       { return n.Pos }
+func (n *EnumMember) Position() Pos  { return n.Pos }
+func (n *Method) Position() Pos      { return n.Pos }
+func (n *Param) Position() Pos       { return n.Pos }
+func (n *Type) Position() Pos        { return n.Pos }
+func (n *Literal) Position() Pos     { return n.Pos }
+
+func (d *Struct) DeclName() string    { return d.Name }
+func (d *Enum) DeclName() string      { return d.Name }
+func (d *Const) DeclName() string     { return d.Name }
+func (d *Key) DeclName() string       { return d.Struct }
+func (d *Interface) DeclName() string { return d.Name }
+
+// 按名字查找 struct，没有时返回 nil
+func (m *Module) Struct(name string) *Struct {
+	for _, decl := range m.Decls {
+		if s, ok := decl.(*Struct); ok && s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// 按名字查找 enum，没有时返回 nil
+func (m *Module) Enum(name string) *Enum {
+	for _, decl := range m.Decls {
+		if e, ok := decl.(*Enum); ok && e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// 按名字查找字段，没有时返回 nil
+func (s *Struct) Field(name string) *Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// 类型
+// ---------------------------------------------------------------------------
+
+// TypeKind 类型的种类
+type TypeKind int
+
+const (
+	TypeBool TypeKind = iota
+	TypeByte
+	TypeShort
+	TypeInt
+	TypeLong
+	TypeFloat
+	TypeDouble
+	TypeString
+	TypeVector
+	TypeMap
+	TypeNamed // struct、enum，或者其他 module 中的 struct、enum
+)
+
+// 基础类型的关键字
+var basicTypes = map[string]TypeKind{
+	"bool":   TypeBool,
+	"byte":   TypeByte,
+	"short":  TypeShort,
+	"int":    TypeInt,
+	"long":   TypeLong,
+	"float":  TypeFloat,
+	"double": TypeDouble,
+	"string": TypeString,
+}
+
+var basicTypeNames = [...]string{
+	TypeBool:   "bool",
+	TypeByte:   "byte",
+	TypeShort:  "short",
+	TypeInt:    "int",
+	TypeLong:   "long",
+	TypeFloat:  "float",
+	TypeDouble: "double",
+	TypeString: "string",
+}
+
+// Type 字段、参数、常量的类型
+type Type struct {
+	Pos      Pos
+	Kind     TypeKind
+	Unsigned bool   // unsigned byte、short、int
+	Key      *Type  // map 的 key
+	Elem     *Type  // vector 的元素、map 的 value
+	Module   string // TypeNamed 的 module，同一个 module 中时为空
+	Name     string // TypeNamed 的名字
+}
+
+// 是否为基础类型，包括 string
+func (t *Type) IsBasic() bool {
+	return t.Kind <= TypeString
+}
+
+// 源码中的写法，比如 map<string, vector<int>>
+func (t *Type) String() string {
+	var b strings.Builder
+	t.format(&b)
+	return b.String()
+}
+
+func (t *Type) format(b *strings.Builder) {
+	switch t.Kind {
+	case TypeVector:
+		b.WriteString("vector<")
+		t.Elem.format(b)
+		b.WriteString(">")
+	case TypeMap:
+		b.WriteString("map<")
+		t.Key.format(b)
+		b.WriteString(", ")
+		t.Elem.format(b)
+		b.WriteString(">")
+	case TypeNamed:
+		if t.Module != "" {
+			b.WriteString(t.Module + "::")
+		}
+		b.WriteString(t.Name)
+	default:
+		if t.Unsigned {
+			b.WriteString("unsigned ")
+		}
+		b.WriteString(basicTypeNames[t.Kind])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 字面量
+// ---------------------------------------------------------------------------
+
+// LiteralKind 字面量的种类
+type LiteralKind int
+
+const (
+	LiteralInt    LiteralKind = iota
+	LiteralFloat              // 浮点数
+	LiteralString             // 字符串
+	LiteralBool               // true、false
+	LiteralIdent              // enum 的值、常量的名字，可以带 module 前缀
+)
+
+// Literal 默认值、常量、enum 的值
+type Literal struct {
+	Pos  Pos
+	Kind LiteralKind
+	Text string // 源码中的原文，字符串包括引号
+}
@@ -0,0 +1,29 @@
+// idl 解析 Tars/JCE 的 IDL 文件（.jce），生成带有位置信息的语法树，用于代码生成、格式化、检查等工具
+//
+// 支持的语法：
+//
+//	#include "other.jce"
+//
+//	module Demo
+//	{
+//	    enum Color { RED, GREEN = 2 };
+//	    const int MAX = 100;
+//
+//	    struct User
+//	    {
+//	        0 require long id;
+//	        1 optional string name = "guest";
+//	        2 optional map<string, vector<int>> scores;
+//	        3 optional Other::Address address;
+//	    };
+//
+//	    key[User, id];
+//
+//	    interface UserService
+//	    {
+//	        int getUser(long id, out User user);
+//	    };
+//	};
+//
+// 解析只检查语法，名字是否存在、tag 是否重复等语义上的问题由使用者检查
+package idl
@@ -0,0 +1,401 @@
+package idl
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// 格式化
+// 按统一的风格重新输出语法树，输出的结果再次格式化不会变化：
+//   - 缩进为 4 个空格，{ 单独一行，声明之间空一行
+//   - struct 字段的 tag、require|optional、类型、名字按列对齐，连续的行尾注释对齐
+//   - #include 按路径排序并去重，紧挨着的注释跟随移动
+//   - 注释按位置放回原处，元素之间的多个空行合并为一个
+// ---------------------------------------------------------------------------
+
+// 解析并格式化 src，src 有语法错误时返回错误
+func FormatSource(filename string, src []byte) (out []byte, err error) {
+	f, err := ParseFile(filename, src)
+	if err != nil {
+		return
+	}
+	return Format(f), nil
+}
+
+// 按统一的风格输出 f，f 需要带有 ParseFile 得到的位置和注释
+func Format(f *File) []byte {
+	p := &printer{comments: f.Comments, used: map[*CommentLine]bool{}, first: true}
+	p.includes(f.Includes)
+	for _, m := range f.Modules {
+		p.module(m)
+	}
+
+	// 文件末尾剩下的注释
+	p.leading(endOfFile, false)
+	return p.render()
+}
+
+const indentUnit = "    "
+
+// 在所有元素之后的位置
+var endOfFile = Pos{Offset: int(^uint(0) >> 1)}
+
+// 第 i 个元素之后的下一个元素的位置，没有时为 end
+func nextPos[T Node](nodes []T, i int, end Pos) Pos {
+	if i+1 < len(nodes) {
+		return nodes[i+1].Position()
+	}
+	return end
+}
+
+// 输出的一行
+type row struct {
+	indent  int
+	cells   []string // 为空时为空行
+	group   int      // 同一个 group 的行按列对齐，0 表示不对齐
+	comment string   // 行尾注释
+}
+
+type printer struct {
+	rows     []row
+	indent   int
+	groups   int // 已经使用的 group 数
+	comments []*CommentLine
+	used     map[*CommentLine]bool
+
+	lastLine int  // 上一个输出的源码元素结束的行号，用于保留空行
+	first    bool // 是否为块中的第一个元素，前面不加空行
+	single   bool // 上一个声明是否为单行的 const、key
+}
+
+func (p *printer) line(cells ...string) *row {
+	p.rows = append(p.rows, row{indent: p.indent, cells: cells})
+	return &p.rows[len(p.rows)-1]
+}
+
+// 添加一个空行，开头、连续的空行会被忽略
+func (p *printer) blank() {
+	if n := len(p.rows); n > 0 && len(p.rows[n-1].cells) > 0 {
+		p.rows = append(p.rows, row{})
+	}
+}
+
+// 开始一个块中的元素，force 为 true 时和前一个元素之间空一行，否则和源码一致，有空行时保留一个空行
+func (p *printer) space(line int, force bool) {
+	if !p.first && (force || line > p.lastLine+1) {
+		p.blank()
+	}
+	p.first = false
+}
+
+// ---------------------------------------------------------------------------
+// 注释
+// ---------------------------------------------------------------------------
+
+// 输出 pos 之前还没有输出的注释，每个注释单独一行；force 表示第一个注释或者 pos 前面需要空一行
+func (p *printer) leading(pos Pos, force bool) {
+	for _, c := range p.comments {
+		if p.used[c] || c.Pos.Offset >= pos.Offset {
+			continue
+		}
+		p.used[c] = true
+		p.space(c.Pos.Line, force)
+		force = false
+		p.line(p.commentText(c))
+		p.lastLine = c.End.Line
+	}
+	p.space(pos.Line, force)
+}
+
+// 和 line 在同一行、还没有输出的注释，作为行尾注释；同一行后面还有其他元素时，注释属于最后一个元素，
+// next 为下一个元素的位置
+func (p *printer) trailing(line int, next Pos) string {
+	for _, c := range p.comments {
+		if !p.used[c] && c.Pos.Line == line && c.Pos.Offset < next.Offset {
+			p.used[c] = true
+			p.lastLine = c.End.Line
+			return p.commentText(c)
+		}
+	}
+	return ""
+}
+
+// 注释的文本，/* */ 注释的后续行按当前的缩进重新对齐
+func (p *printer) commentText(c *CommentLine) string {
+	lines := strings.Split(c.Text, "\n")
+	for i, l := range lines {
+		l = strings.TrimRight(l, " \t\r")
+		if i > 0 {
+			l = strings.TrimLeft(l, " \t")
+			if strings.HasPrefix(l, "*") {
+				l = " " + l
+			}
+			if l != "" {
+				l = strings.Repeat(indentUnit, p.indent) + l
+			}
+		}
+		lines[i] = l
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 注释是否紧挨着 line，即结束在上一行
+func (p *printer) attached(c *CommentLine, line int) bool {
+	return c.End.Line == line-1
+}
+
+// ---------------------------------------------------------------------------
+// 声明
+// ---------------------------------------------------------------------------
+
+// 排序、去重之后的 #include，紧挨在上面的注释和行尾注释跟随移动
+func (p *printer) includes(incs []*IncludeDecl) {
+	if len(incs) == 0 {
+		return
+	}
+
+	// [step 1] 每个 include 带上它和前一个 include 之间的注释；第一个 include 只带紧挨着的注释，
+	// 更前面的为文件开头的注释，原样输出
+	type item struct {
+		path     string
+		comments []*CommentLine
+		trailing []string
+	}
+	var header []*CommentLine
+	items := map[string]*item{}
+	var order []*item
+	for i, inc := range incs {
+		var above []*CommentLine
+		for _, c := range p.comments {
+			if !p.used[c] && c.Pos.Offset < inc.Pos.Offset {
+				p.used[c] = true
+				above = append(above, c)
+			}
+		}
+		if i == 0 {
+			// 从下往上找紧挨着的注释
+			n, line := len(above), inc.Pos.Line
+			for n > 0 && p.attached(above[n-1], line) {
+				n, line = n-1, above[n-1].Pos.Line
+			}
+			header, above = above[:n], above[n:]
+		}
+
+		// 同一个路径只保留一个，注释合并
+		it := items[inc.Path]
+		if it == nil {
+			it = &item{path: inc.Path}
+			items[inc.Path] = it
+			order = append(order, it)
+		}
+		it.comments = append(it.comments, above...)
+		if c := p.trailing(inc.Pos.Line, endOfFile); c != "" {
+			it.trailing = append(it.trailing, c)
+		}
+	}
+
+	// [step 2] 文件开头的注释
+	for _, c := range header {
+		p.space(c.Pos.Line, false)
+		p.line(p.commentText(c))
+		p.lastLine = c.End.Line
+	}
+	if len(header) > 0 {
+		p.blank()
+	}
+
+	// [step 3] 排序之后输出
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].path < order[j].path
+	})
+	for _, it := range order {
+		for _, c := range it.comments {
+			p.line(p.commentText(c))
+		}
+		p.line("#include " + strconv.Quote(it.path)).comment = strings.Join(it.trailing, " ")
+	}
+	p.first = false
+	p.lastLine = incs[len(incs)-1].Pos.Line
+}
+
+func (p *printer) module(m *Module) {
+	p.leading(m.Pos, true)
+	p.open("module "+m.Name, m.Pos, nextPos(m.Decls, -1, m.End))
+	for i, decl := range m.Decls {
+		// 声明之间空一行，连续的 const、key 和源码一致
+		_, single := decl.(*Const)
+		if _, ok := decl.(*Key); ok {
+			single = true
+		}
+		p.leading(decl.Position(), !single || !p.single)
+		p.single = single
+
+		next := nextPos(m.Decls, i, m.End)
+		switch decl := decl.(type) {
+		case *Struct:
+			p.structure(decl)
+		case *Enum:
+			p.enum(decl)
+		case *Const:
+			p.line("const " + decl.Type.String() + " " + decl.Name + " = " + decl.Value.Text + ";").comment = p.trailing(decl.Pos.Line, next)
+			p.lastLine = decl.Pos.Line
+		case *Key:
+			p.line("key[" + strings.Join(append([]string{decl.Struct}, decl.Fields...), ", ") + "];").comment = p.trailing(decl.Pos.Line, next)
+			p.lastLine = decl.Pos.Line
+		case *Interface:
+			p.iface(decl)
+		}
+	}
+	p.single = false
+	p.close(m.End)
+}
+
+// 块的开始：header 一行，{ 单独一行，之后的元素缩进；next 为块中第一个元素的位置
+func (p *printer) open(header string, pos, next Pos) {
+	p.line(header).comment = p.trailing(pos.Line, next)
+	p.lastLine = pos.Line
+	p.line("{")
+	p.indent++
+	p.first = true
+}
+
+// 块的结束：输出 end 之前剩下的注释，然后是 };
+func (p *printer) close(end Pos) {
+	p.leading(end, false)
+	p.indent--
+	p.line("};").comment = p.trailing(end.Line, endOfFile)
+	p.lastLine = end.Line
+	p.first = false
+}
+
+func (p *printer) structure(s *Struct) {
+	p.open("struct "+s.Name, s.Pos, nextPos(s.Fields, -1, s.End))
+
+	// 所有的字段按列对齐：tag、require|optional、类型、名字和默认值
+	p.groups++
+	for i, f := range s.Fields {
+		p.leading(f.Pos, false)
+		require := "optional"
+		if f.Require {
+			require = "require"
+		}
+		last := f.Name
+		if f.Default != nil {
+			last += " = " + f.Default.Text
+		}
+		r := p.line(strconv.Itoa(f.Tag), require, f.Type.String(), last+";")
+		r.group = p.groups
+		r.comment = p.trailing(f.Pos.Line, nextPos(s.Fields, i, s.End))
+		p.lastLine = max(p.lastLine, f.Pos.Line)
+	}
+	p.close(s.End)
+}
+
+func (p *printer) enum(e *Enum) {
+	p.open("enum "+e.Name, e.Pos, nextPos(e.Members, -1, e.End))
+	for i, m := range e.Members {
+		p.leading(m.Pos, false)
+		text := m.Name
+		if m.Value != nil {
+			text += " = " + m.Value.Text
+		}
+		p.line(text + ",").comment = p.trailing(m.Pos.Line, nextPos(e.Members, i, e.End))
+		p.lastLine = max(p.lastLine, m.Pos.Line)
+	}
+	p.close(e.End)
+}
+
+func (p *printer) iface(i *Interface) {
+	p.open("interface "+i.Name, i.Pos, nextPos(i.Methods, -1, i.End))
+	for j, m := range i.Methods {
+		p.leading(m.Pos, false)
+		result := "void"
+		if m.Result != nil {
+			result = m.Result.String()
+		}
+		params := make([]string, len(m.Params))
+		for k, param := range m.Params {
+			var b strings.Builder
+			if param.Out {
+				b.WriteString("out ")
+			}
+			if param.RouteKey {
+				b.WriteString("routekey ")
+			}
+			b.WriteString(param.Type.String() + " " + param.Name)
+			params[k] = b.String()
+		}
+		p.line(result + " " + m.Name + "(" + strings.Join(params, ", ") + ");").comment = p.trailing(m.Pos.Line, nextPos(i.Methods, j, i.End))
+		p.lastLine = max(p.lastLine, m.Pos.Line)
+	}
+	p.close(i.End)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ---------------------------------------------------------------------------
+// 输出
+// ---------------------------------------------------------------------------
+
+// 按列对齐之后输出所有的行
+func (p *printer) render() []byte {
+	// [step 1] 每个 group 中每一列的宽度，最后一列不需要对齐
+	widths := map[int][]int{}
+	for _, r := range p.rows {
+		if r.group == 0 {
+			continue
+		}
+		w := widths[r.group]
+		for i, cell := range r.cells[:len(r.cells)-1] {
+			if i >= len(w) {
+				w = append(w, 0)
+			}
+			w[i] = max(w[i], utf8.RuneCountInString(cell))
+		}
+		widths[r.group] = w
+	}
+
+	// [step 2] 每一行的文本
+	texts := make([]string, len(p.rows))
+	for i, r := range p.rows {
+		if len(r.cells) == 0 {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(strings.Repeat(indentUnit, r.indent))
+		for j, cell := range r.cells {
+			b.WriteString(cell)
+			if j < len(r.cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[r.group][j]-utf8.RuneCountInString(cell)+1))
+			}
+		}
+		texts[i] = b.String()
+	}
+
+	// [step 3] 连续的有行尾注释的行，注释对齐
+	var out bytes.Buffer
+	for i := 0; i < len(p.rows); {
+		j, width := i, 0
+		for ; j < len(p.rows) && p.rows[j].comment != ""; j++ {
+			width = max(width, utf8.RuneCountInString(texts[j]))
+		}
+		if j == i {
+			out.WriteString(texts[i] + "\n")
+			i++
+			continue
+		}
+		for ; i < j; i++ {
+			out.WriteString(texts[i] + strings.Repeat(" ", width-utf8.RuneCountInString(texts[i])+1) + p.rows[i].comment + "\n")
+		}
+	}
+	return out.Bytes()
+}
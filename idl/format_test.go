@@ -0,0 +1,199 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "struct",
+			src: `module Demo{struct User{
+0 require long id;  // id
+1 optional string name="guest";
+10 optional map<string,vector<int>> scores;
+};};`,
+			want: `module Demo
+{
+    struct User
+    {
+        0  require  long                     id; // id
+        1  optional string                   name = "guest";
+        10 optional map<string, vector<int>> scores;
+    };
+};
+`,
+		},
+		{
+			name: "includes",
+			src: `// header
+
+#include "b.jce" // b
+/* a */
+#include "a.jce"
+#include "b.jce"
+module M {};`,
+			want: `// header
+
+/* a */
+#include "a.jce"
+#include "b.jce" // b
+
+module M
+{
+};
+`,
+		},
+		{
+			name: "comments",
+			src: `module M {
+  // color
+  enum Color { RED, GREEN=2, // green
+  BLUE };
+  const int A = 1; // a
+  const long LONG_NAME = 2; // b
+
+
+  /*
+       * doc
+  */
+  interface S { void ping(); // ping
+    // end
+  }; // after
+};
+// tail`,
+			want: `module M
+{
+    // color
+    enum Color
+    {
+        RED,
+        GREEN = 2, // green
+        BLUE,
+    };
+
+    const int A = 1;          // a
+    const long LONG_NAME = 2; // b
+
+    /*
+     * doc
+     */
+    interface S
+    {
+        void ping(); // ping
+        // end
+    }; // after
+};
+// tail
+`,
+		},
+		{
+			name: "blank lines",
+			src: `module M { struct S {
+    0 optional int a;
+
+
+
+    1 optional int b;
+    // c
+    2 optional int c;
+};
+    key[S, a];
+    key[S, b];
+    enum E { A };
+};`,
+			want: `module M
+{
+    struct S
+    {
+        0 optional int a;
+
+        1 optional int b;
+        // c
+        2 optional int c;
+    };
+
+    key[S, a];
+    key[S, b];
+
+    enum E
+    {
+        A,
+    };
+};
+`,
+		},
+		{
+			name: "interface",
+			src:  `module M { interface S { int get(long id,out routekey map<int,string> m); }; };`,
+			want: `module M
+{
+    interface S
+    {
+        int get(long id, out routekey map<int, string> m);
+    };
+};
+`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := FormatSource("t.jce", []byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s:\n got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+
+		// 格式化的结果再次格式化不变
+		again, err := FormatSource("t.jce", got)
+		if err != nil || string(again) != string(got) {
+			t.Errorf("%s: not idempotent, err = %v:\n%s", tt.name, err, again)
+		}
+	}
+}
+
+func TestFormatTestdata(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := FormatSource(path, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		twice, err := FormatSource(path, once)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(once) != string(twice) {
+			t.Errorf("%s: not idempotent:\n%s\n%s", path, once, twice)
+		}
+
+		// 只改变格式，语法树不变
+		f1, _ := ParseFile(path, src)
+		f2, _ := ParseFile(path, once)
+		if len(f1.Comments) != len(f2.Comments) || len(f1.Modules[0].Decls) != len(f2.Modules[0].Decls) {
+			t.Errorf("%s: content changed:\n%s", path, once)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := FormatSource("t.jce", []byte("module M { struct S { x }; };"))
+	if err == nil || err.Error() != "t.jce:1:23: expected field tag, found identifier x" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package idl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 解析 filename 以及它直接、间接 #include 的所有文件，被 include 的文件排在前面，每个文件只出现一次
+// include 的路径先相对于当前文件所在的目录查找，再依次在 includeDirs 中查找
+func Load(filename string, includeDirs ...string) (files []*File, err error) {
+	l := &loader{dirs: includeDirs, seen: map[string]bool{}}
+	if err = l.load(filename); err != nil {
+		return nil, err
+	}
+	return l.files, nil
+}
+
+type loader struct {
+	dirs  []string
+	seen  map[string]bool
+	files []*File
+}
+
+func (l *loader) load(filename string) (err error) {
+	// [step 1] 同一个文件只解析一次，include 的循环在这里终止
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return
+	}
+	if l.seen[abs] {
+		return
+	}
+	l.seen[abs] = true
+
+	f, err := ParseFile(filename, nil)
+	if err != nil {
+		return
+	}
+
+	// [step 2] 先加载 include 的文件
+	for _, inc := range f.Includes {
+		path, ok := l.find(filepath.Dir(filename), inc.Path)
+		if !ok {
+			return &Error{Pos: inc.Pos, Msg: fmt.Sprintf("include file %q not found", inc.Path)}
+		}
+		if err = l.load(path); err != nil {
+			return
+		}
+	}
+	l.files = append(l.files, f)
+	return
+}
+
+func (l *loader) find(dir, path string) (found string, ok bool) {
+	if filepath.IsAbs(path) {
+		return path, fileExists(path)
+	}
+	for _, d := range append([]string{dir}, l.dirs...) {
+		if p := filepath.Join(d, path); fileExists(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ---------------------------------------------------------------------------
+// enum 的值
+// ---------------------------------------------------------------------------
+
+// 计算每个成员的值：没有指定值时为前一个加 1，第一个为 0；值可以是整数，或者前面的成员的名字
+func (e *Enum) Values() (values []int64, err error) {
+	next := int64(0)
+	for _, m := range e.Members {
+		if m.Value != nil {
+			if next, err = e.memberValue(m.Value, values); err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, next)
+		next++
+	}
+	return
+}
+
+func (e *Enum) memberValue(l *Literal, values []int64) (v int64, err error) {
+	if l.Kind == LiteralInt {
+		if v, err = strconv.ParseInt(l.Text, 0, 32); err != nil {
+			return 0, &Error{Pos: l.Pos, Msg: fmt.Sprintf("enum value %s out of range", l.Text)}
+		}
+		return
+	}
+
+	// 可以写为 Color::RED，只看最后的名字
+	name := l.Text[strings.LastIndex(l.Text, ":")+1:]
+	for i, m := range e.Members[:len(values)] {
+		if m.Name == name {
+			return values[i], nil
+		}
+	}
+	return 0, &Error{Pos: l.Pos, Msg: fmt.Sprintf("undefined enum member %s", l.Text)}
+}
@@ -0,0 +1,78 @@
+package idl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	files, err := Load("testdata/demo.jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "testdata/common.jce" || files[1].Name != "testdata/demo.jce" {
+		t.Fatalf("files = %v", files)
+	}
+
+	// [step 1] include 的循环只加载一次，includeDirs 中的文件也能找到
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "inc")
+	if err = os.Mkdir(inc, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, src string) {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "a.jce"), `#include "b.jce" module A {};`)
+	write(filepath.Join(inc, "b.jce"), `#include "../a.jce" module B {};`)
+	if files, err = Load(filepath.Join(dir, "a.jce"), inc); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Modules[0].Name != "B" || files[1].Modules[0].Name != "A" {
+		t.Errorf("files = %v", files)
+	}
+
+	// [step 2] 找不到 include 的文件
+	write(filepath.Join(dir, "c.jce"), `#include "missing.jce"`)
+	_, err = Load(filepath.Join(dir, "c.jce"))
+	if err == nil || !strings.HasSuffix(err.Error(), `c.jce:1:1: include file "missing.jce" not found`) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+		err  string
+	}{
+		{src: `A, B = 2, C`, want: "[0 2 3]"},
+		{src: `A = -1, B, C = A, D = E::B`, want: "[-1 0 -1 0]"},
+		{src: `A = 0x10, B`, want: "[16 17]"},
+		{src: `A = B, B`, err: "t.jce:1:25: undefined enum member B"},
+		{src: `A = 4294967296`, err: "t.jce:1:25: enum value 4294967296 out of range"},
+	}
+	for _, tt := range tests {
+		f, err := ParseFile("t.jce", []byte("module M { enum E { "+tt.src+" }; };"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, err := f.Modules[0].Enum("E").Values()
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: err = %v, want %s", tt.src, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+		} else if got := fmt.Sprint(values); got != tt.want {
+			t.Errorf("%s: values = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
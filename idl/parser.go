@@ -0,0 +1,550 @@
+package idl
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// 关键字，不能作为名字使用
+var keywords = map[string]bool{
+	"module": true, "struct": true, "enum": true, "const": true, "key": true, "interface": true,
+	"require": true, "optional": true, "out": true, "routekey": true, "void": true,
+	"unsigned": true, "vector": true, "map": true, "true": true, "false": true,
+	"bool": true, "byte": true, "short": true, "int": true, "long": true,
+	"float": true, "double": true, "string": true,
+}
+
+// 解析一个 .jce 文件，src 为 nil 时读取 filename
+// 遇到第一个错误时停止，返回的错误为 *Error，带有出错的位置
+func ParseFile(filename string, src []byte) (f *File, err error) {
+	if src == nil {
+		if src, err = os.ReadFile(filename); err != nil {
+			return
+		}
+	}
+
+	p := &parser{s: NewScanner(filename, src), file: &File{Name: filename}}
+	if err = p.next(); err != nil {
+		return
+	}
+	if err = p.parseFile(); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+type parser struct {
+	s    *Scanner
+	file *File
+
+	// 当前的词法单元
+	tok Token
+	lit string
+	pos Pos
+}
+
+// 读取下一个词法单元，注释放到 File.Comments 中
+func (p *parser) next() (err error) {
+	for {
+		if p.tok, p.lit, p.pos, err = p.s.Next(); err != nil {
+			return
+		}
+		if p.tok != Comment {
+			return
+		}
+		p.file.Comments = append(p.file.Comments, &CommentLine{Pos: p.pos, End: p.s.pos(), Text: p.lit})
+	}
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...any) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// 当前词法单元的描述，用于错误信息
+func (p *parser) found() string {
+	switch p.tok {
+	case EOF:
+		return "EOF"
+	case Ident, Int, Float, String:
+		return fmt.Sprintf("%s %s", p.tok, p.lit)
+	default:
+		return "'" + p.lit + "'"
+	}
+}
+
+// 读取一个指定的词法单元
+func (p *parser) expect(tok Token, context string) (pos Pos, err error) {
+	pos = p.pos
+	if p.tok != tok {
+		return pos, p.errorf(pos, "expected '%s' %s, found %s", tok, context, p.found())
+	}
+	return pos, p.next()
+}
+
+// 当前词法单元是否为关键字 word
+func (p *parser) is(word string) bool {
+	return p.tok == Ident && p.lit == word
+}
+
+// 读取一个关键字
+func (p *parser) keyword(word string) (pos Pos, err error) {
+	pos = p.pos
+	if !p.is(word) {
+		return pos, p.errorf(pos, "expected '%s', found %s", word, p.found())
+	}
+	return pos, p.next()
+}
+
+// 读取一个名字，what 用于错误信息
+func (p *parser) name(what string) (name string, pos Pos, err error) {
+	name, pos = p.lit, p.pos
+	if p.tok != Ident {
+		return "", pos, p.errorf(pos, "expected %s name, found %s", what, p.found())
+	}
+	if keywords[name] {
+		return "", pos, p.errorf(pos, "%s name %s is a keyword", what, name)
+	}
+	return name, pos, p.next()
+}
+
+// 声明结尾的 };
+func (p *parser) closeBlock(what string) (end Pos, err error) {
+	if end, err = p.expect(RBrace, "to close "+what); err != nil {
+		return
+	}
+	_, err = p.expect(Semicolon, "after "+what)
+	return
+}
+
+// file := { include | module }
+func (p *parser) parseFile() (err error) {
+	for p.tok != EOF {
+		switch {
+		case p.tok == Include:
+			pos := p.pos
+			if err = p.next(); err != nil {
+				return
+			}
+			if p.tok != String {
+				return p.errorf(p.pos, "expected include path string, found %s", p.found())
+			}
+			path, err := strconv.Unquote(p.lit)
+			if err != nil {
+				return p.errorf(p.pos, "invalid include path %s", p.lit)
+			}
+			p.file.Includes = append(p.file.Includes, &IncludeDecl{Pos: pos, Path: path})
+			if err = p.next(); err != nil {
+				return err
+			}
+		case p.is("module"):
+			m, err := p.parseModule()
+			if err != nil {
+				return err
+			}
+			p.file.Modules = append(p.file.Modules, m)
+		default:
+			return p.errorf(p.pos, "expected 'module' or '#include', found %s", p.found())
+		}
+	}
+	return
+}
+
+// module := "module" name "{" { decl } "}" ";"
+func (p *parser) parseModule() (m *Module, err error) {
+	m = &Module{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if m.Name, _, err = p.name("module"); err != nil {
+		return
+	}
+	if _, err = p.expect(LBrace, "after module name"); err != nil {
+		return
+	}
+
+	for p.tok != RBrace && p.tok != EOF {
+		var decl Decl
+		switch {
+		case p.is("struct"):
+			decl, err = p.parseStruct()
+		case p.is("enum"):
+			decl, err = p.parseEnum()
+		case p.is("const"):
+			decl, err = p.parseConst()
+		case p.is("key"):
+			decl, err = p.parseKey()
+		case p.is("interface"):
+			decl, err = p.parseInterface()
+		default:
+			err = p.errorf(p.pos, "expected declaration (struct, enum, const, key or interface), found %s", p.found())
+		}
+		if err != nil {
+			return
+		}
+		m.Decls = append(m.Decls, decl)
+	}
+
+	m.End, err = p.closeBlock("module")
+	return
+}
+
+// struct := "struct" name "{" { field } "}" ";"
+func (p *parser) parseStruct() (s *Struct, err error) {
+	s = &Struct{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if s.Name, _, err = p.name("struct"); err != nil {
+		return
+	}
+	if _, err = p.expect(LBrace, "after struct name"); err != nil {
+		return
+	}
+	for p.tok != RBrace && p.tok != EOF {
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	s.End, err = p.closeBlock("struct")
+	return
+}
+
+// field := tag ("require" | "optional") type name [ "=" literal ] ";"
+func (p *parser) parseField() (f *Field, err error) {
+	// [step 1] tag
+	f = &Field{Pos: p.pos, TagPos: p.pos}
+	if p.tok != Int {
+		return nil, p.errorf(p.pos, "expected field tag, found %s", p.found())
+	}
+	tag, err := strconv.ParseInt(p.lit, 0, 64)
+	if err != nil || tag < 0 {
+		return nil, p.errorf(p.pos, "invalid field tag %s", p.lit)
+	}
+	f.Tag = int(tag)
+	if err = p.next(); err != nil {
+		return
+	}
+
+	// [step 2] require、optional
+	switch {
+	case p.is("require"):
+		f.Require = true
+	case p.is("optional"):
+	default:
+		return nil, p.errorf(p.pos, "expected 'require' or 'optional', found %s", p.found())
+	}
+	if err = p.next(); err != nil {
+		return
+	}
+
+	// [step 3] 类型、名字、默认值
+	if f.Type, err = p.parseType(); err != nil {
+		return
+	}
+	if f.Name, _, err = p.name("field"); err != nil {
+		return
+	}
+	if p.tok == Assign {
+		if err = p.next(); err != nil {
+			return
+		}
+		if f.Default, err = p.parseLiteral(); err != nil {
+			return
+		}
+	}
+	_, err = p.expect(Semicolon, "after field")
+	return
+}
+
+// type := ["unsigned"] basic | "vector" "<" type ">" | "map" "<" type "," type ">" | name ["::" name]
+func (p *parser) parseType() (t *Type, err error) {
+	t = &Type{Pos: p.pos}
+	if p.tok != Ident {
+		return nil, p.errorf(p.pos, "expected type, found %s", p.found())
+	}
+
+	// [step 1] unsigned 只能用于 byte、short、int
+	if p.is("unsigned") {
+		t.Unsigned = true
+		if err = p.next(); err != nil {
+			return
+		}
+		if kind, ok := basicTypes[p.lit]; !ok || p.tok != Ident || kind != TypeByte && kind != TypeShort && kind != TypeInt {
+			return nil, p.errorf(p.pos, "expected byte, short or int after 'unsigned', found %s", p.found())
+		}
+	}
+
+	// [step 2] 基础类型
+	if kind, ok := basicTypes[p.lit]; ok {
+		t.Kind = kind
+		return t, p.next()
+	}
+
+	switch {
+	// [step 3] vector、map
+	case p.is("vector"):
+		t.Kind = TypeVector
+		if err = p.next(); err != nil {
+			return
+		}
+		if _, err = p.expect(Less, "after vector"); err != nil {
+			return
+		}
+		if t.Elem, err = p.parseType(); err != nil {
+			return
+		}
+		_, err = p.expect(Greater, "to close vector")
+		return
+	case p.is("map"):
+		t.Kind = TypeMap
+		if err = p.next(); err != nil {
+			return
+		}
+		if _, err = p.expect(Less, "after map"); err != nil {
+			return
+		}
+		if t.Key, err = p.parseType(); err != nil {
+			return
+		}
+		if _, err = p.expect(Comma, "between map key and value"); err != nil {
+			return
+		}
+		if t.Elem, err = p.parseType(); err != nil {
+			return
+		}
+		_, err = p.expect(Greater, "to close map")
+		return
+
+	// [step 4] struct、enum 的名字，可以带 module
+	default:
+		t.Kind = TypeNamed
+		if t.Name, _, err = p.name("type"); err != nil {
+			return
+		}
+		if p.tok == Scope {
+			if err = p.next(); err != nil {
+				return
+			}
+			t.Module = t.Name
+			if t.Name, _, err = p.name("type"); err != nil {
+				return
+			}
+		}
+		return
+	}
+}
+
+// literal := int | float | string | "true" | "false" | name ["::" name]
+func (p *parser) parseLiteral() (l *Literal, err error) {
+	l = &Literal{Pos: p.pos, Text: p.lit}
+	switch {
+	case p.tok == Int:
+		l.Kind = LiteralInt
+	case p.tok == Float:
+		l.Kind = LiteralFloat
+	case p.tok == String:
+		l.Kind = LiteralString
+	case p.is("true") || p.is("false"):
+		l.Kind = LiteralBool
+	case p.tok == Ident && !keywords[p.lit]:
+		l.Kind = LiteralIdent
+		if err = p.next(); err != nil {
+			return
+		}
+		if p.tok != Scope {
+			return
+		}
+		if err = p.next(); err != nil {
+			return
+		}
+		name, _, err := p.name("value")
+		if err != nil {
+			return nil, err
+		}
+		l.Text += "::" + name
+		return l, nil
+	default:
+		return nil, p.errorf(p.pos, "expected value, found %s", p.found())
+	}
+	return l, p.next()
+}
+
+// enum := "enum" name "{" [ member { "," member } [","] ] "}" ";"
+func (p *parser) parseEnum() (e *Enum, err error) {
+	e = &Enum{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if e.Name, _, err = p.name("enum"); err != nil {
+		return
+	}
+	if _, err = p.expect(LBrace, "after enum name"); err != nil {
+		return
+	}
+	for p.tok != RBrace && p.tok != EOF {
+		m := &EnumMember{}
+		if m.Name, m.Pos, err = p.name("enum member"); err != nil {
+			return
+		}
+		if p.tok == Assign {
+			if err = p.next(); err != nil {
+				return
+			}
+			if m.Value, err = p.parseLiteral(); err != nil {
+				return
+			}
+			if m.Value.Kind != LiteralInt && m.Value.Kind != LiteralIdent {
+				return nil, p.errorf(m.Value.Pos, "enum value must be an integer or another member, found %s", m.Value.Text)
+			}
+		}
+		e.Members = append(e.Members, m)
+
+		// 最后一个成员后面的逗号可以省略
+		if p.tok != Comma {
+			break
+		}
+		if err = p.next(); err != nil {
+			return
+		}
+	}
+	e.End, err = p.closeBlock("enum")
+	return
+}
+
+// const := "const" type name "=" literal ";"
+func (p *parser) parseConst() (c *Const, err error) {
+	c = &Const{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if c.Type, err = p.parseType(); err != nil {
+		return
+	}
+	if c.Name, _, err = p.name("const"); err != nil {
+		return
+	}
+	if _, err = p.expect(Assign, "after const name"); err != nil {
+		return
+	}
+	if c.Value, err = p.parseLiteral(); err != nil {
+		return
+	}
+	_, err = p.expect(Semicolon, "after const")
+	return
+}
+
+// key := "key" "[" struct { "," field } "]" ";"
+func (p *parser) parseKey() (k *Key, err error) {
+	k = &Key{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if _, err = p.expect(LBrack, "after key"); err != nil {
+		return
+	}
+	if k.Struct, _, err = p.name("struct"); err != nil {
+		return
+	}
+	for p.tok == Comma {
+		if err = p.next(); err != nil {
+			return
+		}
+		name, pos, err := p.name("field")
+		if err != nil {
+			return nil, err
+		}
+		k.Fields = append(k.Fields, name)
+		k.FieldsPos = append(k.FieldsPos, pos)
+	}
+	if len(k.Fields) == 0 {
+		return nil, p.errorf(p.pos, "expected ',' and field name in key, found %s", p.found())
+	}
+	if _, err = p.expect(RBrack, "to close key"); err != nil {
+		return
+	}
+	_, err = p.expect(Semicolon, "after key")
+	return
+}
+
+// interface := "interface" name "{" { method } "}" ";"
+func (p *parser) parseInterface() (i *Interface, err error) {
+	i = &Interface{Pos: p.pos}
+	if err = p.next(); err != nil {
+		return
+	}
+	if i.Name, _, err = p.name("interface"); err != nil {
+		return
+	}
+	if _, err = p.expect(LBrace, "after interface name"); err != nil {
+		return
+	}
+	for p.tok != RBrace && p.tok != EOF {
+		m, err := p.parseMethod()
+		if err != nil {
+			return nil, err
+		}
+		i.Methods = append(i.Methods, m)
+	}
+	i.End, err = p.closeBlock("interface")
+	return
+}
+
+// method := ("void" | type) name "(" [ param { "," param } ] ")" ";"
+func (p *parser) parseMethod() (m *Method, err error) {
+	m = &Method{Pos: p.pos}
+	if p.is("void") {
+		err = p.next()
+	} else {
+		m.Result, err = p.parseType()
+	}
+	if err != nil {
+		return
+	}
+	if m.Name, _, err = p.name("method"); err != nil {
+		return
+	}
+	if _, err = p.expect(LParen, "after method name"); err != nil {
+		return
+	}
+	for p.tok != RParen && p.tok != EOF {
+		param, err := p.parseParam()
+		if err != nil {
+			return nil, err
+		}
+		m.Params = append(m.Params, param)
+		if p.tok != Comma {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = p.expect(RParen, "to close parameters"); err != nil {
+		return
+	}
+	_, err = p.expect(Semicolon, "after method")
+	return
+}
+
+// param := ["out"] ["routekey"] type name
+func (p *parser) parseParam() (param *Param, err error) {
+	param = &Param{Pos: p.pos}
+	for p.is("out") || p.is("routekey") {
+		if p.is("out") {
+			param.Out = true
+		} else {
+			param.RouteKey = true
+		}
+		if err = p.next(); err != nil {
+			return
+		}
+	}
+	if param.Type, err = p.parseType(); err != nil {
+		return
+	}
+	param.Name, _, err = p.name("parameter")
+	return
+}
@@ -0,0 +1,115 @@
+package idl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	f, err := ParseFile("testdata/demo.jce", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// [step 1] include、注释
+	if len(f.Includes) != 1 || f.Includes[0].Path != "common.jce" {
+		t.Errorf("includes = %+v", f.Includes)
+	}
+	if len(f.Comments) != 2 || f.Comments[0].Text != "// 演示用的 IDL，覆盖所有的语法" || f.Comments[1].Pos.Line != 16 {
+		t.Errorf("comments = %+v", f.Comments)
+	}
+	if len(f.Modules) != 1 || f.Modules[0].Name != "Demo" || len(f.Modules[0].Decls) != 6 {
+		t.Fatalf("modules = %+v", f.Modules)
+	}
+	m := f.Modules[0]
+
+	// [step 2] enum、const
+	e := m.Enum("Color")
+	if e == nil || len(e.Members) != 3 || e.Members[1].Value.Text != "2" || e.Members[2].Value != nil {
+		t.Errorf("enum = %+v", e)
+	}
+	c := m.Decls[2].(*Const)
+	if c.Type.Kind != TypeString || c.Name != "NAME" || c.Value.Kind != LiteralString || c.Value.Text != `"demo"` {
+		t.Errorf("const = %+v", c)
+	}
+
+	// [step 3] struct
+	s := m.Struct("User")
+	if s == nil || len(s.Fields) != 10 {
+		t.Fatalf("struct = %+v", s)
+	}
+	var fields []string
+	for _, field := range s.Fields {
+		line := field.Position().String() + " " + field.Type.String() + " " + field.Name
+		if field.Require {
+			line += " require"
+		}
+		if field.Default != nil {
+			line += " = " + field.Default.Text
+		}
+		fields = append(fields, line)
+	}
+	want := `testdata/demo.jce:19:9 long id require
+testdata/demo.jce:20:9 string name = "guest"
+testdata/demo.jce:21:9 unsigned int age = 18
+testdata/demo.jce:22:9 Color color = GREEN
+testdata/demo.jce:23:9 vector<string> tags
+testdata/demo.jce:24:9 map<string, vector<int>> scores
+testdata/demo.jce:25:9 Common::Address address
+testdata/demo.jce:26:9 vector<byte> avatar
+testdata/demo.jce:27:9 double weight = -1.5
+testdata/demo.jce:28:9 bool vip = false`
+	if got := strings.Join(fields, "\n"); got != want {
+		t.Errorf("fields\nwant:\n%s\ngot:\n%s", want, got)
+	}
+	if s.Fields[6].Type.Module != "Common" || s.Fields[9].Tag != 9 || s.End.Line != 29 {
+		t.Errorf("fields = %+v, end = %s", s.Fields, s.End)
+	}
+
+	// [step 4] key、interface
+	k := m.Decls[4].(*Key)
+	if k.Struct != "User" || strings.Join(k.Fields, ",") != "id,name" {
+		t.Errorf("key = %+v", k)
+	}
+	i := m.Decls[5].(*Interface)
+	if len(i.Methods) != 3 || i.Methods[1].Result != nil || len(i.Methods[1].Params) != 0 {
+		t.Fatalf("interface = %+v", i)
+	}
+	batch := i.Methods[2]
+	if batch.Result.Kind != TypeInt || len(batch.Params) != 3 || !batch.Params[0].RouteKey || !batch.Params[2].Out ||
+		batch.Params[2].Type.String() != "map<long, User>" {
+		t.Errorf("method = %+v", batch)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"struct A {};", "1:1: expected 'module' or '#include', found identifier struct"},
+		{"module {", "1:8: expected module name, found '{'"},
+		{"module M { struct A { 0 require int a; } }", "1:42: expected ';' after struct, found '}'"},
+		{"module M { struct A { require int a; }; };", "1:23: expected field tag, found identifier require"},
+		{"module M { struct A { 0 int a; }; };", "1:25: expected 'require' or 'optional', found identifier int"},
+		{"module M { struct A { 0 optional unsigned long a; }; };", "1:43: expected byte, short or int after 'unsigned', found identifier long"},
+		{"module M { struct A { 0 optional vector<int a; }; };", "1:45: expected '>' to close vector, found identifier a"},
+		{"module M { struct A { 0 optional int struct; }; };", "1:38: field name struct is a keyword"},
+		{"module M { struct A { 0 optional int a = ; }; };", "1:42: expected value, found ';'"},
+		{"module M { enum E { A = 1.5 }; };", "1:25: enum value must be an integer or another member, found 1.5"},
+		{"module M { key[A]; };", "1:17: expected ',' and field name in key, found ']'"},
+		{"module M { interface I { void f(int a b); }; };", "1:39: expected ')' to close parameters, found identifier b"},
+		{"module M { typedef int a; };", "1:12: expected declaration (struct, enum, const, key or interface), found identifier typedef"},
+		{"module M { struct A {", "1:22: expected '}' to close struct, found EOF"},
+		{"#include abc", "1:10: expected include path string, found identifier abc"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFile("a.jce", []byte(tt.src))
+		if err == nil || err.Error() != "a.jce:"+tt.want {
+			t.Errorf("ParseFile(%q) error = %v, want a.jce:%s", tt.src, err, tt.want)
+		}
+		if _, ok := err.(*Error); err != nil && !ok {
+			t.Errorf("ParseFile(%q) error type %T, want *Error", tt.src, err)
+		}
+	}
+}
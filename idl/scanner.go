@@ -0,0 +1,246 @@
+package idl
+
+import (
+	"fmt"
+)
+
+// Error 带有位置的词法、语法错误
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// Scanner 把 IDL 源码切分为词法单元
+type Scanner struct {
+	filename string
+	src      []byte
+	offset   int
+	line     int
+	col      int
+}
+
+// 创建一个 Scanner，filename 只用于位置信息
+func NewScanner(filename string, src []byte) *Scanner {
+	return &Scanner{filename: filename, src: src, line: 1, col: 1}
+}
+
+func (s *Scanner) pos() Pos {
+	return Pos{Filename: s.filename, Offset: s.offset, Line: s.line, Column: s.col}
+}
+
+func (s *Scanner) errorf(pos Pos, format string, args ...any) error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// 返回 offset 之后第 n 个字节，超出范围时为 0
+func (s *Scanner) peek(n int) byte {
+	if s.offset+n < len(s.src) {
+		return s.src[s.offset+n]
+	}
+	return 0
+}
+
+func (s *Scanner) advance(n int) {
+	for i := 0; i < n && s.offset < len(s.src); i++ {
+		if s.src[s.offset] == '\n' {
+			s.line, s.col = s.line+1, 1
+		} else {
+			s.col++
+		}
+		s.offset++
+	}
+}
+
+// 读取下一个词法单元，lit 为源码中的原文，结束时返回 EOF
+func (s *Scanner) Next() (tok Token, lit string, pos Pos, err error) {
+	// [step 1] 跳过空白
+	for s.offset < len(s.src) && isSpace(s.src[s.offset]) {
+		s.advance(1)
+	}
+	pos = s.pos()
+	if s.offset >= len(s.src) {
+		return EOF, "", pos, nil
+	}
+
+	start := s.offset
+	c := s.src[s.offset]
+	switch {
+	// [step 2] 注释
+	case c == '/' && s.peek(1) == '/':
+		for s.offset < len(s.src) && s.src[s.offset] != '\n' {
+			s.advance(1)
+		}
+		tok = Comment
+	case c == '/' && s.peek(1) == '*':
+		s.advance(2)
+		for s.offset < len(s.src) && !(s.src[s.offset] == '*' && s.peek(1) == '/') {
+			s.advance(1)
+		}
+		if s.offset >= len(s.src) {
+			return EOF, "", pos, s.errorf(pos, "comment not terminated")
+		}
+		s.advance(2)
+		tok = Comment
+
+	// [step 3] 标识符、关键字
+	case isLetter(c):
+		for s.offset < len(s.src) && (isLetter(s.src[s.offset]) || isDigit(s.src[s.offset])) {
+			s.advance(1)
+		}
+		tok = Ident
+
+	// [step 4] 数字，负号是数字的一部分
+	case isDigit(c) || (c == '-' || c == '.') && isDigit(s.peek(1)) || c == '-' && s.peek(1) == '.' && isDigit(s.peek(2)):
+		if tok, err = s.number(pos); err != nil {
+			return
+		}
+
+	// [step 5] 字符串
+	case c == '"':
+		s.advance(1)
+		for {
+			if s.offset >= len(s.src) || s.src[s.offset] == '\n' {
+				return EOF, "", pos, s.errorf(pos, "string literal not terminated")
+			}
+			if s.src[s.offset] == '"' {
+				break
+			}
+			if s.src[s.offset] == '\\' {
+				s.advance(1)
+			}
+			s.advance(1)
+		}
+		s.advance(1)
+		tok = String
+
+	// [step 6] #include
+	case c == '#':
+		s.advance(1)
+		for s.offset < len(s.src) && isLetter(s.src[s.offset]) {
+			s.advance(1)
+		}
+		if word := string(s.src[start:s.offset]); word != "#include" {
+			return EOF, "", pos, s.errorf(pos, "unknown directive %s", word)
+		}
+		tok = Include
+
+	// [step 7] 标点
+	case c == ':' && s.peek(1) == ':':
+		s.advance(2)
+		tok = Scope
+	default:
+		if tok = punctuation(c); tok == EOF {
+			return EOF, "", pos, s.errorf(pos, "unexpected character %q", c)
+		}
+		s.advance(1)
+	}
+	return tok, string(s.src[start:s.offset]), pos, nil
+}
+
+// 读取一个数字：10 进制、16 进制的整数，以及浮点数
+func (s *Scanner) number(pos Pos) (tok Token, err error) {
+	if s.peek(0) == '-' {
+		s.advance(1)
+	}
+
+	// [step 1] 16 进制
+	if s.peek(0) == '0' && (s.peek(1) == 'x' || s.peek(1) == 'X') {
+		s.advance(2)
+		n := 0
+		for ; isHex(s.peek(0)); n++ {
+			s.advance(1)
+		}
+		if n == 0 {
+			return EOF, s.errorf(pos, "hexadecimal literal has no digits")
+		}
+		return Int, s.checkEnd(pos)
+	}
+
+	// [step 2] 整数部分、小数部分、指数部分
+	tok = Int
+	for isDigit(s.peek(0)) {
+		s.advance(1)
+	}
+	if s.peek(0) == '.' {
+		tok = Float
+		s.advance(1)
+		for isDigit(s.peek(0)) {
+			s.advance(1)
+		}
+	}
+	if c := s.peek(0); c == 'e' || c == 'E' {
+		tok = Float
+		s.advance(1)
+		if c := s.peek(0); c == '+' || c == '-' {
+			s.advance(1)
+		}
+		if !isDigit(s.peek(0)) {
+			return EOF, s.errorf(pos, "exponent has no digits")
+		}
+		for isDigit(s.peek(0)) {
+			s.advance(1)
+		}
+	}
+	// C 风格的浮点数后缀
+	if c := s.peek(0); tok == Float && (c == 'f' || c == 'F') {
+		s.advance(1)
+	}
+	return tok, s.checkEnd(pos)
+}
+
+// 数字后面不能紧跟字母，比如 12abc
+func (s *Scanner) checkEnd(pos Pos) error {
+	if c := s.peek(0); isLetter(c) || isDigit(c) {
+		return s.errorf(pos, "invalid character %q in number", c)
+	}
+	return nil
+}
+
+func punctuation(c byte) Token {
+	switch c {
+	case '{':
+		return LBrace
+	case '}':
+		return RBrace
+	case '(':
+		return LParen
+	case ')':
+		return RParen
+	case '[':
+		return LBrack
+	case ']':
+		return RBrack
+	case '<':
+		return Less
+	case '>':
+		return Greater
+	case ',':
+		return Comma
+	case ';':
+		return Semicolon
+	case '=':
+		return Assign
+	default:
+		return EOF
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
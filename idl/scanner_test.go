@@ -0,0 +1,81 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	src := `#include "a.jce"
+map<string, vector<int>> // line
+-12 0x1F 1.5e3 -.5 2.0f /* block
+comment */ Demo::User;`
+
+	want := []string{
+		`#include "#include" 1:1`,
+		`string "\"a.jce\"" 1:10`,
+		`identifier "map" 2:1`,
+		`< "<" 2:4`,
+		`identifier "string" 2:5`,
+		`, "," 2:11`,
+		`identifier "vector" 2:13`,
+		`< "<" 2:19`,
+		`identifier "int" 2:20`,
+		`> ">" 2:23`,
+		`> ">" 2:24`,
+		`comment "// line" 2:26`,
+		`integer "-12" 3:1`,
+		`integer "0x1F" 3:5`,
+		`float "1.5e3" 3:10`,
+		`float "-.5" 3:16`,
+		`float "2.0f" 3:20`,
+		`comment "/* block\ncomment */" 3:25`,
+		`identifier "Demo" 4:12`,
+		`:: "::" 4:16`,
+		`identifier "User" 4:18`,
+		`; ";" 4:22`,
+		`EOF "" 4:23`,
+	}
+
+	s := NewScanner("", []byte(src))
+	var got []string
+	for {
+		tok, lit, pos, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s %q %d:%d", tok, lit, pos.Line, pos.Column))
+		if tok == EOF {
+			break
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tokens\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestScannerError(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`"abc`, "a.jce:1:1: string literal not terminated"},
+		{"/* abc", "a.jce:1:1: comment not terminated"},
+		{"#define X", "a.jce:1:1: unknown directive #define"},
+		{"  @", `a.jce:1:3: unexpected character '@'`},
+		{"12ab", `a.jce:1:1: invalid character 'a' in number`},
+		{"0x", "a.jce:1:1: hexadecimal literal has no digits"},
+		{"1e+", "a.jce:1:1: exponent has no digits"},
+	}
+	for _, tt := range tests {
+		s := NewScanner("a.jce", []byte(tt.src))
+		var err error
+		for tok := Token(-1); err == nil && tok != EOF; {
+			tok, _, _, err = s.Next()
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("scan %q error = %v, want %s", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package idl
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Pos 源码中的位置
+type Pos struct {
+	Filename string
+	Offset   int // 字节偏移，从 0 开始
+	Line     int // 行号，从 1 开始
+	Column   int // 列号（字节），从 1 开始
+}
+
+// 是否为有效的位置
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Pos) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Token 词法单元的类型
+type Token int
+
+const (
+	EOF     Token = iota
+	Comment       // // 或者 /* */ 注释
+	Ident         // 标识符和关键字
+	Int           // 整数，包括 16 进制和负数
+	Float         // 浮点数
+	String        // 字符串，Lit 为包括引号的原文
+	Include       // #include
+
+	LBrace    // {
+	RBrace    // }
+	LParen    // (
+	RParen    // )
+	LBrack    // [
+	RBrack    // ]
+	Less      // <
+	Greater   // >
+	Comma     // ,
+	Semicolon // ;
+	Assign    // =
+	Scope     // ::
+)
+
+var tokens = [...]string{
+	EOF:       "EOF",
+	Comment:   "comment",
+	Ident:     "identifier",
+	Int:       "integer",
+	Float:     "float",
+	String:    "string",
+	Include:   "#include",
+	LBrace:    "{",
+	RBrace:    "}",
+	LParen:    "(",
+	RParen:    ")",
+	LBrack:    "[",
+	RBrack:    "]",
+	Less:      "<",
+	Greater:   ">",
+	Comma:     ",",
+	Semicolon: ";",
+	Assign:    "=",
+	Scope:     "::",
+}
+
+func (t Token) String() string {
+	if t >= 0 && int(t) < len(tokens) {
+		return tokens[t]
+	}
+	return "Token(" + strconv.Itoa(int(t)) + ")"
+}
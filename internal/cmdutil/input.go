@@ -0,0 +1,67 @@
+// cmdutil 命令行工具共用的输入、输出处理
+package cmdutil
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 输入、输出数据的格式
+const (
+	FormatRaw    = "raw"    // 原始的二进制数据
+	FormatHex    = "hex"    // 16 进制文本，忽略空白以及 0x 前缀
+	FormatBase64 = "base64" // base64 文本，忽略空白，支持标准、URL 两种字符集
+)
+
+// 读取 path 的内容，path 为空或者 "-" 时读取 stdin
+func ReadFile(path string) (data []byte, err error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// 按 format 把输入的数据解码为二进制
+func Decode(data []byte, format string) (out []byte, err error) {
+	switch format {
+	case FormatRaw, "":
+		return data, nil
+
+	case FormatHex:
+		s := strings.Join(strings.Fields(string(data)), "")
+		s = strings.ReplaceAll(strings.ReplaceAll(s, "0x", ""), "0X", "")
+		if out, err = hex.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("decode hex failed, err:%s", err)
+		}
+		return
+
+	case FormatBase64:
+		s := strings.Join(strings.Fields(string(data)), "")
+		enc := base64.StdEncoding
+		if strings.ContainsAny(s, "-_") {
+			enc = base64.URLEncoding
+		}
+		if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+			enc = enc.WithPadding(base64.NoPadding)
+		}
+		if out, err = enc.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("decode base64 failed, err:%s", err)
+		}
+		return
+
+	default:
+		return nil, fmt.Errorf("unknown input format %q, want raw, hex or base64", format)
+	}
+}
+
+// 读取 path 的内容并按 format 解码
+func ReadInput(path, format string) (data []byte, err error) {
+	if data, err = ReadFile(path); err != nil {
+		return
+	}
+	return Decode(data, format)
+}
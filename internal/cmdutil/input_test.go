@@ -0,0 +1,51 @@
+package cmdutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	want := []byte{0x00, 0x07, 0x71, 0xfb}
+	tests := []struct {
+		format string
+		in     string
+		err    bool
+	}{
+		{FormatRaw, string(want), false},
+		{FormatHex, "00 07\n71fb", false},
+		{FormatHex, "0x00 0x07 0x71 0xFB", false},
+		{FormatHex, "0", true},
+		{FormatBase64, "AAdx+w==\n", false},
+		{FormatBase64, "AAdx-w", false},
+		{FormatBase64, "!!", true},
+		{"json", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Decode([]byte(tt.in), tt.format)
+		if (err != nil) != tt.err {
+			t.Errorf("Decode(%q, %s) error = %v, want error %v", tt.in, tt.format, err, tt.err)
+			continue
+		}
+		if !tt.err && !bytes.Equal(got, want) {
+			t.Errorf("Decode(%q, %s) = % x, want % x", tt.in, tt.format, got, want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data := []byte{0x00, 0x07, 0x71, 0xfb}
+	for _, format := range []string{FormatRaw, FormatHex, FormatBase64} {
+		out, err := Encode(data, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := Decode(out, format)
+		if err != nil || !bytes.Equal(back, data) {
+			t.Errorf("Decode(Encode(%s)) = % x, %v", format, back, err)
+		}
+	}
+	if _, err := Encode(data, "json"); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
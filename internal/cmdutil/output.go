@@ -0,0 +1,21 @@
+package cmdutil
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// 按 format 把二进制数据编码为输出的格式，hex、base64 以换行结尾，方便逐行输出多个消息
+func Encode(data []byte, format string) (out []byte, err error) {
+	switch format {
+	case FormatRaw, "":
+		return data, nil
+	case FormatHex:
+		return append([]byte(hex.EncodeToString(data)), '\n'), nil
+	case FormatBase64:
+		return append([]byte(base64.StdEncoding.EncodeToString(data)), '\n'), nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, want raw, hex or base64", format)
+	}
+}
@@ -0,0 +1,254 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jce 包的 import 路径，生成的代码中使用 jce 作为包名
+const ImportPath = "github.com/erpc-go/jce-codec"
+
+// File 生成的一个 go 文件
+type File struct {
+	Package string
+	Header  string // 文件开头的注释，不包括 //，可以有多行
+
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// 添加一个 import
+func (f *File) Import(path string) {
+	if f.imports == nil {
+		f.imports = map[string]bool{}
+	}
+	f.imports[path] = true
+}
+
+// 输出一段代码，不自动换行
+func (f *File) Printf(format string, args ...any) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+// 格式化之后的源码，生成的代码有语法错误时返回错误，同时返回未格式化的源码用于排查
+func (f *File) Source() (src []byte, err error) {
+	var b bytes.Buffer
+	for _, line := range strings.Split(f.Header, "\n") {
+		b.WriteString("// " + line + "\n")
+	}
+	b.WriteString("\npackage " + f.Package + "\n\n")
+
+	// 标准库和其他的包分为两组
+	if len(f.imports) > 0 {
+		var std, other []string
+		for path := range f.imports {
+			if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+				other = append(other, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+		b.WriteString("import (\n")
+		for _, path := range std {
+			b.WriteString(strconv.Quote(path) + "\n")
+		}
+		if len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		for _, path := range other {
+			if path == ImportPath {
+				b.WriteString("jce ")
+			}
+			b.WriteString(strconv.Quote(path) + "\n")
+		}
+		b.WriteString(")\n")
+	}
+	b.Write(f.body.Bytes())
+
+	if src, err = format.Source(b.Bytes()); err != nil {
+		return b.Bytes(), fmt.Errorf("format generated code failed, err:%s", err)
+	}
+	return
+}
+
+// ---------------------------------------------------------------------------
+// 方法
+// 每个 struct 生成 ResetDefault、WriteTo、ReadFrom 以及读写字段的两个辅助方法，
+// 嵌套的 struct 通过 NewEncoder(e)、ReadFrom(d) 共享父 Encoder、Decoder 的缓冲区
+// ---------------------------------------------------------------------------
+
+// 生成 m 的所有方法
+func (f *File) Methods(m *Message) {
+	f.Import("io")
+	f.Import(ImportPath)
+	f.resetDefault(m)
+	f.writeTo(m)
+	f.readFrom(m)
+}
+
+// ResetDefault 把所有字段设置为默认值，ReadFrom 在读取之前调用，这样没有出现的 optional 字段为默认值
+func (f *File) resetDefault(m *Message) {
+	f.Printf("\n// ResetDefault 把所有字段设置为默认值\n")
+	f.Printf("func (m *%s) ResetDefault() {\n", m.Name)
+	f.Printf("*m = %s{}\n", m.Name)
+	for _, field := range m.Fields {
+		switch {
+		case field.Default != "":
+			f.Printf("m.%s = %s\n", field.Name, field.Default)
+		case field.Type.Kind == Struct:
+			f.Printf("m.%s.ResetDefault()\n", field.Name)
+		}
+	}
+	f.Printf("}\n")
+}
+
+func (f *File) writeTo(m *Message) {
+	f.Printf("\n// WriteTo 实现 jce.Messager\n")
+	f.Printf("func (m *%s) WriteTo(w io.Writer) (n int64, err error) {\n", m.Name)
+	f.Printf("e := jce.NewEncoder(w)\n")
+	f.Printf("start := e.Offset()\n")
+	f.Printf("if err = m.jceWriteFields(e); err == nil {\n")
+	f.Printf("err = e.Flush()\n")
+	f.Printf("}\n")
+	f.Printf("return e.Offset() - start, err\n")
+	f.Printf("}\n")
+
+	f.Printf("\nfunc (m *%s) jceWriteFields(e *jce.Encoder) (err error) {\n", m.Name)
+	for _, field := range m.Fields {
+		f.write(field.Type, "m."+field.Name, strconv.Itoa(int(field.Tag)), 0)
+	}
+	f.Printf("return nil\n")
+	f.Printf("}\n")
+}
+
+func (f *File) readFrom(m *Message) {
+	f.Printf("\n// ReadFrom 实现 jce.Messager，没有出现的 optional 字段为默认值\n")
+	f.Printf("func (m *%s) ReadFrom(r io.Reader) (n int64, err error) {\n", m.Name)
+	f.Printf("d := jce.NewDecoder(r)\n")
+	f.Printf("start := d.Offset()\n")
+	f.Printf("m.ResetDefault()\n")
+	f.Printf("err = m.jceReadFields(d)\n")
+	f.Printf("return d.Offset() - start, err\n")
+	f.Printf("}\n")
+
+	f.Printf("\nfunc (m *%s) jceReadFields(d *jce.Decoder) (err error) {\n", m.Name)
+	for _, field := range m.Fields {
+		name := field.Wire
+		if name == "" {
+			name = field.Name
+		}
+		f.read(field.Type, "m."+field.Name, strconv.Itoa(int(field.Tag)), strconv.FormatBool(field.Require), m.Name+"."+name, 0)
+	}
+	f.Printf("return d.SkipToStructEnd()\n")
+	f.Printf("}\n")
+}
+
+// 写一个值，容器的元素、map 的 key 使用 tag 0，map 的 value 使用 tag 1，
+// depth 用于生成不冲突的循环变量
+func (f *File) write(t *Type, expr, tag string, depth int) {
+	switch t.Kind {
+	case Struct:
+		f.check("e.WriteHead(jce.StructBegin, %s)", tag)
+		f.check("_, err = %s.WriteTo(e)", expr)
+		f.check("e.WriteHead(jce.StructEnd, 0)")
+	case Slice, Array:
+		i := "i" + strconv.Itoa(depth)
+		f.check("e.WriteHead(jce.List, %s)", tag)
+		f.check("e.WriteLength(uint32(len(%s)))", expr)
+		f.Printf("for %s := range %s {\n", i, expr)
+		f.write(t.Elem, expr+"["+i+"]", "0", depth+1)
+		f.Printf("}\n")
+	case Map:
+		k, v := "k"+strconv.Itoa(depth), "v"+strconv.Itoa(depth)
+		f.check("e.WriteHead(jce.Map, %s)", tag)
+		f.check("e.WriteLength(uint32(len(%s)))", expr)
+		f.Printf("for %s, %s := range %s {\n", k, v, expr)
+		f.write(t.Key, k, "0", depth+1)
+		f.write(t.Elem, v, "1", depth+1)
+		f.Printf("}\n")
+	default:
+		if t.named() {
+			expr = basics[t.Kind].goType + "(" + expr + ")"
+		}
+		f.check("e.Write%s(%s, %s)", basics[t.Kind].method, expr, tag)
+	}
+}
+
+// 读一个值到 expr，name 为错误信息中值的名字
+func (f *File) read(t *Type, expr, tag, require, name string, depth int) {
+	if t.Kind.IsBasic() {
+		ptr := "&" + expr
+		if t.named() {
+			ptr = "(*" + basics[t.Kind].goType + ")(" + ptr + ")"
+		}
+		f.check("d.Read%s(%s, %s, %s)", basics[t.Kind].method, ptr, tag, require)
+		return
+	}
+
+	// [step 1] 容器先读 head，并检查类型
+	want := map[Kind]string{Struct: "StructBegin", Slice: "List", Array: "List", Map: "Map"}[t.Kind]
+	f.Import("fmt")
+	f.Printf("if ty, have, err := d.ReadHead(%s, %s); err != nil {\n", tag, require)
+	f.Printf("return err\n")
+	f.Printf("} else if have {\n")
+	f.Printf("if ty != jce.%s {\n", want)
+	f.Printf("return fmt.Errorf(\"read %s failed, want %s, but got %%s\", ty)\n", name, want)
+	f.Printf("}\n")
+
+	// [step 2] 读取内容
+	switch t.Kind {
+	case Struct:
+		f.check("_, err = %s.ReadFrom(d)", expr)
+	case Slice:
+		i := "i" + strconv.Itoa(depth)
+		f.Printf("length, err := d.ReadLength()\n")
+		f.Printf("if err != nil {\nreturn err\n}\n")
+		// 长度来自数据，不能直接用来分配内存，逐个读取后 append
+		v := "v" + strconv.Itoa(depth)
+		f.Printf("%s = make(%s, 0, jce.SizeHint(length))\n", expr, t.GoType)
+		f.Printf("for %s := uint32(0); %s < length; %s++ {\n", i, i, i)
+		f.Printf("var %s %s\n", v, t.Elem.GoType)
+		f.read(t.Elem, v, "0", "true", name+"[]", depth+1)
+		f.Printf("%s = append(%s, %s)\n", expr, expr, v)
+		f.Printf("}\n")
+	case Array:
+		i := "i" + strconv.Itoa(depth)
+		f.Printf("length, err := d.ReadLength()\n")
+		f.Printf("if err != nil {\nreturn err\n}\n")
+		f.Printf("if length != %d {\n", t.Len)
+		f.Printf("return fmt.Errorf(\"read %s failed, want %d items, but got %%d\", length)\n", name, t.Len)
+		f.Printf("}\n")
+		f.Printf("for %s := range %s {\n", i, expr)
+		f.read(t.Elem, expr+"["+i+"]", "0", "true", name+"[]", depth+1)
+		f.Printf("}\n")
+	case Map:
+		i, k, v := "i"+strconv.Itoa(depth), "k"+strconv.Itoa(depth), "v"+strconv.Itoa(depth)
+		f.Printf("length, err := d.ReadLength()\n")
+		f.Printf("if err != nil {\nreturn err\n}\n")
+		f.Printf("%s = make(%s, jce.SizeHint(length))\n", expr, t.GoType)
+		f.Printf("for %s := uint32(0); %s < length; %s++ {\n", i, i, i)
+		f.Printf("var %s %s\n", k, t.Key.GoType)
+		f.Printf("var %s %s\n", v, t.Elem.GoType)
+		f.read(t.Key, k, "0", "true", name+".key", depth+1)
+		f.read(t.Elem, v, "1", "true", name+".value", depth+1)
+		f.Printf("%s[%s] = %s\n", expr, k, v)
+		f.Printf("}\n")
+	}
+	f.Printf("}\n")
+}
+
+// 输出一个返回 error 的调用，出错时返回；format 可以是完整的赋值语句，比如 _, err = x.WriteTo(e)
+func (f *File) check(format string, args ...any) {
+	if !strings.HasPrefix(format, "_, err = ") {
+		format = "err = " + format
+	}
+	f.Printf("if "+format+"; err != nil {\n", args...)
+	f.Printf("return err\n")
+	f.Printf("}\n")
+}
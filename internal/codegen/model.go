@@ -0,0 +1,91 @@
+// codegen 生成 jce 序列化代码的公共部分，jce2go、jcegen 把各自的输入转换为这里的模型，
+// 再生成 WriteTo、ReadFrom、ResetDefault 方法，生成的代码只使用 Encoder.Write*、Decoder.Read*
+package codegen
+
+// Kind 字段在编码中的种类，决定使用的 Encoder、Decoder 方法
+type Kind int
+
+const (
+	Bool Kind = iota
+	Int8
+	Uint8
+	Int16
+	Uint16
+	Int32
+	Uint32
+	Int64
+	Uint64
+	Float32
+	Float64
+	String
+	Bytes  // []byte，编码为 SimpleList
+	Int8s  // []int8，编码为 SimpleList
+	Slice  // 其他的 slice，编码为 List
+	Array  // 数组，编码为 List，读取时长度需要一致
+	Map    // 编码为 Map
+	Struct // 实现了 WriteTo、ReadFrom、ResetDefault 的 struct
+)
+
+// 基础类型的 go 类型、Encoder 和 Decoder 方法的后缀
+var basics = [...]struct {
+	goType string
+	method string
+}{
+	Bool:    {"bool", "Bool"},
+	Int8:    {"int8", "Int8"},
+	Uint8:   {"uint8", "Uint8"},
+	Int16:   {"int16", "Int16"},
+	Uint16:  {"uint16", "Uint16"},
+	Int32:   {"int32", "Int32"},
+	Uint32:  {"uint32", "Uint32"},
+	Int64:   {"int64", "Int64"},
+	Uint64:  {"uint64", "Uint64"},
+	Float32: {"float32", "Float32"},
+	Float64: {"float64", "Float64"},
+	String:  {"string", "String"},
+	Bytes:   {"[]byte", "SliceUint8"},
+	Int8s:   {"[]int8", "SliceInt8"},
+}
+
+// 是否为可以直接用 Encoder.Write*、Decoder.Read* 读写的类型
+func (k Kind) IsBasic() bool {
+	return k <= Int8s
+}
+
+// Type 字段的类型
+type Type struct {
+	Kind   Kind
+	GoType string // go 中的写法，比如 int32、Color、[]string、map[string]common.Address
+	Key    *Type  // Map 的 key
+	Elem   *Type  // Slice、Array 的元素，Map 的 value
+	Len    int    // Array 的长度
+}
+
+// 基础类型，goType 为空时使用默认的 go 类型
+func Basic(kind Kind, goType string) *Type {
+	if goType == "" {
+		goType = basics[kind].goType
+	}
+	return &Type{Kind: kind, GoType: goType}
+}
+
+// 是否为基于基础类型定义的类型，比如 type Color int32，读写时需要转换
+func (t *Type) named() bool {
+	return t.Kind.IsBasic() && t.GoType != basics[t.Kind].goType
+}
+
+// Field struct 中的一个字段
+type Field struct {
+	Name    string // go 的字段名
+	Wire    string // 错误信息中使用的名字，比如 IDL 中的字段名
+	Tag     byte
+	Require bool
+	Type    *Type
+	Default string // 默认值的 go 表达式，为空时为零值
+}
+
+// Message 需要生成方法的 struct
+type Message struct {
+	Name   string
+	Fields []*Field
+}
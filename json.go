@@ -0,0 +1,424 @@
+package jce
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// 编码数据和 JSON 的相互转换
+// 用于测试数据、问题单中的数据和真实的编码数据之间的转换，转换是无损的，编码类型原样保留：
+//
+//	{"0": {"Int1": 7}, "1": {"String": "hi"}, "2": {"StructBegin": {"0": {"Zero": 0}}},
+//	 "3": {"List": [{"Int4": -1}]}, "4": {"Map": [[{"String": "k"}, {"Float8": 1.5}]]},
+//	 "5": {"SimpleList": "0a0b"}}
+//
+//   - 消息、struct 为以 tag 为 key 的 object，按编码的顺序输出
+//   - 每个值为只有一个 key 的 object，key 为编码类型，即 JceEncodeType.String()
+//   - 整数按有符号输出，读取时也接受无符号的写法，比如 Int1 的 255
+//   - 浮点数的 NaN、Inf 写为字符串 "NaN"、"+Inf"、"-Inf"
+//   - 不是 UTF-8 的 string 写为 {"hex": "..."}，SimpleList 写为 16 进制字符串
+//   - map 为 [key, value] 对的数组
+// ---------------------------------------------------------------------------
+
+// 把一个完整的消息转换为 JSON，解析失败时错误中带有失败的偏移
+func ToJSON(data []byte) (out []byte, err error) {
+	v, err := decodeMessage(data)
+	if err != nil {
+		return
+	}
+	return appendJSONFields(make([]byte, 0, 2*len(data)), v.Fields), nil
+}
+
+// 把 ToJSON 格式的 JSON 转换为一个完整的消息，解析失败时错误中带有失败的偏移
+func FromJSON(data []byte) (out []byte, err error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	if out, err = ReadJSON(d); err != nil {
+		return
+	}
+	if _, err = d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid json at offset %d, err:unexpected data after message", d.InputOffset())
+	}
+	return out, nil
+}
+
+// 从 d 中读取下一个 JSON 消息并转换为编码数据，用于处理多个连续的 JSON 消息
+// d 会被设置为 UseNumber，没有更多的消息时返回 io.EOF
+func ReadJSON(d *json.Decoder) (out []byte, err error) {
+	d.UseNumber()
+	p := &jsonParser{d: d}
+
+	if !d.More() {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, p.wrap(err)
+		}
+		return nil, p.errorf("want {, but got %v", t)
+	}
+	fields, err := p.fields()
+	if err != nil {
+		return nil, err
+	}
+	return EncodeValue(Value{Type: StructBegin, Fields: fields})
+}
+
+// ---------------------------------------------------------------------------
+// 编码数据 -> JSON
+// ---------------------------------------------------------------------------
+
+func appendJSONFields(b []byte, fields []Field) []byte {
+	b = append(b, '{')
+	for i, field := range fields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, '"')
+		b = strconv.AppendUint(b, uint64(field.Tag), 10)
+		b = append(b, `":`...)
+		b = appendJSONValue(b, field.Value)
+	}
+	return append(b, '}')
+}
+
+func appendJSONValue(b []byte, v Value) []byte {
+	b = append(b, `{"`...)
+	b = append(b, v.Type.String()...)
+	b = append(b, `":`...)
+
+	switch v.Type {
+	case Zero:
+		b = append(b, '0')
+	case Int1, Int2, Int4, Int8:
+		b = strconv.AppendInt(b, v.signed(), 10)
+	case Float4:
+		b = appendJSONFloat(b, v.Float, 32)
+	case Float8:
+		b = appendJSONFloat(b, v.Float, 64)
+	case String:
+		if utf8.Valid(v.Bytes) {
+			b = appendJSONString(b, string(v.Bytes))
+		} else {
+			b = append(b, `{"hex":"`...)
+			b = append(b, hex.EncodeToString(v.Bytes)...)
+			b = append(b, `"}`...)
+		}
+	case SimpleList:
+		b = append(b, '"')
+		b = append(b, hex.EncodeToString(v.Bytes)...)
+		b = append(b, '"')
+	case List:
+		b = append(b, '[')
+		for i, item := range v.List {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONValue(b, item)
+		}
+		b = append(b, ']')
+	case Map:
+		b = append(b, '[')
+		for i, entry := range v.Map {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '[')
+			b = appendJSONValue(b, entry.Key)
+			b = append(b, ',')
+			b = appendJSONValue(b, entry.Value)
+			b = append(b, ']')
+		}
+		b = append(b, ']')
+	case StructBegin:
+		b = appendJSONFields(b, v.Fields)
+	}
+	return append(b, '}')
+}
+
+func appendJSONFloat(b []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Inf"`...)
+	default:
+		return strconv.AppendFloat(b, f, 'g', -1, bitSize)
+	}
+}
+
+func appendJSONString(b []byte, s string) []byte {
+	data, _ := json.Marshal(s) // string 的序列化不会失败
+	return append(b, data...)
+}
+
+// ---------------------------------------------------------------------------
+// JSON -> 编码数据
+// ---------------------------------------------------------------------------
+
+type jsonParser struct {
+	d *json.Decoder
+}
+
+// 给错误加上偏移，语法错误使用错误中的偏移
+func (p *jsonParser) wrap(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("invalid json at offset %d, err:%s", syntax.Offset, err)
+	}
+	return fmt.Errorf("invalid json at offset %d, err:%s", p.d.InputOffset(), err)
+}
+
+func (p *jsonParser) errorf(format string, args ...any) error {
+	return p.wrap(fmt.Errorf(format, args...))
+}
+
+func (p *jsonParser) token() (t json.Token, err error) {
+	if t, err = p.d.Token(); err != nil {
+		return nil, p.wrap(err)
+	}
+	return
+}
+
+// 读取一个分隔符
+func (p *jsonParser) delim(want json.Delim) (err error) {
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	if d, ok := t.(json.Delim); !ok || d != want {
+		return p.errorf("want %s, but got %v", want, t)
+	}
+	return
+}
+
+// 读取以 tag 为 key 的 object
+func (p *jsonParser) fields() (fields []Field, err error) {
+	if err = p.delim('{'); err != nil {
+		return
+	}
+	for p.d.More() {
+		t, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		tag, err := strconv.ParseUint(t.(string), 10, 8)
+		if err != nil {
+			return nil, p.errorf("invalid tag %q", t)
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Tag: byte(tag), Value: v})
+	}
+	return fields, p.delim('}')
+}
+
+// 读取一个 {"类型": 值} 的 object
+func (p *jsonParser) value() (v Value, err error) {
+	// [step 1] 类型
+	if err = p.delim('{'); err != nil {
+		return
+	}
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	name, _ := t.(string)
+	if v.Type, err = parseJSONType(name); err != nil {
+		return v, p.wrap(err)
+	}
+
+	// [step 2] 值
+	switch v.Type {
+	case Zero, Int1, Int2, Int4, Int8:
+		if v.Int, err = p.integer(v.Type); err != nil {
+			return
+		}
+	case Float4, Float8:
+		if v.Float, err = p.float(); err != nil {
+			return
+		}
+	case String:
+		if v.Bytes, err = p.string(); err != nil {
+			return
+		}
+	case SimpleList:
+		if v.Bytes, err = p.hex(); err != nil {
+			return
+		}
+	case List:
+		if err = p.delim('['); err != nil {
+			return
+		}
+		for p.d.More() {
+			item, err := p.value()
+			if err != nil {
+				return v, err
+			}
+			v.List = append(v.List, item)
+		}
+		if err = p.delim(']'); err != nil {
+			return
+		}
+	case Map:
+		if err = p.delim('['); err != nil {
+			return
+		}
+		for p.d.More() {
+			var entry MapEntry
+			if err = p.delim('['); err != nil {
+				return
+			}
+			if entry.Key, err = p.value(); err != nil {
+				return
+			}
+			if entry.Value, err = p.value(); err != nil {
+				return
+			}
+			if err = p.delim(']'); err != nil {
+				return
+			}
+			v.Map = append(v.Map, entry)
+		}
+		if err = p.delim(']'); err != nil {
+			return
+		}
+	case StructBegin:
+		if v.Fields, err = p.fields(); err != nil {
+			return
+		}
+	}
+
+	// [step 3] 只能有一个类型
+	if p.d.More() {
+		return v, p.errorf("value of %s has more than one type", v.Type)
+	}
+	return v, p.delim('}')
+}
+
+// 读取整数，按类型的宽度检查范围，有符号、无符号的写法都可以
+func (p *jsonParser) integer(ty JceEncodeType) (n uint64, err error) {
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	num, ok := t.(json.Number)
+	if !ok {
+		return 0, p.errorf("%s want number, but got %v", ty, t)
+	}
+
+	if n, err = parseInteger(ty, num.String()); err != nil {
+		return 0, p.wrap(err)
+	}
+	return
+}
+
+func (p *jsonParser) float() (f float64, err error) {
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	switch t := t.(type) {
+	case json.Number:
+		return t.Float64()
+	case string:
+		switch t {
+		case "NaN":
+			return math.NaN(), nil
+		case "+Inf", "Inf":
+			return math.Inf(1), nil
+		case "-Inf":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, p.errorf("want float, but got %v", t)
+}
+
+// string 可以是 JSON 字符串或者 {"hex": "..."}
+func (p *jsonParser) string() (data []byte, err error) {
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	if s, ok := t.(string); ok {
+		return []byte(s), nil
+	}
+	if d, ok := t.(json.Delim); !ok || d != '{' {
+		return nil, p.errorf("String want string or {\"hex\": ...}, but got %v", t)
+	}
+	if t, err = p.token(); err != nil {
+		return
+	}
+	if t != "hex" {
+		return nil, p.errorf("String want key hex, but got %v", t)
+	}
+	if data, err = p.hex(); err != nil {
+		return
+	}
+	return data, p.delim('}')
+}
+
+func (p *jsonParser) hex() (data []byte, err error) {
+	t, err := p.token()
+	if err != nil {
+		return
+	}
+	s, ok := t.(string)
+	if !ok {
+		return nil, p.errorf("want hex string, but got %v", t)
+	}
+	if data, err = hex.DecodeString(s); err != nil {
+		return nil, p.errorf("invalid hex %q", s)
+	}
+	return
+}
+
+// 按类型的宽度解析整数，有符号、无符号的写法都可以，结果和 readInt* 一样按无符号零扩展
+func parseInteger(ty JceEncodeType, s string) (n uint64, err error) {
+	if ty == Zero {
+		if s != "0" {
+			return 0, fmt.Errorf("%s want 0, but got %s", ty, s)
+		}
+		return 0, nil
+	}
+
+	bits := map[JceEncodeType]int{Int1: 8, Int2: 16, Int4: 32, Int8: 64}[ty]
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if bits < 64 && (i < -1<<(bits-1) || i >= 1<<bits) {
+			return 0, fmt.Errorf("%s out of range: %s", ty, s)
+		}
+		if bits < 64 {
+			return uint64(i) & (1<<bits - 1), nil
+		}
+		return uint64(i), nil
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil && ty == Int8 {
+		return u, nil
+	}
+	return 0, fmt.Errorf("%s invalid integer: %s", ty, s)
+}
+
+// 按 JceEncodeType.String() 查找类型
+func parseJSONType(name string) (ty JceEncodeType, err error) {
+	for ty = Int1; ty <= StructBegin; ty++ {
+		if ty.String() == name {
+			return
+		}
+	}
+	return 0, fmt.Errorf("unknown type %q", name)
+}
@@ -0,0 +1,108 @@
+package jce
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	v := structValue(
+		Field{Tag: 0, Value: Value{Type: Int1, Int: 0xfe}},
+		Field{Tag: 1, Value: stringValue("hi \"x\"")},
+		Field{Tag: 2, Value: Value{Type: String, Bytes: []byte{0xff, 0x00}}},
+		Field{Tag: 3, Value: Value{Type: Zero}},
+		Field{Tag: 4, Value: Value{Type: Float4, Float: 1.5}},
+		Field{Tag: 5, Value: Value{Type: Float8, Float: math.Inf(-1)}},
+		Field{Tag: 6, Value: Value{Type: SimpleList, Bytes: []byte{1, 2}}},
+		Field{Tag: 7, Value: Value{Type: List, List: []Value{Value{Type: Int8, Int: 1 << 63}}}},
+		Field{Tag: 8, Value: Value{Type: Map, Map: []MapEntry{{Key: stringValue("k"), Value: Value{Type: Int4, Int: 9}}}}},
+		Field{Tag: 200, Value: structValue(Field{Tag: 1, Value: Value{Type: Int2, Int: 300}})},
+		Field{Tag: 1, Value: stringValue("dup")},
+	)
+	data := mustEncodeValue(t, v)
+
+	want := `{"0":{"Int1":-2},"1":{"String":"hi \"x\""},"2":{"String":{"hex":"ff00"}},"3":{"Zero":0},` +
+		`"4":{"Float4":1.5},"5":{"Float8":"-Inf"},"6":{"SimpleList":"0102"},"7":{"List":[{"Int8":-9223372036854775808}]},` +
+		`"8":{"Map":[[{"String":"k"},{"Int4":9}]]},"200":{"StructBegin":{"1":{"Int2":300}}},"1":{"String":"dup"}}`
+	got, err := ToJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("ToJSON()\nwant: %s\ngot:  %s", want, got)
+	}
+
+	// 往返之后和原始数据完全一致
+	back, err := FromJSON(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back, data) {
+		t.Errorf("FromJSON() = % x, want % x", back, data)
+	}
+
+	// 格式化之后的 JSON、无符号的写法也可以读取
+	var pretty bytes.Buffer
+	if err = json.Indent(&pretty, got, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := strings.Replace(pretty.String(), `"Int1": -2`, `"Int1": 254`, 1)
+	if back, err = FromJSON([]byte(unsigned)); err != nil || !bytes.Equal(back, data) {
+		t.Errorf("FromJSON(pretty) = % x, %v", back, err)
+	}
+}
+
+func TestJSONError(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"syntax", `{"0":{"Int1":3},}`, "invalid json at offset 16"},
+		{"range", `{"0":{"Int1":300}}`, "Int1 out of range"},
+		{"tag", `{"x":{"Int1":3}}`, `invalid tag "x"`},
+		{"type", `{"0":{"Int3":3}}`, `unknown type "Int3"`},
+		{"two types", `{"0":{"Int1":3,"Int2":3}}`, "more than one type"},
+		{"hex", `{"0":{"SimpleList":"0g"}}`, `invalid hex "0g"`},
+		{"zero", `{"0":{"Zero":1}}`, "Zero want 0"},
+		{"truncated", `{"0":{"Int1":3}`, "invalid json at offset 15"},
+		{"trailing", `{} {}`, "unexpected data after message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromJSON([]byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FromJSON(%s) error = %v, want %q", tt.json, err, tt.want)
+			}
+		})
+	}
+
+	_, err := ToJSON([]byte{0x00, 7, 0x71, 5, 'h'})
+	if err == nil || !strings.Contains(err.Error(), "invalid jce at offset 4") {
+		t.Errorf("ToJSON() error = %v, want invalid jce at offset 4", err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	d := json.NewDecoder(strings.NewReader(`{"0":{"Int1":1}}
+{"1":{"String":"a"}}
+`))
+	var got [][]byte
+	for {
+		data, err := ReadJSON(d)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, data)
+	}
+	if len(got) != 2 || !bytes.Equal(got[0], []byte{0x00, 1}) || !bytes.Equal(got[1], []byte{0x71, 1, 'a'}) {
+		t.Errorf("ReadJSON() = % x", got)
+	}
+}
@@ -0,0 +1,146 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ---------------------------------------------------------------------------
+// 延迟反序列化
+// 反序列化时只保存字段的原始字节，第一次 Get 时才真正反序列化；
+// 如果一直没有访问，序列化时直接写回原始字节
+// ---------------------------------------------------------------------------
+
+// Lazy 延迟反序列化的字段，*T 需要实现 Messager
+//
+// 对于 struct 字段，T 的 ReadFrom/WriteTo 读写的是 struct 内部的字段；
+// 对于 list、map 等其他类型的字段，T 的 ReadFrom/WriteTo 读写的是 tag 为 0 的一个完整字段
+type Lazy[T any] struct {
+	raw      []byte        // 字段的原始编码，head + data
+	ty       JceEncodeType // 字段的类型
+	explicit bool          // ty 是否被 SetType 设置过
+	val      T
+	decoded  bool // val 是否可用
+	err      error
+}
+
+// 设置值，之后序列化时会使用这个值
+func (l *Lazy[T]) Set(v T) {
+	l.val, l.decoded, l.err = v, true, nil
+}
+
+// 设置字段的编码类型，默认为 StructBegin
+// 只有非 struct 类型、且没有从数据中读到过的字段才需要设置，读到的字段会使用数据中的类型
+func (l *Lazy[T]) SetType(t JceEncodeType) {
+	l.ty, l.explicit = t, true
+}
+
+// 返回值，第一次调用时才会反序列化，返回的指针可以直接修改
+func (l *Lazy[T]) Get() (v *T, err error) {
+	if !l.decoded && l.err == nil {
+		l.err = l.decode()
+		l.decoded = l.err == nil
+	}
+	return &l.val, l.err
+}
+
+// 返回原始字节，为 nil 说明没有从数据中读到过这个字段
+func (l *Lazy[T]) Raw() []byte {
+	return l.raw
+}
+
+// 是否已经反序列化（或者被 Set 过）
+func (l *Lazy[T]) Decoded() bool {
+	return l.decoded
+}
+
+// 从 d 中读取 tag 对应的字段，只保存原始字节
+func (l *Lazy[T]) Read(d *Decoder, tag byte, require bool) (err error) {
+	raw, ty, err := d.ReadRaw(tag, require)
+	if err != nil {
+		return
+	}
+
+	// optional 字段不存在，保持原样
+	if raw == nil {
+		return
+	}
+
+	var zero T
+	l.raw, l.ty, l.val, l.decoded, l.err = raw, ty, zero, false, nil
+	return
+}
+
+// 把字段写入 e，如果没有被访问过，则直接写原始字节
+func (l *Lazy[T]) Write(e *Encoder, tag byte) (err error) {
+	// [step 1] 没有访问过，原样写回
+	if !l.decoded && l.raw != nil {
+		return e.WriteRaw(tag, l.raw)
+	}
+
+	// [step 2] 否则序列化 val
+	m, err := l.messager()
+	if err != nil {
+		return
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0))
+	if _, err = m.WriteTo(b); err != nil {
+		return fmt.Errorf("write lazy field failed, tag:%d, err:%s", tag, err)
+	}
+
+	// [step 3] 非 struct 类型，T 写出的是 tag 为 0 的字段，换成需要的 tag
+	if l.fieldType() != StructBegin {
+		return e.WriteRaw(tag, b.Bytes())
+	}
+
+	// [step 4] struct 类型，补上 begin、end
+	if err = e.writeHead(StructBegin, tag); err != nil {
+		return
+	}
+	if err = e.writeByteN(b.Bytes()); err != nil {
+		return
+	}
+	return e.writeHead(StructEnd, 0)
+}
+
+// 反序列化原始字节
+func (l *Lazy[T]) decode() (err error) {
+	// [step 1] 没有原始数据，就是零值
+	if l.raw == nil {
+		return
+	}
+
+	m, err := l.messager()
+	if err != nil {
+		return
+	}
+
+	// [step 2] struct 类型只需要 struct 内部的数据，其他类型需要把 tag 换成 0
+	data := RawField{Data: l.raw}.Payload()
+	if l.ty != StructBegin {
+		data = append(appendHead(nil, l.ty, 0), data...)
+	}
+
+	if _, err = m.ReadFrom(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("decode lazy field failed, type:%s, err:%s", l.ty, err)
+	}
+	return
+}
+
+// 字段的编码类型
+func (l *Lazy[T]) fieldType() JceEncodeType {
+	if l.raw == nil && !l.explicit {
+		return StructBegin
+	}
+	return l.ty
+}
+
+// *T 必须是 Messager
+func (l *Lazy[T]) messager() (m Messager, err error) {
+	m, ok := any(&l.val).(Messager)
+	if !ok {
+		return nil, fmt.Errorf("lazy type %T is not jce Messager", &l.val)
+	}
+	return
+}
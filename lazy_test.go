@@ -0,0 +1,180 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type lazyInner struct {
+	A int32
+	B string
+}
+
+func (m *lazyInner) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	if err = d.ReadInt32(&m.A, 0, true); err != nil {
+		return
+	}
+	if err = d.ReadString(&m.B, 1, false); err != nil {
+		return
+	}
+	return 0, d.SkipToStructEnd()
+}
+
+func (m *lazyInner) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteInt32(m.A, 0); err != nil {
+		return
+	}
+	if err = e.WriteString(m.B, 1); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+// list 容器，读写的是 tag 为 0 的字段
+type lazyInts []int32
+
+func (m *lazyInts) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	ty, _, err := d.ReadHead(0, true)
+	if err != nil {
+		return
+	}
+	if ty != List {
+		return 0, fmt.Errorf("want list, got %s", ty)
+	}
+	length, err := d.ReadLength()
+	if err != nil {
+		return
+	}
+	*m = make(lazyInts, length)
+	for i := range *m {
+		if err = d.ReadInt32(&(*m)[i], 0, true); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (m *lazyInts) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteHead(List, 0); err != nil {
+		return
+	}
+	if err = e.WriteLength(uint32(len(*m))); err != nil {
+		return
+	}
+	for _, v := range *m {
+		if err = e.WriteInt32(v, 0); err != nil {
+			return
+		}
+	}
+	return 0, e.Flush()
+}
+
+type lazyOuter struct {
+	Header string
+	Body   Lazy[lazyInner]
+	Items  Lazy[lazyInts]
+}
+
+func (m *lazyOuter) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	if err = d.ReadString(&m.Header, 0, true); err != nil {
+		return
+	}
+	if err = m.Body.Read(d, 1, true); err != nil {
+		return
+	}
+	return 0, m.Items.Read(d, 2, false)
+}
+
+func (m *lazyOuter) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteString(m.Header, 0); err != nil {
+		return
+	}
+	if err = m.Body.Write(e, 1); err != nil {
+		return
+	}
+	if err = m.Items.Write(e, 2); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+func TestLazy(t *testing.T) {
+	var src lazyOuter
+	src.Header = "h"
+	src.Body.Set(lazyInner{A: 70000, B: "body"})
+	src.Items.SetType(List)
+	src.Items.Set(lazyInts{1, 2, 300})
+
+	data, err := Marshal(&src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// [step 1] 反序列化后没有访问，原样写回
+	var got lazyOuter
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Body.Decoded() || got.Body.Raw() == nil {
+		t.Error("body should not be decoded")
+	}
+	out, err := Marshal(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Errorf("untouched round trip failed\nwant:%v\ngot: %v", data, out)
+	}
+
+	// [step 2] 访问后反序列化
+	body, err := got.Body.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.A != 70000 || body.B != "body" {
+		t.Errorf("decode body failed, got:%+v", body)
+	}
+	items, err := got.Items.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*items, lazyInts{1, 2, 300}) {
+		t.Errorf("decode items failed, got:%v", *items)
+	}
+
+	// [step 3] 修改后重新序列化
+	body.B = "changed"
+	*items = append(*items, 4)
+	out, err = Marshal(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var again lazyOuter
+	if err := Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if body, err := again.Body.Get(); err != nil || body.B != "changed" || body.A != 70000 {
+		t.Errorf("modified body failed, got:%+v, err:%v", body, err)
+	}
+	if items, err := again.Items.Get(); err != nil || !reflect.DeepEqual(*items, lazyInts{1, 2, 300, 4}) {
+		t.Errorf("modified items failed, got:%v, err:%v", items, err)
+	}
+}
+
+func TestLazyNotMessager(t *testing.T) {
+	var l Lazy[int]
+	l.raw, l.ty = []byte{0x00, 0x01}, Int1
+	if _, err := l.Get(); err == nil {
+		t.Error("want error for non Messager type")
+	}
+}
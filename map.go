@@ -0,0 +1,113 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// map 的序列化
+// key、value 的读写函数可以直接使用 Encoder、Decoder 的方法表达式，比如：
+//
+//	jce.WriteMap(e, m, 3, (*jce.Encoder).WriteString, (*jce.Encoder).WriteInt32)
+//	jce.ReadMap(d, &m, 3, true, (*jce.Decoder).ReadString, (*jce.Decoder).ReadInt32)
+// ---------------------------------------------------------------------------
+
+// 序列化 map，key 的 tag 为 0，value 的 tag 为 1
+// 规范模式下按 key 编码后的字节从小到大写入，否则按 map 的遍历顺序写入
+func WriteMap[K comparable, V any](e *Encoder, m map[K]V, tag byte,
+	writeKey func(*Encoder, K, byte) error, writeValue func(*Encoder, V, byte) error) (err error) {
+	// [step 1] 写 head、长度
+	if err = e.WriteHead(Map, tag); err != nil {
+		return fmt.Errorf("write head failed, type:%s, tag:%d ,err: %w", Map, tag, err)
+	}
+	if err = e.WriteLength(uint32(len(m))); err != nil {
+		return fmt.Errorf("write map length failed, tag:%d ,err: %w", tag, err)
+	}
+
+	// [step 2] 非规范模式，直接按遍历顺序写
+	if !e.canonical {
+		for k, v := range m {
+			if err = writeKey(e, k, 0); err != nil {
+				return
+			}
+			if err = writeValue(e, v, 1); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	// [step 3] 规范模式，先单独编码每个 key，按字节排序后再写
+	type entry struct {
+		key   []byte
+		value V
+	}
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		b := bytes.NewBuffer(make([]byte, 0))
+		ke := NewEncoder(b)
+		ke.SetCanonical(true)
+		if err = writeKey(ke, k, 0); err != nil {
+			return
+		}
+		if err = ke.Flush(); err != nil {
+			return
+		}
+		entries = append(entries, entry{key: b.Bytes(), value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	for _, en := range entries {
+		if err = e.writeByteN(en.key); err != nil {
+			return
+		}
+		// key 没有经过 writeHead，需要单独更新字段掩码的状态
+		e.enterField(JceEncodeType(en.key[0]>>4), 0)
+		if err = writeValue(e, en.value, 1); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 反序列化 map，key 的 tag 为 0，value 的 tag 为 1
+func ReadMap[K comparable, V any](d *Decoder, m *map[K]V, tag byte, require bool,
+	readKey func(*Decoder, *K, byte, bool) error, readValue func(*Decoder, *V, byte, bool) error) (err error) {
+	// [step 1] 读 head
+	t, have, err := d.ReadHead(tag, require)
+	if err != nil {
+		return fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
+	}
+	if !have {
+		return nil
+	}
+	if t != Map {
+		return &TypeMismatchError{Name: "map", Tag: tag, Type: t}
+	}
+
+	// [step 2] 读长度
+	length, err := d.ReadLength()
+	if err != nil {
+		return fmt.Errorf("read map length failed, tag:%d, err:%w", tag, err)
+	}
+
+	// [step 3] 读 key、value
+	*m = make(map[K]V, SizeHint(length))
+	for i := uint32(0); i < length; i++ {
+		var k K
+		var v V
+		if err = readKey(d, &k, 0, true); err != nil {
+			return fmt.Errorf("read map key failed, tag:%d, err:%w", tag, err)
+		}
+		if err = readValue(d, &v, 1, true); err != nil {
+			return fmt.Errorf("read map value failed, tag:%d, err:%w", tag, err)
+		}
+		(*m)[k] = v
+	}
+
+	return
+}
@@ -0,0 +1,171 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// 两个编码消息的合并
+// 直接在字节层面按 tag 合并：overlay 中存在的字段替换 base 中的字段，嵌套的 struct 递归合并，
+// list、map 按选项替换或者拼接，结果依然按 tag 从小到大排列
+// ---------------------------------------------------------------------------
+
+// MergeMode list、map 的合并方式
+type MergeMode byte
+
+const (
+	MergeReplace MergeMode = iota // overlay 替换 base
+	MergeConcat                   // list 拼接；map 合并 key，相同的 key 使用 overlay 的 value
+)
+
+// MergeOptions 合并选项，默认 list、map 都是替换
+type MergeOptions struct {
+	Lists MergeMode
+	Maps  MergeMode
+}
+
+// 合并 base、overlay 两个消息，不会修改 base、overlay
+func Merge(base, overlay []byte, opts MergeOptions) (out []byte, err error) {
+	return mergeStruct(base, overlay, opts)
+}
+
+// 合并两个 struct 内的数据，返回合并后的字段，不包括 StructEnd
+func mergeStruct(base, overlay []byte, opts MergeOptions) (out []byte, err error) {
+	// [step 1] 拆分字段
+	bf, err := splitFields(base)
+	if err != nil {
+		return nil, fmt.Errorf("split base failed, err:%s", err)
+	}
+	of, err := splitFields(overlay)
+	if err != nil {
+		return nil, fmt.Errorf("split overlay failed, err:%s", err)
+	}
+
+	// [step 2] 按 tag 排序，一般数据本身就是有序的
+	sortFields(bf)
+	sortFields(of)
+
+	// [step 3] 按 tag 归并
+	out = make([]byte, 0, len(base)+len(overlay))
+	i, j := 0, 0
+	for i < len(bf) || j < len(of) {
+		switch {
+		case j == len(of) || (i < len(bf) && bf[i].Tag < of[j].Tag):
+			out = append(out, bf[i].Data...)
+			i++
+		case i == len(bf) || of[j].Tag < bf[i].Tag:
+			out = append(out, of[j].Data...)
+			j++
+		default:
+			var field []byte
+			if field, err = mergeField(bf[i], of[j], opts); err != nil {
+				return nil, fmt.Errorf("merge tag %d failed, err:%s", of[j].Tag, err)
+			}
+			out = append(out, field...)
+			i, j = i+1, j+1
+		}
+	}
+
+	return
+}
+
+// 合并 tag 相同的两个字段
+func mergeField(b, o RawField, opts MergeOptions) (field []byte, err error) {
+	// [step 1] 类型不同，直接使用 overlay
+	if b.Type != o.Type {
+		return o.Data, nil
+	}
+
+	switch {
+	// [step 2] struct 递归合并
+	case o.Type == StructBegin:
+		body, err := mergeStruct(b.Payload(), o.Payload(), opts)
+		if err != nil {
+			return nil, err
+		}
+		field = appendHead(make([]byte, 0, len(body)+3), StructBegin, o.Tag)
+		field = append(field, body...)
+		return appendHead(field, StructEnd, 0), nil
+
+	// [step 3] list 拼接
+	case o.Type == List && opts.Lists == MergeConcat:
+		bi, err := splitItems(b.Payload(), 1)
+		if err != nil {
+			return nil, err
+		}
+		oi, err := splitItems(o.Payload(), 1)
+		if err != nil {
+			return nil, err
+		}
+		return appendItems(o.Tag, List, append(bi, oi...), 1), nil
+
+	// [step 4] map 合并 key
+	case o.Type == Map && opts.Maps == MergeConcat:
+		bi, err := splitItems(b.Payload(), 2)
+		if err != nil {
+			return nil, err
+		}
+		oi, err := splitItems(o.Payload(), 2)
+		if err != nil {
+			return nil, err
+		}
+		items, err := mergeEntries(bi, oi)
+		if err != nil {
+			return nil, err
+		}
+		return appendItems(o.Tag, Map, items, 2), nil
+
+	// [step 5] 其他情况使用 overlay
+	default:
+		return o.Data, nil
+	}
+}
+
+// 合并 map 的 key、value 对，key 按值比较（同 compareMap），overlay 中的 key 覆盖 base 中的 key
+func mergeEntries(base, overlay [][]byte) (items [][]byte, err error) {
+	// [step 1] overlay 中的 key
+	keys := make(map[string]bool, len(overlay)/2)
+	for j := 0; j < len(overlay); j += 2 {
+		key, err := itemKey(overlay[j])
+		if err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+
+	// [step 2] 保留 base 中没有被覆盖的 key
+	items = make([][]byte, 0, len(base)+len(overlay))
+	for i := 0; i < len(base); i += 2 {
+		key, err := itemKey(base[i])
+		if err != nil {
+			return nil, err
+		}
+		if !keys[key] {
+			items = append(items, base[i], base[i+1])
+		}
+	}
+	return append(items, overlay...), nil
+}
+
+// map 中一个 key 的规范编码，Int1(5) 和 Int4(5)、Zero 和 Int1(0) 等相等的值结果相同
+func itemKey(item []byte) (key string, err error) {
+	v, err := NewDecoder(bytes.NewReader(item)).readItemValue()
+	if err != nil {
+		return "", fmt.Errorf("decode map key failed, err:%s", err)
+	}
+	return canonicalKey(v)
+}
+
+// 编码一个 list 或 map 字段，items 为编码好的元素，n 为每个元素占的 item 个数
+func appendItems(tag byte, ty JceEncodeType, items [][]byte, n int) (field []byte) {
+	return append(appendHead(nil, ty, tag), appendPayload(items, n)...)
+}
+
+// 按 tag 稳定排序
+func sortFields(fields []RawField) {
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Tag < fields[j].Tag
+	})
+}
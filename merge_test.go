@@ -0,0 +1,141 @@
+package jce
+
+import (
+	"testing"
+)
+
+func intValue(v uint64) Value {
+	return Value{Type: Int4, Int: v}
+}
+
+func stringValue(s string) Value {
+	return Value{Type: String, Bytes: []byte(s)}
+}
+
+func structValue(fields ...Field) Value {
+	return Value{Type: StructBegin, Fields: fields}
+}
+
+func mustEncodeValue(t testing.TB, v Value) []byte {
+	data, err := EncodeValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMerge(t *testing.T) {
+	base := mustEncodeValue(t, structValue(
+		Field{Tag: 1, Value: stringValue("base")},
+		Field{Tag: 2, Value: structValue(
+			Field{Tag: 0, Value: intValue(1)},
+			Field{Tag: 1, Value: intValue(2)},
+		)},
+		Field{Tag: 3, Value: Value{Type: List, List: []Value{intValue(1), intValue(2)}}},
+		Field{Tag: 4, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("a"), Value: intValue(1)},
+			{Key: stringValue("b"), Value: intValue(2)},
+		}}},
+		Field{Tag: 6, Value: intValue(6)},
+	))
+	overlay := mustEncodeValue(t, structValue(
+		Field{Tag: 0, Value: stringValue("new")},
+		Field{Tag: 2, Value: structValue(
+			Field{Tag: 1, Value: intValue(20)},
+			Field{Tag: 2, Value: intValue(30)},
+		)},
+		Field{Tag: 3, Value: Value{Type: List, List: []Value{intValue(3)}}},
+		Field{Tag: 4, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("b"), Value: intValue(20)},
+			{Key: stringValue("c"), Value: intValue(30)},
+		}}},
+		Field{Tag: 5, Value: stringValue("five")},
+		Field{Tag: 6, Value: stringValue("six")},
+	))
+
+	tests := []struct {
+		name string
+		opts MergeOptions
+		want string
+	}{
+		{
+			name: "replace",
+			opts: MergeOptions{},
+			want: `{0: "new", 1: "base", 2: {0: Int4(1), 1: Int4(20), 2: Int4(30)}, 3: [Int4(3)], ` +
+				`4: map["b": Int4(20), "c": Int4(30)], 5: "five", 6: "six"}`,
+		},
+		{
+			name: "concat",
+			opts: MergeOptions{Lists: MergeConcat, Maps: MergeConcat},
+			want: `{0: "new", 1: "base", 2: {0: Int4(1), 1: Int4(20), 2: Int4(30)}, 3: [Int4(1), Int4(2), Int4(3)], ` +
+				`4: map["a": Int4(1), "b": Int4(20), "c": Int4(30)], 5: "five", 6: "six"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Merge(base, overlay, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := DecodeValue(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.String() != tt.want {
+				t.Errorf("merge failed\nwant:%s\ngot: %s", tt.want, v)
+			}
+		})
+	}
+}
+
+func TestMergeEmpty(t *testing.T) {
+	base := mustEncodeValue(t, structValue(Field{Tag: 1, Value: stringValue("base")}))
+
+	out, err := Merge(base, nil, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(base) {
+		t.Errorf("merge empty overlay failed, got:%v", out)
+	}
+
+	out, err = Merge(nil, base, MergeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(base) {
+		t.Errorf("merge empty base failed, got:%v", out)
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	if _, err := Merge([]byte{0x70, 0x05, 'a'}, nil, MergeOptions{}); err == nil {
+		t.Error("want error for truncated base")
+	}
+}
+
+// 值相等但编码不同的 key 是同一个 key
+func TestMergeMapKeyWidth(t *testing.T) {
+	base := mustEncodeValue(t, structValue(Field{Tag: 0, Value: Value{Type: Map, Map: []MapEntry{
+		{Key: Value{Type: Int1, Int: 5}, Value: stringValue("a")},
+		{Key: Value{Type: Zero}, Value: stringValue("b")},
+		{Key: Value{Type: Int2, Int: 7}, Value: stringValue("c")},
+	}}}))
+	overlay := mustEncodeValue(t, structValue(Field{Tag: 0, Value: Value{Type: Map, Map: []MapEntry{
+		{Key: Value{Type: Int4, Int: 5}, Value: stringValue("x")},
+		{Key: Value{Type: Int1, Int: 0}, Value: stringValue("y")},
+	}}}))
+
+	out, err := Merge(base, overlay, MergeOptions{Maps: MergeConcat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := DecodeValue(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{0: map[Int2(7): "c", Int4(5): "x", Int1(0): "y"]}`
+	if v.String() != want {
+		t.Errorf("merge failed\nwant:%s\ngot: %s", want, v)
+	}
+}
@@ -0,0 +1,132 @@
+package jce
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// 运行时统计
+// 调用 EnableMetrics 之后，统计结果通过 expvar 以 MetricsName 发布，
+// 可以在 /debug/vars 中看到；没有开启时每个统计点只有一次原子读，开销可以忽略
+// 消息级别的统计只覆盖 Marshal、MarshalTo、Unmarshal、UnmarshalFrom，直接使用 Encoder、Decoder 时不统计
+//   - messages_encoded、messages_decoded：成功编解码的消息数
+//   - bytes_out、bytes_in：编解码的字节数，即 WriteTo、ReadFrom 返回的字节数，手写的 Messager 需要正确返回
+//   - encode_ns、decode_ns：编解码花费的总时间，单位为纳秒，除以消息数即为平均耗时
+//   - decode_errors：解码失败的次数，按错误的种类区分
+//   - fields_skipped：readHeadC 跳过的字段数
+//   - max_depth：Decoder 见过的最大嵌套深度
+// ---------------------------------------------------------------------------
+
+// MetricsName 统计结果在 expvar 中的名字
+const MetricsName = "jce"
+
+// 解码错误的种类
+const (
+	decodeErrorEOF      = "eof"
+	decodeErrorMissing  = "missing_tag"
+	decodeErrorMismatch = "type_mismatch"
+	decodeErrorOther    = "other"
+)
+
+type codecMetrics struct {
+	vars expvar.Map
+
+	messagesEncoded expvar.Int
+	messagesDecoded expvar.Int
+	bytesOut        expvar.Int
+	bytesIn         expvar.Int
+	encodeNanos     expvar.Int
+	decodeNanos     expvar.Int
+	decodeErrors    expvar.Map
+	fieldsSkipped   expvar.Int
+	maxDepth        atomic.Int64
+}
+
+var (
+	metrics     atomic.Pointer[codecMetrics] // 没有开启时为 nil
+	metricsOnce sync.Once
+	metricsData *codecMetrics
+)
+
+// 开启统计，第一次开启时发布到 expvar，之后重新开启会继续累加
+func EnableMetrics() {
+	metricsOnce.Do(func() {
+		metricsData = newCodecMetrics()
+		expvar.Publish(MetricsName, &metricsData.vars)
+	})
+	metrics.Store(metricsData)
+}
+
+// 关闭统计，已有的统计结果保留
+func DisableMetrics() {
+	metrics.Store(nil)
+}
+
+func newCodecMetrics() *codecMetrics {
+	m := &codecMetrics{}
+	m.vars.Init()
+	m.decodeErrors.Init()
+	m.vars.Set("messages_encoded", &m.messagesEncoded)
+	m.vars.Set("messages_decoded", &m.messagesDecoded)
+	m.vars.Set("bytes_out", &m.bytesOut)
+	m.vars.Set("bytes_in", &m.bytesIn)
+	m.vars.Set("encode_ns", &m.encodeNanos)
+	m.vars.Set("decode_ns", &m.decodeNanos)
+	m.vars.Set("decode_errors", &m.decodeErrors)
+	m.vars.Set("fields_skipped", &m.fieldsSkipped)
+	m.vars.Set("max_depth", expvar.Func(func() any {
+		return m.maxDepth.Load()
+	}))
+	return m
+}
+
+// 记录一次编码，n 为写入的字节数
+func (m *codecMetrics) encoded(n int64, start time.Time, err error) {
+	m.bytesOut.Add(n)
+	m.encodeNanos.Add(int64(time.Since(start)))
+	if err == nil {
+		m.messagesEncoded.Add(1)
+	}
+}
+
+// 记录一次解码，n 为读取的字节数，失败时按错误的种类记录
+func (m *codecMetrics) decoded(n int64, start time.Time, err error) {
+	m.bytesIn.Add(n)
+	m.decodeNanos.Add(int64(time.Since(start)))
+	if err != nil {
+		m.decodeErrors.Add(decodeErrorKind(err), 1)
+	} else {
+		m.messagesDecoded.Add(1)
+	}
+}
+
+// 记录 Decoder 的嵌套深度
+func (m *codecMetrics) depth(depth int64) {
+	for {
+		cur := m.maxDepth.Load()
+		if depth <= cur || m.maxDepth.CompareAndSwap(cur, depth) {
+			return
+		}
+	}
+}
+
+// 解码错误的种类，按错误链中的 *MissingFieldsError、*TypeMismatchError、io.ErrUnexpectedEOF 等区分
+func decodeErrorKind(err error) string {
+	var missing *MissingFieldsError
+	var mismatch *TypeMismatchError
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return decodeErrorEOF
+	case errors.As(err, &missing):
+		return decodeErrorMissing
+	case errors.As(err, &mismatch):
+		return decodeErrorMismatch
+	default:
+		return decodeErrorOther
+	}
+}
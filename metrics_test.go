@@ -0,0 +1,123 @@
+package jce
+
+import (
+	"bytes"
+	"expvar"
+	"strconv"
+	"testing"
+)
+
+// 读取当前的统计值
+func metricValue(t *testing.T, name string) int64 {
+	t.Helper()
+	v := expvar.Get(MetricsName).(*expvar.Map).Get(name)
+	if v == nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(v.String(), 10, 64)
+	if err != nil {
+		t.Fatalf("metric %s = %s", name, v)
+	}
+	return n
+}
+
+func decodeErrorCount(t *testing.T, kind string) int64 {
+	t.Helper()
+	v := expvar.Get(MetricsName).(*expvar.Map).Get("decode_errors").(*expvar.Map).Get(kind)
+	if v == nil {
+		return 0
+	}
+	return v.(*expvar.Int).Value()
+}
+
+func TestMetrics(t *testing.T) {
+	EnableMetrics()
+	defer DisableMetrics()
+
+	names := []string{"messages_encoded", "messages_decoded", "bytes_out", "bytes_in", "fields_skipped", "decode_ns"}
+	before := map[string]int64{}
+	for _, name := range names {
+		before[name] = metricValue(t, name)
+	}
+	eofErrors := decodeErrorCount(t, decodeErrorEOF)
+
+	// [step 1] 正常的编解码
+	record := newPathRecord(3)
+	data, err := Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Unmarshal(data, &pathRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = UnmarshalFrom(bytes.NewReader(data), &pathRecord{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// [step 2] 数据不完整
+	if err = Unmarshal(data[:len(data)-2], &pathRecord{}); err == nil {
+		t.Fatal("want error for truncated data")
+	}
+
+	// [step 3] 跳过 tag 0
+	d := NewDecoder(bytes.NewReader([]byte{0x00, 1, 0x71, 1, 'a'}))
+	var s string
+	if err = d.ReadString(&s, 1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int64{
+		"messages_encoded": 1,
+		"messages_decoded": 2,
+		"bytes_out":        int64(len(data)),
+		// 数据不完整时只统计读取成功的部分，最后一个字段 Tail 的数据没有读到
+		"bytes_in":       int64(3*len(data) - len(record.Tail)),
+		"fields_skipped": 1,
+	}
+	for _, name := range names {
+		if name == "decode_ns" {
+			if metricValue(t, name) <= before[name] {
+				t.Errorf("decode_ns not increased")
+			}
+			continue
+		}
+		if got := metricValue(t, name) - before[name]; got != want[name] {
+			t.Errorf("%s increased by %d, want %d", name, got, want[name])
+		}
+	}
+	if got := decodeErrorCount(t, decodeErrorEOF) - eofErrors; got != 1 {
+		t.Errorf("eof errors increased by %d, want 1", got)
+	}
+	if depth := metricValue(t, "max_depth"); depth < 2 {
+		t.Errorf("max_depth = %d, want >= 2", depth)
+	}
+
+	// [step 4] 按错误的类型区分，和错误的内容无关
+	for _, tt := range []struct {
+		data []byte
+		kind string
+	}{
+		{[]byte{0x02, 0x05}, decodeErrorMissing},       // 没有 require 的 tag 1，只有 tag 2
+		{[]byte{0x00, 0x05}, decodeErrorMissing},       // 只有 tag 0，数据结束时 require 的 tag 1 依然不存在
+		{[]byte{0x71, 0x01, 'a'}, decodeErrorMismatch}, // tag 1 是 String，期望的是整数
+		{[]byte{0x01}, decodeErrorEOF},
+	} {
+		before := decodeErrorCount(t, tt.kind)
+		if err = Unmarshal(tt.data, &pathRecord{}); err == nil {
+			t.Fatalf("want error for %x", tt.data)
+		}
+		if got := decodeErrorCount(t, tt.kind) - before; got != 1 {
+			t.Errorf("%x: %s errors increased by %d, want 1, err:%s", tt.data, tt.kind, got, err)
+		}
+	}
+
+	// [step 5] 关闭之后不再统计
+	DisableMetrics()
+	encoded := metricValue(t, "messages_encoded")
+	if _, err = Marshal(newPathRecord(1)); err != nil {
+		t.Fatal(err)
+	}
+	if got := metricValue(t, "messages_encoded"); got != encoded {
+		t.Errorf("messages_encoded changed after disable, %d != %d", got, encoded)
+	}
+}
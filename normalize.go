@@ -0,0 +1,37 @@
+package jce
+
+// ---------------------------------------------------------------------------
+// 编码数据的压缩
+// 其他语言的实现可能没有做压缩：整数使用了更大的宽度、浮点数 0 没有写成 Zero、
+// 短字符串使用了 4 字节的长度等，这里按本库 Encoder 的规则重新编码：
+//   1. 整数使用 writeInt1..writeInt8 的最小宽度，0 使用 Zero
+//   2. 浮点数 0 使用 Zero
+//   3. 长度使用 writeLength 的最短形式
+// 和 Canonicalize 不同，不会调整字段、map 的顺序，NaN 也保持原样
+// ---------------------------------------------------------------------------
+
+// 按本库的压缩规则重新编码 data
+func Normalize(data []byte) (out []byte, err error) {
+	out, _, err = NormalizeReport(data)
+	return
+}
+
+// 按本库的压缩规则重新编码 data，并返回节省的字节数
+func NormalizeReport(data []byte) (out []byte, saved int, err error) {
+	// [step 1] 解析
+	v, err := DecodeValue(data)
+	if err != nil {
+		return
+	}
+
+	// [step 2] 压缩
+	if v, err = compactValue(v, false); err != nil {
+		return
+	}
+
+	// [step 3] 编码
+	if out, err = encodeFields(v, false, len(data)); err != nil {
+		return
+	}
+	return out, len(data) - len(out), nil
+}
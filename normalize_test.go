@@ -0,0 +1,62 @@
+package jce
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	// 没有压缩的编码，字段、map 的顺序也不是本库的顺序
+	data := []byte{
+		0x30, 0, 0, 0, 0, 0, 0, 0, 5, // tag 0: Int8(5)
+		0x51, 0, 0, 0, 0, 0, 0, 0, 0, // tag 1: Float8(0)
+		0x22, 0, 0, 0x01, 0x2c, // tag 2: Int4(300)
+		0x74, 0x80, 0, 0, 2, 'h', 'i', // tag 4: 4 字节长度的 "hi"
+		0x83, 0x80, 0, 0, 2, // tag 3: map，4 字节长度
+		0x70, 1, 'b', 0x11, 0, 2, // "b": Int2(2)
+		0x70, 1, 'a', 0x41, 0, 0, 0, 0, // "a": Float4(0)
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+	check := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(e.WriteInt64(5, 0))
+	check(e.WriteFloat64(0, 1))
+	check(e.WriteInt32(300, 2))
+	check(e.WriteString("hi", 4))
+	check(e.WriteHead(Map, 3))
+	check(e.WriteLength(2))
+	check(e.WriteString("b", 0))
+	check(e.WriteInt16(2, 1))
+	check(e.WriteString("a", 0))
+	check(e.WriteFloat32(0, 1))
+	check(e.Flush())
+	want := b.Bytes()
+
+	out, saved, err := NormalizeReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("normalize failed\nwant:%x\ngot: %x", want, out)
+	}
+	if saved != len(data)-len(want) {
+		t.Errorf("saved = %d, want %d", saved, len(data)-len(want))
+	}
+
+	// 语义不变，再次压缩没有变化
+	if equal, err := Equal(data, out); err != nil || !equal {
+		t.Errorf("normalized data not equal, err:%v", err)
+	}
+	again, err := Normalize(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, out) {
+		t.Errorf("normalize not idempotent\nwant:%x\ngot: %x", out, again)
+	}
+}
@@ -0,0 +1,165 @@
+package jce
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// 编解码过程的观察
+// 用于排查生产者、消费者之间的不一致：在 Encoder、Decoder 上设置 Observer 后，
+// 每写、读一个 head、长度、标量都会收到一个事件，ReadHead 的结果（找到、不存在、跳过）也会收到事件
+// 没有设置 Observer 时只多一次判断，不会有额外的开销
+// ---------------------------------------------------------------------------
+
+// EventKind 事件的类型
+type EventKind byte
+
+const (
+	EventWriteHead   EventKind = iota // Encoder 写了一个 head
+	EventWriteLength                  // Encoder 写了 list、map 的长度
+	EventWriteValue                   // Encoder 写了一个标量、string 或者 SimpleList
+	EventFound                        // ReadHead 找到了需要的 tag
+	EventAbsent                       // ReadHead 没有找到需要的 tag，但是不是必须的
+	EventSkip                         // ReadHead 跳过了一个不需要的字段
+	EventReadLength                   // Decoder 读了 list、map 的长度
+	EventReadValue                    // Decoder 读了一个标量、string 或者 SimpleList
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventWriteHead:
+		return "write head"
+	case EventWriteLength:
+		return "write length"
+	case EventWriteValue:
+		return "write value"
+	case EventFound:
+		return "found"
+	case EventAbsent:
+		return "absent"
+	case EventSkip:
+		return "skip"
+	case EventReadLength:
+		return "read length"
+	case EventReadValue:
+		return "read value"
+	default:
+		return fmt.Sprintf("EventKind(%d)", byte(k))
+	}
+}
+
+// Event 一个编解码事件
+// 长度、值的事件中 Tag、Type、Offset 为它们所属的 head 的信息
+type Event struct {
+	Kind   EventKind
+	Tag    byte
+	Type   JceEncodeType // EventAbsent 没有类型
+	Offset int64         // head 的位置，EventAbsent 为当前的位置
+	Length int64         // list、map 的元素个数，string、SimpleList 的字节数
+	Value  any           // 标量、string、[]byte 的值，类型同 Read*、Write* 的参数
+}
+
+func (ev *Event) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s offset:%d tag:%d", ev.Kind, ev.Offset, ev.Tag)
+	if ev.Kind != EventAbsent {
+		fmt.Fprintf(&b, " type:%s", ev.Type)
+	}
+	if ev.Kind == EventReadLength || ev.Kind == EventWriteLength || ev.Length > 0 {
+		fmt.Fprintf(&b, " length:%d", ev.Length)
+	}
+	switch v := ev.Value.(type) {
+	case nil:
+	case string:
+		fmt.Fprintf(&b, " value:%q", v)
+	case []byte:
+		fmt.Fprintf(&b, " value:%x", v)
+	default:
+		fmt.Fprintf(&b, " value:%v", v)
+	}
+	return b.String()
+}
+
+// Observer 事件的接收者，ev 只在调用期间有效
+type Observer interface {
+	Observe(ev *Event)
+}
+
+// 设置 Decoder 的 Observer，nil 表示关闭，子 Decoder 会继承
+func (d *Decoder) SetObserver(o Observer) {
+	d.obs = o
+}
+
+// 设置 Encoder 的 Observer，nil 表示关闭，子 Encoder 会继承
+func (e *Encoder) SetObserver(o Observer) {
+	e.obs = o
+}
+
+// LogObserver 把事件逐行写入 io.Writer 的 Observer，可以在多个 Encoder、Decoder 间共享
+type LogObserver struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+// 创建一个 LogObserver，每行以 prefix 开头
+func NewLogObserver(w io.Writer, prefix string) *LogObserver {
+	return &LogObserver{w: w, prefix: prefix}
+}
+
+func (o *LogObserver) Observe(ev *Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintf(o.w, "%s%s\n", o.prefix, ev)
+}
+
+// ReadHead 的事件，记录找到、不存在的 head，后续的长度、值的事件使用
+func (d *Decoder) observeHead(kind EventKind, ty JceEncodeType, tag byte, offset int64) {
+	ev := Event{Kind: kind, Tag: tag, Type: ty, Offset: offset}
+	if kind != EventSkip {
+		d.head = ev
+	}
+	d.obs.Observe(&ev)
+}
+
+// 读到长度的事件
+func (d *Decoder) observeLength(length uint32) {
+	ev := d.head
+	ev.Kind, ev.Length = EventReadLength, int64(length)
+	d.obs.Observe(&ev)
+}
+
+// 读到值的事件，只有最近一次 ReadHead 找到的是 tag 时才会发送，tag 不存在时不发送
+func (d *Decoder) observeValue(tag byte, value any, length int) {
+	if d.head.Kind != EventFound || d.head.Tag != tag {
+		return
+	}
+	ev := d.head
+	ev.Kind, ev.Value, ev.Length = EventReadValue, value, int64(length)
+	d.head.Kind = EventReadValue
+	d.obs.Observe(&ev)
+}
+
+// 写 head 的事件
+func (e *Encoder) observeHead(ty JceEncodeType, tag byte, offset int64) {
+	e.head = Event{Kind: EventWriteHead, Tag: tag, Type: ty, Offset: offset}
+	ev := e.head
+	e.obs.Observe(&ev)
+}
+
+// 写长度的事件
+func (e *Encoder) observeLength(length uint32) {
+	ev := e.head
+	ev.Kind, ev.Length = EventWriteLength, int64(length)
+	e.obs.Observe(&ev)
+}
+
+// 写值的事件
+func (e *Encoder) observeValue(value any, length int) {
+	ev := e.head
+	ev.Kind, ev.Value, ev.Length = EventWriteValue, value, int64(length)
+	e.obs.Observe(&ev)
+}
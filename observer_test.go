@@ -0,0 +1,90 @@
+package jce
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type recordObserver struct {
+	events []string
+}
+
+func (o *recordObserver) Observe(ev *Event) {
+	o.events = append(o.events, ev.String())
+}
+
+func TestEncoderObserver(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	o := &recordObserver{}
+	e.SetObserver(o)
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(e.WriteInt32(7, 0))
+	must(e.WriteString("hi", 2))
+	must(e.WriteHead(List, 3))
+	must(e.WriteLength(1))
+	must(e.WriteInt64(300, 0))
+	if e.Offset() != 11 {
+		t.Errorf("offset = %d, want 11", e.Offset())
+	}
+	must(e.Flush())
+
+	want := []string{
+		"write head   offset:0 tag:0 type:Int1",
+		"write value  offset:0 tag:0 type:Int1 value:7",
+		"write head   offset:2 tag:2 type:String",
+		`write value  offset:2 tag:2 type:String length:2 value:"hi"`,
+		"write head   offset:6 tag:3 type:List",
+		"write length offset:6 tag:3 type:List length:1",
+		"write head   offset:8 tag:0 type:Int2",
+		"write value  offset:8 tag:0 type:Int2 value:300",
+	}
+	if got := strings.Join(o.events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("events failed\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+}
+
+func TestDecoderObserver(t *testing.T) {
+	data := []byte{0x00, 7, 0x72, 2, 'h', 'i', 0xa3, 1, 0x10, 0x01, 0x2c}
+
+	var log bytes.Buffer
+	d := NewDecoder(bytes.NewReader(data))
+	d.SetObserver(NewLogObserver(&log, "> "))
+
+	var a, b int32
+	var c int64
+	if err := d.ReadInt32(&a, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReadInt32(&b, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.ReadHead(3, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.ReadLength(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReadInt64(&c, 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `> found        offset:0 tag:0 type:Int1
+> read value   offset:0 tag:0 type:Int1 value:7
+> absent       offset:2 tag:1
+> skip         offset:2 tag:2 type:String
+> found        offset:6 tag:3 type:List
+> read length  offset:6 tag:3 type:List length:1
+> found        offset:8 tag:0 type:Int2
+> read value   offset:8 tag:0 type:Int2 value:300
+`
+	if log.String() != want {
+		t.Errorf("log failed\nwant:\n%s\ngot:\n%s", want, log.String())
+	}
+}
@@ -0,0 +1,319 @@
+package jce
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ---------------------------------------------------------------------------
+// 直接修改编码数据中的某个字段
+// 沿着路径读取 head 找到字段的位置，只替换这个字段的 head + data，其余字节原样保留
+// ---------------------------------------------------------------------------
+
+// 字段在数据中的位置
+type fieldSpan struct {
+	container  JceEncodeType // 字段所在的容器类型
+	found      bool          // 字段是否存在
+	start, end int           // 字段的 head 开头到数据结尾；不存在时 start == end，为插入的位置
+	entryStart int           // map 中 key 的开头，只对 map 有效
+
+	// 容器的元素个数及其长度字段的位置，只对 list、map、SimpleList 有效
+	count            uint32
+	lenStart, lenEnd int
+}
+
+// 修改 path 对应的字段，返回修改后的数据，不会修改 data
+//
+// value 支持 bool、各种整数、float32、float64、string、[]byte、[]int8，按 Encoder 的压缩规则编码；
+// 也支持 Value、RawMessage（ReadRaw 返回的完整字段）、Messager（作为嵌套的 struct）
+//
+// 字段不存在时会插入：struct 中按 tag 顺序插入，map 中追加一个 key，
+// list、SimpleList 只能在下标等于长度时追加
+func SetField(data []byte, path Path, value any) (out []byte, err error) {
+	// [step 1] 找到字段的位置
+	sp, err := findSpan(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("set %s failed, err:%s", path, err)
+	}
+	last := path[len(path)-1]
+
+	// [step 2] 按容器的类型编码新的字段
+	var field []byte
+	switch sp.container {
+	case StructBegin:
+		field, err = encodeField(last.Tag, value)
+	case List:
+		field, err = encodeField(0, value)
+	case SimpleList:
+		field, err = byteValue(value)
+	case Map:
+		if field, err = encodeField(1, value); err != nil || sp.found {
+			break
+		}
+		// map 中不存在的 key，需要带上 key 一起追加
+		var key []byte
+		if key, err = encodeKey(last); err == nil {
+			field = append(key, field...)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("set %s failed, err:%s", path, err)
+	}
+
+	// [step 3] 替换，新增元素时需要修改容器的长度
+	if sp.found || sp.container == StructBegin {
+		return splice(data, sp, sp.count, field), nil
+	}
+	return splice(data, sp, sp.count+1, field), nil
+}
+
+// 删除 path 对应的字段，返回修改后的数据，不会修改 data
+// struct 中的字段、map 中的 key 不存在时，原样返回
+func DeleteField(data []byte, path Path) (out []byte, err error) {
+	// [step 1] 找到字段的位置
+	sp, err := findSpan(data, path)
+	if err != nil {
+		return nil, fmt.Errorf("delete %s failed, err:%s", path, err)
+	}
+
+	// [step 2] 不存在
+	if !sp.found {
+		if sp.container == List || sp.container == SimpleList {
+			return nil, fmt.Errorf("delete %s failed, index out of range, length:%d", path, sp.count)
+		}
+		return append([]byte(nil), data...), nil
+	}
+
+	// [step 3] 删除，容器中的元素需要修改长度，map 需要连 key 一起删除
+	switch sp.container {
+	case StructBegin:
+		return splice(data, sp, sp.count, nil), nil
+	case Map:
+		sp.start = sp.entryStart
+	}
+	return splice(data, sp, sp.count-1, nil), nil
+}
+
+// 用 field 替换 sp 对应的字节，如果容器的元素个数变了，同时修改长度字段
+func splice(data []byte, sp fieldSpan, count uint32, field []byte) (out []byte) {
+	out = make([]byte, 0, len(data)+len(field))
+
+	// [step 1] 修改长度字段
+	if count != sp.count {
+		out = append(out, data[:sp.lenStart]...)
+		if sp.container == SimpleList {
+			out = defulatByteOrder.AppendUint32(out, count)
+		} else {
+			out = appendLength(out, count)
+		}
+		out = append(out, data[sp.lenEnd:sp.start]...)
+	} else {
+		out = append(out, data[:sp.start]...)
+	}
+
+	// [step 2] 替换字段
+	out = append(out, field...)
+	return append(out, data[sp.end:]...)
+}
+
+// 找到 path 对应的字段在 data 中的位置
+func findSpan(data []byte, path Path) (sp fieldSpan, err error) {
+	if len(path) == 0 {
+		return sp, errors.New("empty path")
+	}
+
+	// [step 1] 定位到最后一步所在的容器
+	d := NewDecoder(bytes.NewReader(data))
+	ty := StructBegin
+	for _, step := range path[:len(path)-1] {
+		if ty, err = d.locate(ty, step); err != nil {
+			return
+		}
+	}
+
+	sp.container = ty
+	last := path[len(path)-1]
+	off := func() int { return int(d.Offset()) }
+
+	switch {
+	// [step 2] struct 中的 tag，不存在时插入到第一个更大的 tag 或者 struct 结尾之前
+	case ty == StructBegin && last.Kind == StepTag:
+		for {
+			start := off()
+			cur, tag, err := d.readHead()
+			if err == io.EOF || (err == nil && (cur == StructEnd || tag > last.Tag)) {
+				sp.start, sp.end = start, start
+				return sp, nil
+			}
+			if err != nil {
+				return sp, err
+			}
+			if err = d.skipField(cur); err != nil {
+				return sp, err
+			}
+			if tag == last.Tag {
+				sp.start, sp.end, sp.found = start, off(), true
+				return sp, nil
+			}
+		}
+
+	// [step 3] list 的下标，下标等于长度时为追加的位置
+	case ty == List && last.Kind == StepIndex:
+		sp.lenStart = off()
+		if sp.count, err = d.readLength(); err != nil {
+			return
+		}
+		sp.lenEnd = off()
+		if last.Index < 0 || last.Index > int64(sp.count) {
+			return sp, fmt.Errorf("index %d out of range, length:%d", last.Index, sp.count)
+		}
+		for i := int64(0); i < last.Index; i++ {
+			if err = d.skipItem(); err != nil {
+				return
+			}
+		}
+		sp.start, sp.end = off(), off()
+		if last.Index == int64(sp.count) {
+			return
+		}
+		if err = d.skipItem(); err != nil {
+			return
+		}
+		sp.end, sp.found = off(), true
+
+	// [step 4] SimpleList 的下标，每个元素就是一个字节
+	case ty == SimpleList && last.Kind == StepIndex:
+		sp.lenStart = off()
+		if sp.count, err = d.readByte4(); err != nil {
+			return
+		}
+		sp.lenEnd = off()
+		if last.Index < 0 || last.Index > int64(sp.count) {
+			return sp, fmt.Errorf("index %d out of range, length:%d", last.Index, sp.count)
+		}
+		if _, err = d.readByte(); err != nil {
+			return
+		}
+		if err = d.skip(int(last.Index)); err != nil {
+			return
+		}
+		sp.start, sp.end = off(), off()
+		if last.Index < int64(sp.count) {
+			sp.end, sp.found = sp.start+1, true
+		}
+
+	// [step 5] map 的 key，不存在时为 map 结尾追加的位置
+	case ty == Map && (last.Kind == StepIndex || last.Kind == StepKey):
+		sp.lenStart = off()
+		if sp.count, err = d.readLength(); err != nil {
+			return
+		}
+		sp.lenEnd = off()
+		for i := uint32(0); i < sp.count; i++ {
+			entryStart := off()
+			key, err := d.readItemValue()
+			if err != nil {
+				return sp, err
+			}
+			start := off()
+			if err = d.skipItem(); err != nil {
+				return sp, err
+			}
+			if last.match(key) {
+				sp.entryStart, sp.start, sp.end, sp.found = entryStart, start, off(), true
+				return sp, nil
+			}
+		}
+		sp.start, sp.end = off(), off()
+
+	default:
+		return sp, fmt.Errorf("can not apply %s to type %s", last.format(true), ty)
+	}
+
+	return
+}
+
+// 把 value 编码为 tag 对应的一个完整字段
+func encodeField(tag byte, value any) (field []byte, err error) {
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+
+	switch v := value.(type) {
+	case bool:
+		err = e.WriteBool(v, tag)
+	case int8:
+		err = e.WriteInt8(v, tag)
+	case uint8:
+		err = e.WriteUint8(v, tag)
+	case int16:
+		err = e.WriteInt16(v, tag)
+	case uint16:
+		err = e.WriteUint16(v, tag)
+	case int32:
+		err = e.WriteInt32(v, tag)
+	case uint32:
+		err = e.WriteUint32(v, tag)
+	case int64:
+		err = e.WriteInt64(v, tag)
+	case uint64:
+		err = e.WriteUint64(v, tag)
+	case float32:
+		err = e.WriteFloat32(v, tag)
+	case float64:
+		err = e.WriteFloat64(v, tag)
+	case string:
+		err = e.WriteString(v, tag)
+	case []byte:
+		err = e.WriteSliceUint8(v, tag)
+	case []int8:
+		err = e.WriteSliceInt8(v, tag)
+	case Value:
+		err = e.writeValue(v, tag)
+	case RawMessage:
+		err = e.WriteRaw(tag, v)
+	case Messager:
+		if err = e.WriteHead(StructBegin, tag); err != nil {
+			return
+		}
+		if _, err = v.WriteTo(e.Writer()); err != nil {
+			return
+		}
+		err = e.WriteHead(StructEnd, 0)
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// 编码 map 中新追加的 key
+// 负数的宽度没法确定（比如 int32 的 -1 要写 4 字节），所以不支持
+func encodeKey(step PathStep) (key []byte, err error) {
+	if step.Kind == StepKey {
+		return encodeField(0, step.Key)
+	}
+	if step.Index < 0 {
+		return nil, fmt.Errorf("can not insert negative integer key %d", step.Index)
+	}
+	return encodeField(0, uint64(step.Index))
+}
+
+// SimpleList 的元素只能是一个字节
+func byteValue(value any) (b []byte, err error) {
+	switch v := value.(type) {
+	case uint8:
+		return []byte{v}, nil
+	case int8:
+		return []byte{uint8(v)}, nil
+	default:
+		return nil, fmt.Errorf("simple list item need uint8 or int8, but got %T", value)
+	}
+}
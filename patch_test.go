@@ -0,0 +1,154 @@
+package jce
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSetField(t *testing.T) {
+	data, err := Marshal(newPathRecord(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := append([]byte(nil), data...)
+
+	tests := []struct {
+		path  string
+		value any
+		check func(m *pathRecord) bool
+	}{
+		{path: "4", value: "new tail", check: func(m *pathRecord) bool { return m.Tail == "new tail" }},
+		{path: "1", value: int32(70000), check: func(m *pathRecord) bool { return m.Version == 70000 }},
+		{path: "1", value: int32(0), check: func(m *pathRecord) bool { return m.Version == 0 }},
+		{path: "2.5[1].2", value: int64(-5), check: func(m *pathRecord) bool { return m.Items[1].ID == -5 }},
+		{path: "2.5[3]", value: &pathItem{Name: "x", ID: 1}, check: func(m *pathRecord) bool {
+			return len(m.Items) == 4 && m.Items[3] == pathItem{Name: "x", ID: 1}
+		}},
+		{path: `2.6["c"]`, value: int32(3), check: func(m *pathRecord) bool { return len(m.Scores) == 3 && m.Scores["c"] == 3 }},
+		{path: `2.6["a"]`, value: int32(1000), check: func(m *pathRecord) bool { return len(m.Scores) == 2 && m.Scores["a"] == 1000 }},
+		{path: "2.7[-1]", value: "minus", check: func(m *pathRecord) bool { return m.Names[-1] == "minus" }},
+		{path: "3[0]", value: uint8(1), check: func(m *pathRecord) bool { return bytes.Equal(m.Bytes, []byte{1, 8, 7}) }},
+		{path: "3[3]", value: int8(6), check: func(m *pathRecord) bool { return bytes.Equal(m.Bytes, []byte{9, 8, 7, 6}) }},
+		{path: "2.8", value: "ignored", check: func(m *pathRecord) bool { return len(m.Items) == 3 }},
+		{path: "0", value: true, check: func(m *pathRecord) bool { return m.Version == 7 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out, err := SetField(data, MustParsePath(tt.path), tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var m pathRecord
+			if err := Unmarshal(out, &m); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(&m) {
+				t.Errorf("set %s failed, got:%+v", tt.path, m)
+			}
+		})
+	}
+
+	if !bytes.Equal(data, origin) {
+		t.Error("origin data modified")
+	}
+}
+
+func TestSetFieldInsertOrder(t *testing.T) {
+	data, err := Marshal(newPathRecord(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 插入的字段可以被按 tag 读到
+	for _, path := range []string{"0", "2.0", "2.9", "9", "200"} {
+		out, err := SetField(data, MustParsePath(path), "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := Extract(out, MustParsePath(path))
+		if err != nil {
+			t.Fatalf("extract %s failed, err:%s", path, err)
+		}
+		if string(v.Bytes) != "v" {
+			t.Errorf("insert %s failed, got:%s", path, v)
+		}
+	}
+}
+
+func TestSetFieldError(t *testing.T) {
+	data, err := Marshal(newPathRecord(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		path  string
+		value any
+	}{
+		{path: "2.5[2]", value: "x"},
+		{path: "3[0]", value: "x"},
+		{path: "2.7[-2]", value: "x"},
+		{path: "1.1", value: "x"},
+		{path: "4", value: struct{}{}},
+	} {
+		if _, err := SetField(data, MustParsePath(tt.path), tt.value); err == nil {
+			t.Errorf("set %s want error", tt.path)
+		}
+	}
+}
+
+func TestDeleteField(t *testing.T) {
+	data, err := Marshal(newPathRecord(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		check func(m *pathRecord) bool
+	}{
+		{path: "2.5[0]", check: func(m *pathRecord) bool {
+			return len(m.Items) == 2 && m.Items[0].Name == "item-1"
+		}},
+		{path: `2.6["a"]`, check: func(m *pathRecord) bool {
+			return reflect.DeepEqual(m.Scores, map[string]int32{"b": 2})
+		}},
+		{path: "2.7[300]", check: func(m *pathRecord) bool {
+			return reflect.DeepEqual(m.Names, map[int32]string{-1: "neg"})
+		}},
+		{path: "3[1]", check: func(m *pathRecord) bool { return bytes.Equal(m.Bytes, []byte{9, 7}) }},
+		{path: "9", check: func(m *pathRecord) bool { return m.Tail == "tail" }},
+		{path: `2.6["none"]`, check: func(m *pathRecord) bool { return len(m.Scores) == 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			out, err := DeleteField(data, MustParsePath(tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var m pathRecord
+			if err := Unmarshal(out, &m); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(&m) {
+				t.Errorf("delete %s failed, got:%+v", tt.path, m)
+			}
+		})
+	}
+
+	// 删除后读不到
+	out, err := DeleteField(data, MustParsePath("4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(out, MustParsePath("4")); err == nil {
+		t.Error("deleted field still exists")
+	}
+
+	if _, err := DeleteField(data, MustParsePath("2.5[3]")); err == nil {
+		t.Error("want error for index out of range")
+	}
+}
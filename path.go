@@ -0,0 +1,298 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 字段路径以及按路径提取字段
+// 路径的语法如：2.5[3].1、2["abc"]
+//   - 数字表示 struct 中的 tag
+//   - [n] 表示 list、SimpleList 的第 n 个元素（从 0 开始），或者 map 中整数 key 为 n 的 value
+//   - ["s"] 表示 map 中字符串 key 为 s 的 value，字符串使用 go 的引号语法
+//   - [*] 表示 list 的任意元素或者 map 的任意 value，只用于 Rewriter 的规则
+// ---------------------------------------------------------------------------
+
+// StepKind 路径中一步的类型
+type StepKind byte
+
+const (
+	StepTag   StepKind = iota // struct 中的 tag
+	StepIndex                 // list 的下标，或者 map 中的整数 key
+	StepKey                   // map 中的字符串 key
+	StepAny                   // list 的任意元素、map 的任意 key
+)
+
+// PathStep 路径中的一步
+type PathStep struct {
+	Kind  StepKind
+	Tag   byte   // StepTag
+	Index int64  // StepIndex
+	Key   string // StepKey
+}
+
+// Path 字段路径
+type Path []PathStep
+
+// 解析路径
+func ParsePath(s string) (p Path, err error) {
+	i := 0
+	for i < len(s) {
+		switch {
+		// [step 1] 下标或者 key
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("parse path %q failed, missing ']' at %d", s, i)
+			}
+			// 字符串 key 中可能包含 ']'，需要按引号找结尾
+			if i+1 < len(s) && s[i+1] == '"' {
+				quoted, err := strconv.QuotedPrefix(s[i+1:])
+				if err != nil {
+					return nil, fmt.Errorf("parse path %q failed, invalid key at %d", s, i+1)
+				}
+				end = 1 + len(quoted)
+				if i+end >= len(s) || s[i+end] != ']' {
+					return nil, fmt.Errorf("parse path %q failed, missing ']' at %d", s, i+end)
+				}
+			}
+
+			step, err := parseSelector(s[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("parse path %q failed at %d, err:%s", s, i+1, err)
+			}
+			p = append(p, step)
+			i += end + 1
+
+		// [step 2] tag，除了第一个，前面都需要有 '.'
+		default:
+			if len(p) > 0 {
+				if s[i] != '.' {
+					return nil, fmt.Errorf("parse path %q failed, want '.' or '[' at %d", s, i)
+				}
+				i++
+			}
+
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			tag, err := strconv.ParseUint(s[i:j], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("parse path %q failed, invalid tag at %d", s, i)
+			}
+			p = append(p, PathStep{Kind: StepTag, Tag: byte(tag)})
+			i = j
+		}
+	}
+
+	return
+}
+
+// 解析路径，失败时 panic，用于常量路径
+func MustParsePath(s string) Path {
+	p, err := ParsePath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// 解析 [] 中的内容
+func parseSelector(s string) (step PathStep, err error) {
+	if s == "*" {
+		return PathStep{Kind: StepAny}, nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		key, err := strconv.Unquote(s)
+		if err != nil {
+			return step, fmt.Errorf("invalid key %s", s)
+		}
+		return PathStep{Kind: StepKey, Key: key}, nil
+	}
+
+	index, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return step, fmt.Errorf("invalid index %q", s)
+	}
+	return PathStep{Kind: StepIndex, Index: index}, nil
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+	for i, step := range p {
+		b.WriteString(step.format(i == 0))
+	}
+	return b.String()
+}
+
+func (s PathStep) format(first bool) string {
+	switch s.Kind {
+	case StepTag:
+		if first {
+			return strconv.Itoa(int(s.Tag))
+		}
+		return "." + strconv.Itoa(int(s.Tag))
+	case StepIndex:
+		return "[" + strconv.FormatInt(s.Index, 10) + "]"
+	case StepAny:
+		return "[*]"
+	default:
+		return "[" + strconv.Quote(s.Key) + "]"
+	}
+}
+
+// 返回追加了一步之后的新路径，不会修改 p
+func (p Path) append(step PathStep) Path {
+	return append(p[:len(p):len(p)], step)
+}
+
+// 从编码数据中提取 path 对应的值
+// 只会沿着路径读取 head、跳过无关的字段，最后只解析目标字段，不需要反序列化整个消息
+func Extract(data []byte, path Path) (v Value, err error) {
+	d := NewDecoder(bytes.NewReader(data))
+
+	// [step 1] 沿着路径定位，最外层是 struct
+	ty := StructBegin
+	for i, step := range path {
+		if ty, err = d.locate(ty, step); err != nil {
+			return v, fmt.Errorf("extract %s failed, err:%s", path[:i+1], err)
+		}
+	}
+
+	// [step 2] 解析目标字段
+	if len(path) == 0 {
+		return d.readStructValue()
+	}
+	if v, err = d.readValue(ty); err != nil {
+		return v, fmt.Errorf("extract %s failed, err:%s", path, err)
+	}
+	return
+}
+
+// 当前位于 ty 类型数据部分的开头，定位到 step 对应的子字段的数据部分，并返回子字段的类型
+func (d *Decoder) locate(ty JceEncodeType, step PathStep) (child JceEncodeType, err error) {
+	switch {
+	// [step 1] struct 中的 tag
+	case ty == StructBegin && step.Kind == StepTag:
+		child, have, err := d.readHeadC(step.Tag, false)
+		if err != nil {
+			return child, err
+		}
+		if !have {
+			return child, fmt.Errorf("tag %d not found", step.Tag)
+		}
+		return child, nil
+
+	// [step 2] list 的下标
+	case ty == List && step.Kind == StepIndex:
+		length, err := d.readLength()
+		if err != nil {
+			return child, err
+		}
+		if step.Index < 0 || step.Index >= int64(length) {
+			return child, fmt.Errorf("index %d out of range, length:%d", step.Index, length)
+		}
+		for i := int64(0); i < step.Index; i++ {
+			if err = d.skipItem(); err != nil {
+				return child, err
+			}
+		}
+		child, _, err = d.readHead()
+		return child, err
+
+	// [step 3] SimpleList 的下标，元素都是 Int1
+	case ty == SimpleList && step.Kind == StepIndex:
+		length, err := d.readByte4()
+		if err != nil {
+			return child, err
+		}
+		if step.Index < 0 || step.Index >= int64(length) {
+			return child, fmt.Errorf("index %d out of range, length:%d", step.Index, length)
+		}
+		if _, err = d.readByte(); err != nil {
+			return child, err
+		}
+		return Int1, d.skip(int(step.Index))
+
+	// [step 4] map 的 key
+	case ty == Map && (step.Kind == StepIndex || step.Kind == StepKey):
+		return d.lookup(step)
+
+	default:
+		return child, fmt.Errorf("can not apply %s to type %s", step.format(true), ty)
+	}
+}
+
+// 在 map 中查找 key，找到后定位到 value 的数据部分
+func (d *Decoder) lookup(step PathStep) (child JceEncodeType, err error) {
+	length, err := d.readLength()
+	if err != nil {
+		return
+	}
+
+	for i := uint32(0); i < length; i++ {
+		// [step 1] key 比较小，直接解析
+		key, err := d.readItemValue()
+		if err != nil {
+			return child, err
+		}
+
+		// [step 2] 匹配则定位到 value，否则跳过 value
+		if step.match(key) {
+			child, _, err = d.readHead()
+			return child, err
+		}
+		if err = d.skipItem(); err != nil {
+			return child, err
+		}
+	}
+
+	return child, fmt.Errorf("key %s not found", step.format(true))
+}
+
+// 跳过 list、map 中的一个元素
+func (d *Decoder) skipItem() (err error) {
+	ty, _, err := d.readHead()
+	if err != nil {
+		return
+	}
+	return d.skipField(ty)
+}
+
+// map 的 key 是否和 step 匹配
+// 整数 key 编码时会被压缩，所以负数既可能按无符号存储，也可能按对应宽度的有符号存储
+func (s PathStep) match(key Value) bool {
+	if s.Kind == StepAny {
+		return true
+	}
+	if s.Kind == StepKey {
+		return key.Type == String && string(key.Bytes) == s.Key
+	}
+
+	switch key.Type {
+	case Zero:
+		return s.Index == 0
+	case Int1, Int2, Int4, Int8:
+		return key.Int == uint64(s.Index) || key.signed() == s.Index
+	default:
+		return false
+	}
+}
+
+// 按整数的宽度做符号扩展
+func (v Value) signed() int64 {
+	switch v.Type {
+	case Int1:
+		return int64(int8(v.Int))
+	case Int2:
+		return int64(int16(v.Int))
+	case Int4:
+		return int64(int32(v.Int))
+	default:
+		return int64(v.Int)
+	}
+}
@@ -0,0 +1,341 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		err  bool
+	}{
+		{path: "2.5[3].1", want: "2.5[3].1"},
+		{path: `2["a]b"].1`, want: `2["a]b"].1`},
+		{path: "2[-1]", want: "2[-1]"},
+		{path: "2[*].1", want: "2[*].1"},
+		{path: "255", want: "255"},
+		{path: "", want: ""},
+		{path: "256", err: true},
+		{path: "2.", err: true},
+		{path: "2[3", err: true},
+		{path: "2[x]", err: true},
+		{path: "2 5", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := ParsePath(tt.path)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParsePath(%q) err = %v, want err %v", tt.path, err, tt.err)
+			}
+			if err == nil && p.String() != tt.want {
+				t.Errorf("ParsePath(%q) = %s, want %s", tt.path, p, tt.want)
+			}
+		})
+	}
+}
+
+// 测试用的消息
+type pathItem struct {
+	Name string
+	ID   int64
+}
+
+type pathRecord struct {
+	Version int32
+	Items   []pathItem
+	Scores  map[string]int32
+	Names   map[int32]string
+	Bytes   []byte
+	Tail    string
+}
+
+func (m *pathItem) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	if err = d.ReadString(&m.Name, 1, true); err != nil {
+		return
+	}
+	if err = d.ReadInt64(&m.ID, 2, true); err != nil {
+		return
+	}
+	return 0, d.SkipToStructEnd()
+}
+
+func (m *pathItem) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	if err = e.WriteString(m.Name, 1); err != nil {
+		return
+	}
+	if err = e.WriteInt64(m.ID, 2); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+func (m *pathRecord) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	defer func() { n = d.Offset() }()
+	if err = d.ReadInt32(&m.Version, 1, true); err != nil {
+		return
+	}
+
+	// tag 2 是一个嵌套的 struct
+	if _, _, err = d.ReadHead(2, true); err != nil {
+		return
+	}
+	c := NewDecoder(d)
+	if err = m.readBody(c); err != nil {
+		return
+	}
+
+	if err = d.ReadSliceUint8(&m.Bytes, 3, true); err != nil {
+		return
+	}
+	return 0, d.ReadString(&m.Tail, 4, true)
+}
+
+func (m *pathRecord) readBody(d *Decoder) (err error) {
+	if _, _, err = d.ReadHead(5, true); err != nil {
+		return
+	}
+	length, err := d.ReadLength()
+	if err != nil {
+		return
+	}
+	m.Items = make([]pathItem, length)
+	for i := range m.Items {
+		if _, _, err = d.ReadHead(0, true); err != nil {
+			return
+		}
+		if _, err = m.Items[i].ReadFrom(d); err != nil {
+			return
+		}
+	}
+
+	if _, _, err = d.ReadHead(6, true); err != nil {
+		return
+	}
+	if length, err = d.ReadLength(); err != nil {
+		return
+	}
+	m.Scores = make(map[string]int32, length)
+	for i := uint32(0); i < length; i++ {
+		var k string
+		var v int32
+		if err = d.ReadString(&k, 0, true); err != nil {
+			return
+		}
+		if err = d.ReadInt32(&v, 1, true); err != nil {
+			return
+		}
+		m.Scores[k] = v
+	}
+
+	if _, _, err = d.ReadHead(7, true); err != nil {
+		return
+	}
+	if length, err = d.ReadLength(); err != nil {
+		return
+	}
+	m.Names = make(map[int32]string, length)
+	for i := uint32(0); i < length; i++ {
+		var k int32
+		var v string
+		if err = d.ReadInt32(&k, 0, true); err != nil {
+			return
+		}
+		if err = d.ReadString(&v, 1, true); err != nil {
+			return
+		}
+		m.Names[k] = v
+	}
+
+	return d.SkipToStructEnd()
+}
+
+func (m *pathRecord) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	defer func() { n = e.Offset() }()
+	if err = e.WriteInt32(m.Version, 1); err != nil {
+		return
+	}
+
+	if err = e.WriteHead(StructBegin, 2); err != nil {
+		return
+	}
+	if err = e.WriteHead(List, 5); err != nil {
+		return
+	}
+	if err = e.WriteLength(uint32(len(m.Items))); err != nil {
+		return
+	}
+	for i := range m.Items {
+		if err = e.WriteHead(StructBegin, 0); err != nil {
+			return
+		}
+		if _, err = m.Items[i].WriteTo(e); err != nil {
+			return
+		}
+		if err = e.WriteHead(StructEnd, 0); err != nil {
+			return
+		}
+	}
+
+	if err = e.WriteHead(Map, 6); err != nil {
+		return
+	}
+	if err = e.WriteLength(uint32(len(m.Scores))); err != nil {
+		return
+	}
+	for k, v := range m.Scores {
+		if err = e.WriteString(k, 0); err != nil {
+			return
+		}
+		if err = e.WriteInt32(v, 1); err != nil {
+			return
+		}
+	}
+
+	if err = e.WriteHead(Map, 7); err != nil {
+		return
+	}
+	if err = e.WriteLength(uint32(len(m.Names))); err != nil {
+		return
+	}
+	for k, v := range m.Names {
+		if err = e.WriteInt32(k, 0); err != nil {
+			return
+		}
+		if err = e.WriteString(v, 1); err != nil {
+			return
+		}
+	}
+	if err = e.WriteHead(StructEnd, 0); err != nil {
+		return
+	}
+
+	if err = e.WriteSliceUint8(m.Bytes, 3); err != nil {
+		return
+	}
+	if err = e.WriteString(m.Tail, 4); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+func newPathRecord(items int) *pathRecord {
+	m := &pathRecord{
+		Version: 7,
+		Scores:  map[string]int32{"a": 1, "b": 2},
+		Names:   map[int32]string{-1: "neg", 300: "big"},
+		Bytes:   []byte{9, 8, 7},
+		Tail:    "tail",
+	}
+	for i := 0; i < items; i++ {
+		m.Items = append(m.Items, pathItem{Name: fmt.Sprintf("item-%d", i), ID: int64(i) * 1000})
+	}
+	return m
+}
+
+func TestExtract(t *testing.T) {
+	data, err := Marshal(newPathRecord(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+		err  bool
+	}{
+		{path: "1", want: "Int1(7)"},
+		{path: "2.5[3].1", want: `"item-3"`},
+		{path: "2.5[4].2", want: "Int2(4000)"},
+		{path: "2.5[0].2", want: "Zero"},
+		{path: `2.6["b"]`, want: "Int1(2)"},
+		{path: "2.7[-1]", want: `"neg"`},
+		{path: "2.7[300]", want: `"big"`},
+		{path: "3[1]", want: "Int1(8)"},
+		{path: "4", want: `"tail"`},
+		{path: "2.5[1]", want: `{1: "item-1", 2: Int2(1000)}`},
+		{path: "2.5[5]", err: true},
+		{path: `2.6["c"]`, err: true},
+		{path: "9", err: true},
+		{path: "1[0]", err: true},
+		{path: "2.5.1", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, err := Extract(data, MustParsePath(tt.path))
+			if (err != nil) != tt.err {
+				t.Fatalf("Extract(%s) err = %v, want err %v", tt.path, err, tt.err)
+			}
+			if err == nil && v.String() != tt.want {
+				t.Errorf("Extract(%s) = %s, want %s", tt.path, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	want := newPathRecord(3)
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := DecodeValue(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail, ok := v.Field(4); !ok || string(tail.Bytes) != "tail" {
+		t.Errorf("field failed, got:%s", tail)
+	}
+
+	// 原样编码回去，和原始数据一致
+	got, err := EncodeValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("encode value failed\nwant:%v\ngot: %v", data, got)
+	}
+}
+
+func BenchmarkExtract(b *testing.B) {
+	data, err := Marshal(newPathRecord(100))
+	if err != nil {
+		b.Fatal(err)
+	}
+	path := MustParsePath("2.5[50].1")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Extract(data, path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExtractUnmarshal(b *testing.B) {
+	data, err := Marshal(newPathRecord(100))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var m pathRecord
+		if err := Unmarshal(data, &m); err != nil {
+			b.Fatal(err)
+		}
+		if m.Items[50].Name != "item-50" {
+			b.Fatal("no eq.")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package jce
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 字段出现情况的记录
+// Read* 系列函数在 optional 字段不存在时不会修改传入的指针，所以调用方无法区分
+// "字段不存在" 和 "字段存在且值为默认值"，开启记录后可以通过 Present 查询
+// ---------------------------------------------------------------------------
+
+// FieldSet tag 的集合，tag 最大为 255，故用 256 bit 表示
+type FieldSet [4]uint64
+
+// 添加一个 tag
+func (s *FieldSet) Add(tag byte) {
+	s[tag>>6] |= 1 << (tag & 63)
+}
+
+// 判断 tag 是否存在
+func (s *FieldSet) Has(tag byte) bool {
+	return s[tag>>6]&(1<<(tag&63)) != 0
+}
+
+// 清空
+func (s *FieldSet) Reset() {
+	*s = FieldSet{}
+}
+
+// 按从小到大的顺序返回集合中的所有 tag
+func (s *FieldSet) Tags() (tags []byte) {
+	for i := 0; i < 256; i++ {
+		if s.Has(byte(i)) {
+			tags = append(tags, byte(i))
+		}
+	}
+	return
+}
+
+// MissingFieldsError 必须存在的字段缺失，一次性列出所有缺失的 tag；读取不存在的 require 字段时也会返回，只包含这一个 tag
+type MissingFieldsError struct {
+	Tags []byte
+}
+
+func (e *MissingFieldsError) Error() string {
+	tags := make([]string, 0, len(e.Tags))
+	for _, tag := range e.Tags {
+		tags = append(tags, fmt.Sprint(tag))
+	}
+	return fmt.Sprintf("missing required tags: %s", strings.Join(tags, ", "))
+}
+
+// 开启或者关闭字段出现情况的记录，开启时会清空已有的记录
+// 记录只针对当前这一层 struct，通过 NewDecoder(d) 创建的子 Decoder 会继承这个开关，但有自己的记录
+func (d *Decoder) TrackPresence(on bool) {
+	d.trackPresence = on
+	d.presence.Reset()
+}
+
+// 返回当前这一层 struct 中出现过的 tag，包括读取时被跳过的 tag
+func (d *Decoder) Presence() *FieldSet {
+	return &d.presence
+}
+
+// tag 是否出现过
+func (d *Decoder) Present(tag byte) bool {
+	return d.presence.Has(tag)
+}
+
+// 检查所有 tag 是否都出现过，如果有缺失，则返回 *MissingFieldsError，包含所有缺失的 tag
+// 设置了字段掩码时，被过滤掉的 require 字段读取时不会报错，需要用 RequireAll 检查；
+// 这些字段只有在读取后面的字段或者 SkipToStructEnd 跳过它们时才会被记录为出现过
+func (d *Decoder) RequireAll(tags ...byte) (err error) {
+	if !d.trackPresence {
+		return errors.New("presence tracking not enabled")
+	}
+
+	var missing []byte
+	for _, tag := range tags {
+		if !d.presence.Has(tag) {
+			missing = append(missing, tag)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingFieldsError{Tags: missing}
+	}
+	return
+}
+
+// 记录一个出现过的 tag
+//
+//go:nosplit
+func (d *Decoder) markPresent(tag byte) {
+	if d.trackPresence {
+		d.presence.Add(tag)
+	}
+}
@@ -0,0 +1,129 @@
+package jce
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFieldSet(t *testing.T) {
+	var s FieldSet
+	for _, tag := range []byte{0, 63, 64, 200, 255} {
+		s.Add(tag)
+	}
+
+	if !s.Has(63) || !s.Has(255) || s.Has(1) {
+		t.Error("has failed")
+	}
+
+	if got := s.Tags(); !reflect.DeepEqual(got, []byte{0, 63, 64, 200, 255}) {
+		t.Errorf("tags failed, got:%v", got)
+	}
+
+	s.Reset()
+	if len(s.Tags()) != 0 {
+		t.Error("reset failed")
+	}
+}
+
+func TestPresence(t *testing.T) {
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	if err := e.WriteInt32(0, 1); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteString("x", 2); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteInt64(7, 5); err != nil {
+		t.Error(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Error(err)
+	}
+
+	d := NewDecoder(data)
+	d.TrackPresence(true)
+
+	// tag 2 不读，会被跳过；tag 3、4 不存在
+	a, b, c := int32(9), int32(9), int64(9)
+	if err := d.ReadInt32(&a, 1, false); err != nil {
+		t.Error(err)
+	}
+	if err := d.ReadInt32(&b, 3, false); err != nil {
+		t.Error(err)
+	}
+	if err := d.ReadInt64(&c, 5, false); err != nil {
+		t.Error(err)
+	}
+
+	if a != 0 || b != 9 || c != 7 {
+		t.Errorf("read failed, a:%d, b:%d, c:%d", a, b, c)
+	}
+
+	if !d.Present(1) || !d.Present(2) || d.Present(3) || !d.Present(5) {
+		t.Errorf("presence failed, got:%v", d.Presence().Tags())
+	}
+
+	err := d.RequireAll(1, 3, 4, 5)
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("want MissingFieldsError, got:%v", err)
+	}
+	if !reflect.DeepEqual(missing.Tags, []byte{3, 4}) {
+		t.Errorf("missing tags failed, got:%v", missing.Tags)
+	}
+	if err.Error() != "missing required tags: 3, 4" {
+		t.Errorf("error message failed, got:%s", err)
+	}
+
+	if err := d.RequireAll(1, 2, 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPresenceDisabled(t *testing.T) {
+	d := NewDecoder(bytes.NewBuffer(nil))
+	if err := d.RequireAll(1); err == nil {
+		t.Error("want error when tracking disabled")
+	}
+}
+
+func TestPresenceChildDecoder(t *testing.T) {
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	if err := e.WriteInt32(1, 0); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteInt32(2, 1); err != nil {
+		t.Error(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Error(err)
+	}
+
+	d := NewDecoder(data)
+	d.TrackPresence(true)
+
+	var v int32
+	if err := d.ReadInt32(&v, 0, true); err != nil {
+		t.Error(err)
+	}
+
+	// 子 Decoder 共享缓冲区，继承开关，但记录是独立的
+	c := NewDecoder(d)
+	if err := c.ReadInt32(&v, 1, true); err != nil {
+		t.Error(err)
+	}
+	if v != 2 {
+		t.Errorf("child read failed, got:%d", v)
+	}
+
+	if !c.Present(1) || c.Present(0) {
+		t.Errorf("child presence failed, got:%v", c.Presence().Tags())
+	}
+	if d.Present(1) || !d.Present(0) {
+		t.Errorf("parent presence failed, got:%v", d.Presence().Tags())
+	}
+}
@@ -0,0 +1,238 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ---------------------------------------------------------------------------
+// 编码大小的统计
+// 把编码数据的每个字节（head、长度、数据）都归到对应的字段路径和类型上，
+// 可以累加多个消息，用于找出占用空间最多、最值得优化的字段：
+//   - list、map 的元素使用 [*] 汇总，比如 2[*].1
+//   - map 的 key 计入 map 字段本身，value 计入 [*]
+//   - struct 的 StructBegin、StructEnd 计入 struct 字段本身
+// ---------------------------------------------------------------------------
+
+// SizeEntry 一个字段路径、类型的统计
+type SizeEntry struct {
+	Path  string
+	Type  JceEncodeType
+	Count int64 // 出现的次数
+	Bytes int64 // 总字节数，包括子字段
+	Own   int64 // 字段自身的字节数，不包括子字段，所有字段的 Own 之和等于 Total
+}
+
+// 平均每次出现的字节数
+func (e *SizeEntry) Average() float64 {
+	if e.Count == 0 {
+		return 0
+	}
+	return float64(e.Bytes) / float64(e.Count)
+}
+
+// SizeReport 若干个消息的编码大小统计
+type SizeReport struct {
+	Payloads int   // 统计的消息个数
+	Invalid  int   // 无法解析、没有统计的消息个数
+	Total    int64 // 统计的消息的总字节数
+
+	entries map[sizeKey]*SizeEntry
+}
+
+type sizeKey struct {
+	path string
+	ty   JceEncodeType
+}
+
+// 创建一个空的统计
+func NewSizeReport() *SizeReport {
+	return &SizeReport{entries: make(map[sizeKey]*SizeEntry)}
+}
+
+// 统计一个消息，无法解析的消息只计入 Invalid
+func Profile(data []byte) *SizeReport {
+	r := NewSizeReport()
+	_ = r.Add(data)
+	return r
+}
+
+// 累加一个消息的统计，无法解析时返回错误，并且只计入 Invalid
+func (r *SizeReport) Add(data []byte) (err error) {
+	// [step 1] 先统计到临时的结果中，避免解析失败时只统计了一部分
+	p := NewSizeReport()
+	d := NewDecoder(bytes.NewReader(data))
+	if _, err = p.profileStruct(d, nil, false); err != nil {
+		r.Invalid++
+		return fmt.Errorf("profile failed at offset %d, err:%s", d.Offset(), err)
+	}
+
+	// [step 2] 合并
+	p.Payloads, p.Total = 1, int64(len(data))
+	r.Merge(p)
+	return nil
+}
+
+// 累加另一个统计的结果
+func (r *SizeReport) Merge(o *SizeReport) {
+	r.Payloads += o.Payloads
+	r.Invalid += o.Invalid
+	r.Total += o.Total
+	for k, e := range o.entries {
+		r.add(k.path, k.ty, e.Count, e.Bytes, e.Own)
+	}
+}
+
+// 按总字节数从大到小返回所有的统计
+func (r *SizeReport) Entries() (entries []SizeEntry) {
+	entries = make([]SizeEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Bytes != entries[j].Bytes {
+			return entries[i].Bytes > entries[j].Bytes
+		}
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Type < entries[j].Type
+	})
+	return
+}
+
+// 以表格的形式输出，按总字节数从大到小排列
+func (r *SizeReport) WriteTo(w io.Writer) (n int64, err error) {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "path\ttype\tcount\tbytes\tavg\tshare\t\n")
+	for _, e := range r.Entries() {
+		share := 0.0
+		if r.Total > 0 {
+			share = float64(e.Bytes) * 100 / float64(r.Total)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f\t%.1f%%\t\n", e.Path, e.Type, e.Count, e.Bytes, e.Average(), share)
+	}
+	fmt.Fprintf(tw, "total\t\t%d\t%d\t\t\t\n", r.Payloads, r.Total)
+	if err = tw.Flush(); err != nil {
+		return
+	}
+
+	c, err := io.WriteString(w, b.String())
+	return int64(c), err
+}
+
+func (r *SizeReport) String() string {
+	var b strings.Builder
+	_, _ = r.WriteTo(&b)
+	return b.String()
+}
+
+// 累加一个字段的统计
+func (r *SizeReport) add(path string, ty JceEncodeType, count, size, own int64) {
+	k := sizeKey{path: path, ty: ty}
+	e, ok := r.entries[k]
+	if !ok {
+		e = &SizeEntry{Path: path, Type: ty}
+		r.entries[k] = e
+	}
+	e.Count += count
+	e.Bytes += size
+	e.Own += own
+}
+
+// 统计 struct 内的字段，直到 StructEnd 或者数据结束，返回所有字段的字节数，不包括 StructEnd
+func (r *SizeReport) profileStruct(d *Decoder, path Path, nested bool) (size int64, err error) {
+	for {
+		// [step 1] 读 head
+		start := d.Offset()
+		ty, tag, err := d.readHead()
+		if err == io.EOF && !nested {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if ty == StructEnd {
+			break
+		}
+
+		// [step 2] 统计字段
+		n, err := r.profileField(d, ty, path.append(PathStep{Kind: StepTag, Tag: tag}), start)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
+
+// 统计一个字段，head 已经读取，start 为 head 的位置，返回字段的总字节数
+func (r *SizeReport) profileField(d *Decoder, ty JceEncodeType, path Path, start int64) (size int64, err error) {
+	// 子字段的字节数
+	var children int64
+	item := path.append(PathStep{Kind: StepAny})
+
+	switch ty {
+	// [step 1] struct 递归统计
+	case StructBegin:
+		if children, err = r.profileStruct(d, path, true); err != nil {
+			return
+		}
+
+	// [step 2] list 统计每个元素
+	case List:
+		length, err := d.readLength()
+		if err != nil {
+			return 0, err
+		}
+		for i := uint32(0); i < length; i++ {
+			n, err := r.profileItem(d, item)
+			if err != nil {
+				return 0, err
+			}
+			children += n
+		}
+
+	// [step 3] map 的 key 计入 map 本身，统计每个 value
+	case Map:
+		length, err := d.readLength()
+		if err != nil {
+			return 0, err
+		}
+		for i := uint32(0); i < length; i++ {
+			if err = d.skipItem(); err != nil {
+				return 0, err
+			}
+			n, err := r.profileItem(d, item)
+			if err != nil {
+				return 0, err
+			}
+			children += n
+		}
+
+	// [step 4] 其他类型没有子字段
+	default:
+		if err = d.skipField(ty); err != nil {
+			return
+		}
+	}
+
+	size = d.Offset() - start
+	r.add(path.String(), ty, 1, size, size-children)
+	return
+}
+
+// 统计 list、map 中的一个元素，返回元素的总字节数
+func (r *SizeReport) profileItem(d *Decoder, path Path) (size int64, err error) {
+	start := d.Offset()
+	ty, _, err := d.readHead()
+	if err != nil {
+		return
+	}
+	return r.profileField(d, ty, path, start)
+}
@@ -0,0 +1,65 @@
+package jce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	data := mustEncodeValue(t, structValue(
+		Field{Tag: 1, Value: stringValue("hello")},
+		Field{Tag: 2, Value: Value{Type: List, List: []Value{
+			structValue(Field{Tag: 1, Value: Value{Type: Int1, Int: 5}}),
+			structValue(Field{Tag: 1, Value: Value{Type: Int1, Int: 6}}),
+		}}},
+		Field{Tag: 3, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("k"), Value: Value{Type: Int1, Int: 1}},
+		}}},
+	))
+
+	r := Profile(data)
+	if err := r.Add(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add([]byte{0x70, 5}); err == nil {
+		t.Error("want error for invalid data")
+	}
+	if r.Payloads != 2 || r.Invalid != 1 || r.Total != 48 {
+		t.Errorf("report = %d payloads, %d invalid, %d bytes", r.Payloads, r.Invalid, r.Total)
+	}
+
+	want := []SizeEntry{
+		{Path: "2", Type: List, Count: 2, Bytes: 20, Own: 4},
+		{Path: "2[*]", Type: StructBegin, Count: 4, Bytes: 16, Own: 8},
+		{Path: "1", Type: String, Count: 2, Bytes: 14, Own: 14},
+		{Path: "3", Type: Map, Count: 2, Bytes: 14, Own: 10},
+		{Path: "2[*].1", Type: Int1, Count: 4, Bytes: 8, Own: 8},
+		{Path: "3[*]", Type: Int1, Count: 2, Bytes: 4, Own: 4},
+	}
+	got := r.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("entries = %+v", got)
+	}
+	var own int64
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+		own += got[i].Own
+	}
+	if own != r.Total {
+		t.Errorf("sum of own bytes = %d, want %d", own, r.Total)
+	}
+
+	// 表格按总字节数排列
+	lines := strings.Split(strings.TrimSpace(r.String()), "\n")
+	if len(lines) != len(want)+2 {
+		t.Fatalf("table:\n%s", r)
+	}
+	if row := strings.Join(strings.Fields(lines[1]), " "); row != "2 List 2 20 10.0 41.7%" {
+		t.Errorf("first row = %q", row)
+	}
+	if row := strings.Join(strings.Fields(lines[len(lines)-1]), " "); row != "total 2 48" {
+		t.Errorf("total row = %q", row)
+	}
+}
@@ -0,0 +1,158 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ---------------------------------------------------------------------------
+// 原始字节的读写
+// 用于透传某个字段，比如只解析 header 做路由，body 原样转发
+// ---------------------------------------------------------------------------
+
+// RawMessage 已经编码好的 jce 数据，序列化和反序列化时原样读写
+type RawMessage []byte
+
+// 读取一个 struct 的所有字段，直到 StructEnd（会被读掉，但不包括在结果中）或者数据结束
+// 作为嵌套的 struct 字段时，r 为外层的 Decoder，只读取这一个 struct，后面的字段留给外层读取
+func (m *RawMessage) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	start := d.Offset()
+
+	// [step 1] 保存外层的记录状态，开始新的记录
+	recording, rec := d.recording, d.rec
+	d.recording, d.rec = true, nil
+
+	// [step 2] 逐个跳过字段，记录下读到的字节
+	end := -1
+	for {
+		before := len(d.rec)
+		ty, _, err := d.readHead()
+		if err == io.EOF && len(d.rec) > before {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return d.Offset() - start, err
+		}
+		if ty == StructEnd {
+			end = before
+			break
+		}
+		if err = d.skipField(ty); err != nil {
+			return d.Offset() - start, err
+		}
+	}
+
+	// [step 3] 去掉 StructEnd，并恢复外层的记录，外层也需要这部分数据
+	data := d.rec
+	if end >= 0 {
+		data = data[:end:end]
+	}
+	d.recording, d.rec = recording, append(rec, d.rec...)
+	if !recording {
+		d.rec = nil
+	}
+	*m = data
+	return d.Offset() - start, nil
+}
+
+// 原样写入
+func (m RawMessage) WriteTo(w io.Writer) (n int64, err error) {
+	c, err := w.Write(m)
+	return int64(c), err
+}
+
+// 读取一个字段完整的原始字节，包括 head 和 data，以及字段的类型
+// 字段不存在且不是必须的时，返回 nil
+func (d *Decoder) ReadRaw(tag byte, require bool) (raw []byte, t JceEncodeType, err error) {
+	// [step 1] 读取 head
+	t, have, err := d.ReadHead(tag, require)
+	if err != nil {
+		return nil, t, fmt.Errorf("read head failed, tag:%d, err:%w", tag, err)
+	}
+	if !have {
+		return nil, t, nil
+	}
+
+	// [step 2] 借助 skipField 找到字段的结尾，读出数据部分
+	payload, err := d.readFieldRaw(t)
+	if err != nil {
+		return nil, t, fmt.Errorf("read raw data failed, tag:%d, type:%s, err:%w", tag, t, err)
+	}
+
+	// [step 3] 补上 head
+	raw = appendHead(make([]byte, 0, len(payload)+2), t, tag)
+	return append(raw, payload...), t, nil
+}
+
+// 写入一个已经编码好的字段，raw 为 ReadRaw 返回的 head + data
+// 会以 raw 中的类型和传入的 tag 重新写 head，所以可以换一个 tag 写入
+func (e *Encoder) WriteRaw(tag byte, raw []byte) (err error) {
+	// [step 1] 解析原来的 head
+	t, _, n, err := parseHead(raw)
+	if err != nil {
+		return fmt.Errorf("parse raw head failed, tag:%d, err:%w", tag, err)
+	}
+
+	// [step 2] 写新的 head
+	if err = e.writeHead(t, tag); err != nil {
+		return
+	}
+
+	// [step 3] 写数据
+	return e.writeByteN(raw[n:])
+}
+
+// 把 struct 内的数据拆分为一个个完整的字段，直到 StructEnd 或者数据结束
+// 返回的 Data 直接引用 data 中的字节
+func splitFields(data []byte) (fields []RawField, err error) {
+	d := NewDecoder(bytes.NewReader(data))
+	for {
+		// [step 1] 读 head
+		start := d.Offset()
+		ty, tag, err := d.readHead()
+		if err == io.EOF || (err == nil && ty == StructEnd) {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// [step 2] 借助 skipField 找到字段的结尾
+		if err = d.skipField(ty); err != nil {
+			return nil, fmt.Errorf("skip tag %d failed, err:%w", tag, err)
+		}
+		fields = append(fields, RawField{Tag: tag, Type: ty, Data: data[start:d.Offset()]})
+	}
+}
+
+// 把 list（n 为 1）或者 map（n 为 2）的数据部分拆分为一个个元素，每个元素包括 head + data
+// map 的元素按 key、value 交替排列
+func splitItems(payload []byte, n int) (items [][]byte, err error) {
+	d := NewDecoder(bytes.NewReader(payload))
+
+	// [step 1] 读长度
+	length, err := d.readLength()
+	if err != nil {
+		return
+	}
+
+	// [step 2] 逐个跳过元素，每个元素至少占一个字节，长度不会超过数据的字节数
+	total := int(length) * n
+	if total > len(payload) {
+		return nil, fmt.Errorf("invalid length %d, only %d bytes", length, len(payload))
+	}
+	items = make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		start := d.Offset()
+		if err = d.skipItem(); err != nil {
+			return nil, fmt.Errorf("skip item %d failed, err:%w", i, err)
+		}
+		items = append(items, payload[start:d.Offset()])
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package jce
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadWriteRaw(t *testing.T) {
+	// [step 1] 构造数据: tag 0 为 header，tag 1 为嵌套的 body
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	if err := e.WriteString("route", 0); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteHead(StructBegin, 1); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteString("hello", 0); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteSliceUint8([]byte{1, 2}, 16); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteHead(StructEnd, 0); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteInt32(-1, 2); err != nil {
+		t.Error(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Error(err)
+	}
+	origin := append([]byte(nil), data.Bytes()...)
+
+	// [step 2] 只读 header，body 读成原始字节
+	d := NewDecoder(data)
+	var route string
+	if err := d.ReadString(&route, 0, true); err != nil {
+		t.Error(err)
+	}
+	raw, ty, err := d.ReadRaw(1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ty != StructBegin {
+		t.Errorf("type failed, got:%s", ty)
+	}
+	var tail int32
+	if err := d.ReadInt32(&tail, 2, true); err != nil {
+		t.Error(err)
+	}
+
+	// [step 3] 原样写回去，结果和原始数据一致
+	out := bytes.NewBuffer(make([]byte, 0))
+	e = NewEncoder(out)
+	if err := e.WriteString(route, 0); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteRaw(1, raw); err != nil {
+		t.Error(err)
+	}
+	if err := e.WriteInt32(tail, 2); err != nil {
+		t.Error(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(out.Bytes(), origin) {
+		t.Errorf("raw round trip failed\nwant:%v\ngot: %v", origin, out.Bytes())
+	}
+
+	// [step 4] 换一个 tag 写入
+	out.Reset()
+	e = NewEncoder(out)
+	if err := e.WriteRaw(20, raw); err != nil {
+		t.Error(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Error(err)
+	}
+	d = NewDecoder(out)
+	got, _, err := d.ReadRaw(20, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got[2:], raw[1:]) {
+		t.Errorf("retag failed\nwant:%v\ngot: %v", raw, got)
+	}
+}
+
+func TestReadRawOptional(t *testing.T) {
+	d := NewDecoder(bytes.NewBuffer(nil))
+	raw, _, err := d.ReadRaw(3, false)
+	if err != nil || raw != nil {
+		t.Errorf("optional raw failed, raw:%v, err:%v", raw, err)
+	}
+}
+
+func TestRawMessage(t *testing.T) {
+	want := RawMessage{0x00, 0x01, 0x16, 0x01, 'a'}
+
+	data, err := Marshal(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got RawMessage
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("raw message failed, want:%v, got:%v", want, got)
+	}
+}
+
+// 作为嵌套的 struct 字段时，只读取这一个 struct，后面的字段不受影响
+func TestRawMessageNested(t *testing.T) {
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	e.WriteHead(StructBegin, 1)
+	e.WriteInt32(1, 0)
+	e.WriteString("a", 20)
+	e.WriteHead(StructEnd, 0)
+	e.WriteInt32(7, 2)
+	e.Flush()
+	src := append([]byte(nil), data.Bytes()...)
+
+	// [step 1] 读取
+	var raw RawMessage
+	var v int32
+	d := NewDecoder(bytes.NewReader(src))
+	if _, _, err := d.ReadHead(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.ReadFrom(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReadInt32(&v, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := (RawMessage{0x00, 0x01, 0x7f, 20, 0x01, 'a'}); !bytes.Equal(raw, want) || v != 7 {
+		t.Errorf("want:% x and 7\ngot: % x and %d", want, raw, v)
+	}
+
+	// [step 2] 原样写回
+	out := bytes.NewBuffer(make([]byte, 0))
+	e = NewEncoder(out)
+	e.WriteHead(StructBegin, 1)
+	raw.WriteTo(e)
+	e.WriteHead(StructEnd, 0)
+	e.WriteInt32(7, 2)
+	e.Flush()
+	if !bytes.Equal(out.Bytes(), src) {
+		t.Errorf("want:% x\ngot: % x", src, out.Bytes())
+	}
+}
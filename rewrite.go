@@ -0,0 +1,318 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ---------------------------------------------------------------------------
+// 字节层面的字段重写
+// 用于 schema 迁移：不需要生成的类型，直接按规则修改 tag、删除字段，比如：
+//
+//	r := jce.NewRewriter()
+//	r.Rename(jce.MustParsePath("2[*].3"), 5) // list 中每个 struct 的 tag 3 改为 5
+//	r.Drop(jce.MustParsePath("4"))           // 删除 tag 4 整个子树
+//	err := r.Rewrite(d, e)
+//
+// 规则中的路径都使用重写前的 tag，没有规则的字段原样拷贝，不会重新编码；
+// 重写后每个 struct 的字段依然按 tag 从小到大排列
+//
+// 重写是流式的：一个 struct 中没有改名的规则时，字段按读到的顺序边读边写，只缓存当前的一个字段；
+// 有改名的规则时，这一层的字段先缓存下来，按 tag 排序后再写。有规则作用于元素的 list、map
+// 需要先算出新的长度，整个字段会缓存下来
+// ---------------------------------------------------------------------------
+
+// 规则的动作
+type rewriteAction byte
+
+const (
+	rewriteRename rewriteAction = iota // 修改 tag
+	rewriteDrop                        // 删除字段、list 元素或者 map 的 key、value 对
+)
+
+// 一条重写规则
+type rewriteRule struct {
+	path   Path
+	action rewriteAction
+	to     byte
+}
+
+// Rewriter 按规则重写编码数据，可以重复使用，但是不能在 Rewrite 的同时添加规则
+type Rewriter struct {
+	rules []*rewriteRule
+}
+
+// 创建一个没有规则的 Rewriter
+func NewRewriter() *Rewriter {
+	return &Rewriter{}
+}
+
+// 把 path 对应的字段的 tag 改为 to，path 的最后一步必须是 tag
+// 同一个路径有多条规则时，先添加的规则生效
+func (r *Rewriter) Rename(path Path, to byte) (err error) {
+	if len(path) == 0 || path[len(path)-1].Kind != StepTag {
+		return fmt.Errorf("rename %s failed, path must end with a tag", path)
+	}
+	r.rules = append(r.rules, &rewriteRule{path: path, action: rewriteRename, to: to})
+	return nil
+}
+
+// 删除 path 对应的整个子树，path 的最后一步是 list 的下标或者 map 的 key 时删除对应的元素
+func (r *Rewriter) Drop(path Path) (err error) {
+	if len(path) == 0 {
+		return fmt.Errorf("drop failed, path is empty")
+	}
+	r.rules = append(r.rules, &rewriteRule{path: path, action: rewriteDrop})
+	return nil
+}
+
+// 删除 parent 对应的 struct 中的若干 tag，parent 为空时表示最外层
+func (r *Rewriter) DropTags(parent Path, tags ...byte) {
+	for _, tag := range tags {
+		_ = r.Drop(parent.append(PathStep{Kind: StepTag, Tag: tag}))
+	}
+}
+
+// 从 d 读取一个消息，直到 StructEnd（会被读掉，但不写入 e）或者数据结束，重写后写入 e
+// 只重写一个消息，后面的数据留在 d 中：多个消息连在一起时，每个消息需要以嵌套 struct 的形式写入，
+// 读掉 StructBegin 后调用 Rewrite，再写 StructEnd。出错时 e 中可能已经写入了部分字段
+func (r *Rewriter) Rewrite(d *Decoder, e *Encoder) (err error) {
+	return r.rewriteStruct(d, e.writeByteN, ruleSet{rules: r.rules}, false)
+}
+
+// 重写一个完整的消息
+func (r *Rewriter) RewriteBytes(data []byte) (out []byte, err error) {
+	b := bytes.NewBuffer(make([]byte, 0, len(data)))
+	e := NewEncoder(b)
+	if err = r.Rewrite(NewDecoder(bytes.NewReader(data)), e); err != nil {
+		return
+	}
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// ruleSet 匹配状态：rules 中每条规则路径的前 depth 步都已经匹配当前位置
+type ruleSet struct {
+	rules []*rewriteRule
+	depth int
+}
+
+// 是否有作用于当前这一层字段的改名规则，有时字段的顺序可能改变
+func (s ruleSet) renames() bool {
+	for _, rr := range s.rules {
+		if len(rr.path) == s.depth+1 && rr.action == rewriteRename {
+			return true
+		}
+	}
+	return false
+}
+
+// 下一步为 step 的子字段对应的匹配状态，以及正好作用于这个子字段的规则
+func (s ruleSet) child(match func(step PathStep) bool) (sub ruleSet, rule *rewriteRule) {
+	sub.depth = s.depth + 1
+	for _, rr := range s.rules {
+		if !match(rr.path[s.depth]) {
+			continue
+		}
+		if len(rr.path) > sub.depth {
+			sub.rules = append(sub.rules, rr)
+		} else if rule == nil {
+			rule = rr
+		}
+	}
+	return
+}
+
+// 重写 struct 内的字段并写入 w，不包括 StructEnd，nested 为 true 时必须以 StructEnd 结尾
+// 这一层有改名的规则时，先缓存所有的字段，按 tag 排序后再写入，否则边读边写
+func (r *Rewriter) rewriteStruct(d *Decoder, w func([]byte) error, rs ruleSet, nested bool) (err error) {
+	var fields []RawField
+	buffered := rs.renames()
+	for {
+		// [step 1] 读 head，遇到 StructEnd 或者数据结束时停止
+		ty, tag, err := d.readHead()
+		if err == io.EOF && !nested {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read head failed, err:%s", err)
+		}
+		if ty == StructEnd {
+			break
+		}
+
+		// [step 2] 匹配规则
+		sub, rule := rs.child(func(step PathStep) bool {
+			return step.Kind == StepTag && step.Tag == tag
+		})
+		if rule != nil && rule.action == rewriteDrop {
+			if err = d.skipField(ty); err != nil {
+				return fmt.Errorf("skip tag %d failed, err:%s", tag, err)
+			}
+			continue
+		}
+		to := tag
+		if rule != nil && rule.action == rewriteRename {
+			to = rule.to
+		}
+
+		// [step 3] 重写字段，需要排序时先缓存
+		out := w
+		var data []byte
+		if buffered {
+			out = func(p []byte) error {
+				data = append(data, p...)
+				return nil
+			}
+		}
+		if err = r.writeField(d, out, ty, to, sub); err != nil {
+			return fmt.Errorf("rewrite tag %d failed, err:%s", tag, err)
+		}
+		if buffered {
+			fields = append(fields, RawField{Tag: to, Type: ty, Data: data})
+		}
+	}
+	if !buffered {
+		return nil
+	}
+
+	// [step 4] 按 tag 重新排序，改名后不能和已有的 tag 冲突
+	sortFields(fields)
+	for i, field := range fields {
+		if i > 0 && field.Tag == fields[i-1].Tag {
+			return fmt.Errorf("duplicate tag %d after rename", field.Tag)
+		}
+	}
+	for _, field := range fields {
+		if err = w(field.Data); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 重写一个字段并以 tag 为 to 写入 w，有规则的嵌套 struct 边读边写，其他字段整个写入
+func (r *Rewriter) writeField(d *Decoder, w func([]byte) error, ty JceEncodeType, to byte, rs ruleSet) (err error) {
+	head := appendHead(make([]byte, 0, 2), ty, to)
+	if ty == StructBegin && len(rs.rules) > 0 {
+		if err = w(head); err != nil {
+			return
+		}
+		if err = r.rewriteStruct(d, w, rs, true); err != nil {
+			return
+		}
+		return w(appendHead(nil, StructEnd, 0))
+	}
+
+	payload, err := r.rewriteField(d, ty, rs)
+	if err != nil {
+		return
+	}
+	return w(append(head, payload...))
+}
+
+// 重写 ty 类型字段的数据部分，不包括 head
+func (r *Rewriter) rewriteField(d *Decoder, ty JceEncodeType, rs ruleSet) (payload []byte, err error) {
+	// [step 1] 没有规则作用于子字段，原样拷贝
+	if len(rs.rules) == 0 {
+		return d.readFieldRaw(ty)
+	}
+
+	switch ty {
+	// [step 2] struct 递归重写，list、map 中的 struct 需要缓存下来
+	case StructBegin:
+		w := func(p []byte) error {
+			payload = append(payload, p...)
+			return nil
+		}
+		if err = r.rewriteStruct(d, w, rs, true); err != nil {
+			return nil, err
+		}
+		return appendHead(payload, StructEnd, 0), nil
+
+	// [step 3] list 逐个元素重写
+	case List:
+		length, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		items := make([][]byte, 0, SizeHint(length))
+		for i := int64(0); i < int64(length); i++ {
+			sub, rule := rs.child(func(step PathStep) bool {
+				return step.Kind == StepAny || (step.Kind == StepIndex && step.Index == i)
+			})
+			item, err := r.rewriteItem(d, sub, rule)
+			if err != nil {
+				return nil, fmt.Errorf("rewrite item %d failed, err:%s", i, err)
+			}
+			if item != nil {
+				items = append(items, item)
+			}
+		}
+		return appendPayload(items, 1), nil
+
+	// [step 4] map 按 key 匹配，重写 value
+	case Map:
+		length, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		items := make([][]byte, 0, 2*SizeHint(length))
+		for i := uint32(0); i < length; i++ {
+			key, err := r.rewriteItem(d, ruleSet{}, nil)
+			if err != nil {
+				return nil, fmt.Errorf("read key %d failed, err:%s", i, err)
+			}
+			kv, err := NewDecoder(bytes.NewReader(key)).readItemValue()
+			if err != nil {
+				return nil, fmt.Errorf("parse key %d failed, err:%s", i, err)
+			}
+
+			sub, rule := rs.child(func(step PathStep) bool {
+				return step.Kind != StepTag && step.match(kv)
+			})
+			value, err := r.rewriteItem(d, sub, rule)
+			if err != nil {
+				return nil, fmt.Errorf("rewrite value of key %s failed, err:%s", kv, err)
+			}
+			if value != nil {
+				items = append(items, key, value)
+			}
+		}
+		return appendPayload(items, 2), nil
+
+	// [step 5] 其他类型没有子字段
+	default:
+		return d.readFieldRaw(ty)
+	}
+}
+
+// 重写 list、map 中的一个元素，包括 head，元素被删除时返回 nil
+func (r *Rewriter) rewriteItem(d *Decoder, rs ruleSet, rule *rewriteRule) (item []byte, err error) {
+	ty, tag, err := d.readHead()
+	if err != nil {
+		return
+	}
+	if rule != nil && rule.action == rewriteDrop {
+		return nil, d.skipField(ty)
+	}
+
+	payload, err := r.rewriteField(d, ty, rs)
+	if err != nil {
+		return
+	}
+	item = appendHead(make([]byte, 0, len(payload)+1), ty, tag)
+	return append(item, payload...), nil
+}
+
+// 编码 list、map 的数据部分，items 为编码好的元素，n 为每个元素占的 item 个数
+func appendPayload(items [][]byte, n int) (payload []byte) {
+	payload = appendLength(nil, uint32(len(items)/n))
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return
+}
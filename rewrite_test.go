@@ -0,0 +1,177 @@
+package jce
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newRewriteData(t *testing.T) []byte {
+	return mustEncodeValue(t, structValue(
+		Field{Tag: 0, Value: stringValue("keep")},
+		Field{Tag: 1, Value: intValue(7)},
+		Field{Tag: 2, Value: Value{Type: List, List: []Value{
+			structValue(Field{Tag: 3, Value: stringValue("a")}, Field{Tag: 4, Value: Value{Type: Int1, Int: 1}}),
+			structValue(Field{Tag: 3, Value: stringValue("b")}),
+		}}},
+		Field{Tag: 4, Value: structValue(
+			Field{Tag: 1, Value: stringValue("secret")},
+			Field{Tag: 2, Value: stringValue("x")},
+		)},
+		Field{Tag: 5, Value: Value{Type: Map, Map: []MapEntry{
+			{Key: stringValue("a"), Value: structValue(
+				Field{Tag: 1, Value: Value{Type: Int1, Int: 1}},
+				Field{Tag: 9, Value: Value{Type: Int1, Int: 2}},
+			)},
+			{Key: stringValue("b"), Value: structValue(Field{Tag: 9, Value: Value{Type: Int1, Int: 3}})},
+		}}},
+	))
+}
+
+func TestRewriter(t *testing.T) {
+	data := newRewriteData(t)
+
+	r := NewRewriter()
+	for _, rule := range []struct {
+		path string
+		to   int
+	}{
+		{path: "2[*].3", to: 0},
+		{path: `5["a"].9`, to: 0},
+		{path: "0", to: 6},
+		{path: "4.1", to: -1},
+		{path: `5["b"]`, to: -1},
+	} {
+		var err error
+		if rule.to < 0 {
+			err = r.Drop(MustParsePath(rule.path))
+		} else {
+			err = r.Rename(MustParsePath(rule.path), byte(rule.to))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.DropTags(nil, 1)
+
+	out, err := r.RewriteBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := DecodeValue(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{2: [{0: "a", 4: Int1(1)}, {0: "b"}], 4: {2: "x"}, 5: map["a": {0: Int1(2), 1: Int1(1)}], 6: "keep"}`
+	if v.String() != want {
+		t.Errorf("rewrite failed\nwant:%s\ngot: %s", want, v)
+	}
+}
+
+func TestRewriterCopy(t *testing.T) {
+	data := newRewriteData(t)
+
+	// 没有规则时原样拷贝
+	out, err := NewRewriter().RewriteBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Errorf("copy failed\nwant:%x\ngot: %x", data, out)
+	}
+
+	// 通过 Decoder、Encoder 流式重写
+	r := NewRewriter()
+	r.DropTags(MustParsePath("4"), 2)
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+	if err = r.Rewrite(NewDecoder(bytes.NewReader(data)), e); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := Extract(b.Bytes(), MustParsePath("4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{1: "secret"}`; v.String() != want {
+		t.Errorf("stream rewrite failed, want:%s, got:%s", want, v)
+	}
+}
+
+// 从多个连在一起的消息中只重写第一个，后面的数据保持不变
+func TestRewriterStream(t *testing.T) {
+	record := func(name string) Value {
+		return structValue(Field{Tag: 0, Value: stringValue(name)}, Field{Tag: 1, Value: intValue(7)})
+	}
+	data := mustEncodeValue(t, structValue(
+		Field{Tag: 0, Value: record("a")},
+		Field{Tag: 1, Value: record("b")},
+	))
+
+	r := NewRewriter()
+	r.DropTags(nil, 1)
+	d := NewDecoder(bytes.NewReader(data))
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+	if _, have, err := d.ReadHead(0, true); err != nil || !have {
+		t.Fatalf("read head failed, have:%v, err:%v", have, err)
+	}
+	e.WriteHead(StructBegin, 0)
+	if err := r.Rewrite(d, e); err != nil {
+		t.Fatal(err)
+	}
+	e.WriteHead(StructEnd, 0)
+
+	// 剩下的第二个消息原样拷贝
+	if err := NewRewriter().Rewrite(d, e); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := DecodeValue(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{0: {0: "a"}, 1: {0: "b", 1: Int4(7)}}`; v.String() != want {
+		t.Errorf("stream rewrite failed, want:%s, got:%s", want, v)
+	}
+
+	// 没有改名时边读边写，出错前的字段已经写入；有改名时需要先读完整个 struct
+	truncated := data[:len(data)-3]
+	for _, rename := range []bool{false, true} {
+		r = NewRewriter()
+		if rename {
+			if err = r.Rename(MustParsePath("1"), 2); err != nil {
+				t.Fatal(err)
+			}
+		}
+		e = NewEncoder(bytes.NewBuffer(make([]byte, 0)))
+		if err = r.Rewrite(NewDecoder(bytes.NewReader(truncated)), e); err == nil {
+			t.Fatal("want error for truncated data")
+		}
+		if written := e.Offset() > 0; written == rename {
+			t.Errorf("rename:%v, written before error:%v", rename, written)
+		}
+	}
+}
+
+func TestRewriterError(t *testing.T) {
+	r := NewRewriter()
+	if err := r.Rename(MustParsePath("2[0]"), 1); err == nil {
+		t.Error("want error for rename of list item")
+	}
+	if err := r.Drop(nil); err == nil {
+		t.Error("want error for empty drop path")
+	}
+
+	// 改名后和已有的 tag 冲突
+	if err := r.Rename(MustParsePath("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RewriteBytes(newRewriteData(t)); err == nil {
+		t.Error("want error for duplicate tag")
+	}
+}
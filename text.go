@@ -0,0 +1,392 @@
+package jce
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 文本格式
+// 类似 protobuf 的文本格式，保留所有的编码类型，用于把测试数据以文本的形式提交，方便在 diff 中查看：
+//
+//	0: int1 7
+//	1: string "abc"
+//	2: struct {
+//	  0: zero
+//	}
+//	3: list [
+//	  int4 1
+//	  int4 -1
+//	]
+//	4: map {
+//	  string "k": float8 1.5
+//	}
+//	5: simplelist "0a0b"
+//
+//   - 字段为 "tag: 类型 值"，类型为 JceEncodeType 的小写名字，struct 的类型写为 struct
+//   - 整数按有符号输出，读取时也接受无符号的写法，比如 int1 255
+//   - 浮点数的 NaN、Inf 写为 NaN、+Inf、-Inf
+//   - string 使用 go 的字符串语法，不是 UTF-8 的字节用 \x 转义，SimpleList 写为 16 进制字符串
+//   - 空白、逗号只用于分隔，字段、元素可以写在同一行；# 开始到行尾为注释
+// ---------------------------------------------------------------------------
+
+// 把一个完整的消息转换为文本格式
+func FormatText(data []byte) (text []byte, err error) {
+	v, err := decodeMessage(data)
+	if err != nil {
+		return
+	}
+	var b bytes.Buffer
+	for _, field := range v.Fields {
+		formatTextField(&b, 0, field)
+	}
+	return b.Bytes(), nil
+}
+
+// 把文本格式转换为编码数据，出错时错误中带有行号和列号
+func ParseText(text []byte) (data []byte, err error) {
+	p := &textParser{s: text, line: 1, col: 1}
+	fields, err := p.fields(0)
+	if err != nil {
+		return
+	}
+	return EncodeValue(Value{Type: StructBegin, Fields: fields})
+}
+
+// ---------------------------------------------------------------------------
+// 编码数据 -> 文本
+// ---------------------------------------------------------------------------
+
+// 类型在文本中的名字
+func textTypeName(ty JceEncodeType) string {
+	if ty == StructBegin {
+		return "struct"
+	}
+	return strings.ToLower(ty.String())
+}
+
+func formatTextField(b *bytes.Buffer, depth int, field Field) {
+	writeIndent(b, depth)
+	fmt.Fprintf(b, "%d: ", field.Tag)
+	formatTextValue(b, depth, field.Value)
+	b.WriteByte('\n')
+}
+
+// 输出一个值，容器的内容每行一个元素，值的后面不换行
+func formatTextValue(b *bytes.Buffer, depth int, v Value) {
+	b.WriteString(textTypeName(v.Type))
+
+	switch v.Type {
+	case Zero:
+	case Int1, Int2, Int4, Int8:
+		fmt.Fprintf(b, " %d", v.signed())
+	case Float4:
+		b.WriteString(" " + strconv.FormatFloat(v.Float, 'g', -1, 32))
+	case Float8:
+		b.WriteString(" " + strconv.FormatFloat(v.Float, 'g', -1, 64))
+	case String:
+		b.WriteString(" " + strconv.Quote(string(v.Bytes)))
+	case SimpleList:
+		b.WriteString(` "` + hex.EncodeToString(v.Bytes) + `"`)
+	case List:
+		if len(v.List) == 0 {
+			b.WriteString(" []")
+			return
+		}
+		b.WriteString(" [\n")
+		for _, item := range v.List {
+			writeIndent(b, depth+1)
+			formatTextValue(b, depth+1, item)
+			b.WriteByte('\n')
+		}
+		writeIndent(b, depth)
+		b.WriteByte(']')
+	case Map:
+		if len(v.Map) == 0 {
+			b.WriteString(" {}")
+			return
+		}
+		b.WriteString(" {\n")
+		for _, entry := range v.Map {
+			writeIndent(b, depth+1)
+			formatTextValue(b, depth+1, entry.Key)
+			b.WriteString(": ")
+			formatTextValue(b, depth+1, entry.Value)
+			b.WriteByte('\n')
+		}
+		writeIndent(b, depth)
+		b.WriteByte('}')
+	case StructBegin:
+		if len(v.Fields) == 0 {
+			b.WriteString(" {}")
+			return
+		}
+		b.WriteString(" {\n")
+		for _, field := range v.Fields {
+			formatTextField(b, depth+1, field)
+		}
+		writeIndent(b, depth)
+		b.WriteByte('}')
+	}
+}
+
+func writeIndent(b *bytes.Buffer, depth int) {
+	for i := 0; i < depth; i++ {
+		b.WriteString("  ")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 文本 -> 编码数据
+// ---------------------------------------------------------------------------
+
+type textParser struct {
+	s         []byte
+	pos       int
+	line, col int // 当前位置的行号、列号，从 1 开始
+}
+
+// 一个 token 以及它的位置
+type textToken struct {
+	text      string // 标点为它自己，字符串为包括引号的原文，结束时为空
+	line, col int
+}
+
+func (p *textParser) errorf(t textToken, format string, args ...any) error {
+	return fmt.Errorf("invalid text at line %d col %d, err:%s", t.line, t.col, fmt.Sprintf(format, args...))
+}
+
+// 跳过空白和注释
+func (p *textParser) skip() {
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.advance(1)
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ',':
+			p.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+func (p *textParser) advance(n int) {
+	for i := 0; i < n; i++ {
+		if p.s[p.pos] == '\n' {
+			p.line, p.col = p.line+1, 1
+		} else {
+			p.col++
+		}
+		p.pos++
+	}
+}
+
+// 读取下一个 token，不移动位置
+func (p *textParser) peek() (t textToken, err error) {
+	p.skip()
+	t = textToken{line: p.line, col: p.col}
+	if p.pos >= len(p.s) {
+		return
+	}
+
+	switch c := p.s[p.pos]; {
+	// [step 1] 标点
+	case strings.IndexByte(":{}[]", c) >= 0:
+		t.text = string(c)
+
+	// [step 2] 字符串，到没有转义的引号为止
+	case c == '"':
+		end := p.pos + 1
+		for ; end < len(p.s) && p.s[end] != '"'; end++ {
+			if p.s[end] == '\\' {
+				end++
+			} else if p.s[end] == '\n' {
+				break
+			}
+		}
+		if end >= len(p.s) || p.s[end] != '"' {
+			return t, p.errorf(t, "unterminated string")
+		}
+		t.text = string(p.s[p.pos : end+1])
+
+	// [step 3] 单词：类型、tag、数字
+	default:
+		end := p.pos
+		for end < len(p.s) && !isTextDelim(p.s[end]) {
+			end++
+		}
+		t.text = string(p.s[p.pos:end])
+	}
+	return
+}
+
+func isTextDelim(c byte) bool {
+	return strings.IndexByte(" \t\r\n,#:{}[]\"", c) >= 0
+}
+
+// 读取下一个 token
+func (p *textParser) next() (t textToken, err error) {
+	if t, err = p.peek(); err == nil {
+		p.advance(len(t.text))
+	}
+	return
+}
+
+// 读取一个指定的标点
+func (p *textParser) expect(want string) (err error) {
+	t, err := p.next()
+	if err != nil {
+		return
+	}
+	if t.text != want {
+		return p.errorf(t, "want %q, but got %s", want, t)
+	}
+	return
+}
+
+func (t textToken) String() string {
+	if t.text == "" {
+		return "end of text"
+	}
+	return strconv.Quote(t.text)
+}
+
+// 读取字段直到 end，最外层的 end 为空，即文本结束
+func (p *textParser) fields(depth int) (fields []Field, err error) {
+	end := ""
+	if depth > 0 {
+		end = "}"
+	}
+	for {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.text == end {
+			return fields, nil
+		}
+		if t.text == "" {
+			return nil, p.errorf(t, "want %q, but got end of text", end)
+		}
+
+		tag, err := strconv.ParseUint(t.text, 10, 8)
+		if err != nil {
+			return nil, p.errorf(t, "invalid tag %s", t)
+		}
+		if err = p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(depth)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Tag: byte(tag), Value: v})
+	}
+}
+
+// 读取一个 "类型 值"
+func (p *textParser) value(depth int) (v Value, err error) {
+	// [step 1] 类型
+	t, err := p.next()
+	if err != nil {
+		return
+	}
+	if v.Type, err = parseTextType(t.text); err != nil {
+		return v, p.errorf(t, "%s", err)
+	}
+	if v.Type == Zero {
+		return
+	}
+
+	// [step 2] 容器
+	switch v.Type {
+	case List:
+		if err = p.expect("["); err != nil {
+			return
+		}
+		for {
+			if t, err = p.peek(); err != nil {
+				return
+			}
+			if t.text == "]" {
+				return v, p.expect("]")
+			}
+			item, err := p.value(depth + 1)
+			if err != nil {
+				return v, err
+			}
+			v.List = append(v.List, item)
+		}
+	case Map:
+		if err = p.expect("{"); err != nil {
+			return
+		}
+		for {
+			if t, err = p.peek(); err != nil {
+				return
+			}
+			if t.text == "}" {
+				return v, p.expect("}")
+			}
+			var entry MapEntry
+			if entry.Key, err = p.value(depth + 1); err != nil {
+				return
+			}
+			if err = p.expect(":"); err != nil {
+				return
+			}
+			if entry.Value, err = p.value(depth + 1); err != nil {
+				return
+			}
+			v.Map = append(v.Map, entry)
+		}
+	case StructBegin:
+		if err = p.expect("{"); err != nil {
+			return
+		}
+		v.Fields, err = p.fields(depth + 1)
+		return
+	}
+
+	// [step 3] 标量
+	if t, err = p.next(); err != nil {
+		return
+	}
+	switch v.Type {
+	case Int1, Int2, Int4, Int8:
+		v.Int, err = parseInteger(v.Type, t.text)
+	case Float4:
+		v.Float, err = strconv.ParseFloat(t.text, 32)
+	case Float8:
+		v.Float, err = strconv.ParseFloat(t.text, 64)
+	case String:
+		var s string
+		s, err = strconv.Unquote(t.text)
+		v.Bytes = []byte(s)
+	case SimpleList:
+		var s string
+		if s, err = strconv.Unquote(t.text); err == nil {
+			v.Bytes, err = hex.DecodeString(s)
+		}
+	}
+	if err != nil {
+		return v, p.errorf(t, "invalid %s value %s", textTypeName(v.Type), t)
+	}
+	return
+}
+
+// 按 textTypeName 查找类型
+func parseTextType(name string) (ty JceEncodeType, err error) {
+	for ty = Int1; ty <= StructBegin; ty++ {
+		if ty != StructEnd && textTypeName(ty) == name {
+			return
+		}
+	}
+	return 0, fmt.Errorf("unknown type %q", name)
+}
@@ -0,0 +1,104 @@
+package jce
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	v := structValue(
+		Field{Tag: 0, Value: Value{Type: Int1, Int: 0xfe}},
+		Field{Tag: 1, Value: stringValue("hi \"x\"")},
+		Field{Tag: 2, Value: Value{Type: String, Bytes: []byte{0xff, 0x00}}},
+		Field{Tag: 3, Value: Value{Type: Zero}},
+		Field{Tag: 4, Value: Value{Type: Float4, Float: 1.5}},
+		Field{Tag: 5, Value: Value{Type: Float8, Float: math.Inf(-1)}},
+		Field{Tag: 6, Value: Value{Type: SimpleList, Bytes: []byte{1, 2}}},
+		Field{Tag: 7, Value: Value{Type: List, List: []Value{{Type: Int8, Int: 1 << 63}, {Type: List}}}},
+		Field{Tag: 8, Value: Value{Type: Map, Map: []MapEntry{{Key: stringValue("k"), Value: Value{Type: Int4, Int: 9}}}}},
+		Field{Tag: 200, Value: structValue(Field{Tag: 1, Value: Value{Type: Int2, Int: 300}}, Field{Tag: 2, Value: structValue()})},
+		Field{Tag: 1, Value: stringValue("dup")},
+	)
+	data := mustEncodeValue(t, v)
+
+	want := `0: int1 -2
+1: string "hi \"x\""
+2: string "\xff\x00"
+3: zero
+4: float4 1.5
+5: float8 -Inf
+6: simplelist "0102"
+7: list [
+  int8 -9223372036854775808
+  list []
+]
+8: map {
+  string "k": int4 9
+}
+200: struct {
+  1: int2 300
+  2: struct {}
+}
+1: string "dup"
+`
+	got, err := FormatText(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("FormatText()\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	// 往返之后和原始数据完全一致
+	back, err := ParseText(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back, data) {
+		t.Errorf("ParseText() = % x, want % x", back, data)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text := `# 写在一行，带注释
+0: int1 255, 1: struct { 0: zero }  # tag 1
+2: list [ int4 1 int4 2 ] 3: map { int1 1: string "a" }
+`
+	want := []byte{
+		0x00, 0xff,
+		0xb1, 0x60, 0xc0,
+		0xa2, 2, 0x20, 0, 0, 0, 1, 0x20, 0, 0, 0, 2,
+		0x83, 1, 0x00, 1, 0x71, 1, 'a',
+	}
+	got, err := ParseText([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ParseText() = % x, want % x", got, want)
+	}
+}
+
+func TestParseTextError(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"0: int1 300", "line 1 col 9, err:invalid int1 value \"300\""},
+		{"0: int3 1", `line 1 col 4, err:unknown type "int3"`},
+		{"\n  x: zero", `line 2 col 3, err:invalid tag "x"`},
+		{"0 zero", `line 1 col 3, err:want ":", but got "zero"`},
+		{"0: struct { 1: zero", `line 1 col 20, err:want "}", but got end of text`},
+		{`0: string "abc`, "line 1 col 11, err:unterminated string"},
+		{`0: simplelist "0g"`, `invalid simplelist value "\"0g\""`},
+		{"0: zero 1: float4 1e100", "invalid float4 value"},
+	}
+	for _, tt := range tests {
+		_, err := ParseText([]byte(tt.text))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseText(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
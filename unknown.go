@@ -0,0 +1,109 @@
+package jce
+
+import (
+	"io"
+	"sort"
+)
+
+// ---------------------------------------------------------------------------
+// 未知字段的保存
+// 新版本的协议增加了字段后，老版本的服务反序列化时会跳过这些字段，如果再序列化转发出去，
+// 这些字段就丢失了。开启保存后，跳过的字段会以原始字节的形式保存下来，序列化时再按 tag 顺序写回去
+// ---------------------------------------------------------------------------
+
+// RawField 一个完整编码的字段
+type RawField struct {
+	Tag  byte
+	Type JceEncodeType
+	Data []byte // head + data 的原始字节
+}
+
+// 返回字段去掉 head 后的数据部分
+func (f RawField) Payload() []byte {
+	_, _, n, err := parseHead(f.Data)
+	if err != nil {
+		return nil
+	}
+	return f.Data[n:]
+}
+
+// UnknownFields 反序列化时跳过的未知字段，按 tag 从小到大排列
+type UnknownFields []RawField
+
+// 开启未知字段的保存，之后读取时跳过的字段都会追加到 u 中，u 为 nil 时关闭
+// 被字段掩码过滤掉的 tag 是已知的字段，只会被跳过，不会保存
+func (d *Decoder) CaptureUnknownFields(u *UnknownFields) {
+	d.unknown = u
+}
+
+// 跳过当前 struct 剩余的所有字段，直到 StructEnd（会被读掉）或者数据结束
+// 一般在 ReadFrom 的最后调用，这样 tag 比已知字段都大的未知字段也能被保存下来
+func (d *Decoder) SkipToStructEnd() (err error) {
+	for {
+		// [step 1] 读 head，数据结束说明是最外层的 struct
+		ty, tag, err := d.readHead()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// [step 2] 读到 struct 的结尾
+		if ty == StructEnd {
+			return nil
+		}
+
+		// [step 3] 跳过数据
+		d.markPresent(tag)
+		if err = d.skipUnknown(ty, tag); err != nil {
+			return err
+		}
+	}
+}
+
+// 跳过一个字段的数据部分，如果开启了未知字段的保存，则保存下来
+func (d *Decoder) skipUnknown(ty JceEncodeType, tag byte) (err error) {
+	// [step 1] 没有开启保存，或者是被掩码过滤掉的字段，直接跳过
+	if d.unknown == nil || d.masked(tag) {
+		return d.skipField(ty)
+	}
+
+	// [step 2] 否则读出原始字节，并补上 head
+	payload, err := d.readFieldRaw(ty)
+	if err != nil {
+		return
+	}
+
+	data := appendHead(make([]byte, 0, len(payload)+2), ty, tag)
+	*d.unknown = append(*d.unknown, RawField{Tag: tag, Type: ty, Data: append(data, payload...)})
+	return
+}
+
+// 设置需要写回的未知字段，之后写入 tag 更大的字段前，会先按 tag 顺序写入这些字段
+// 剩余的字段需要调用 WriteUnknownFields 写入；未知字段只属于当前这一层 struct，
+// 之后写在同一个 Encoder 上的嵌套 struct（StructBegin 到 StructEnd）中不会写入这些字段
+func (e *Encoder) SetUnknownFields(u UnknownFields) {
+	e.unknown = append(UnknownFields(nil), u...)
+	sort.SliceStable(e.unknown, func(i, j int) bool {
+		return e.unknown[i].Tag < e.unknown[j].Tag
+	})
+}
+
+// 写入剩余的所有未知字段，一般在 WriteTo 的最后调用
+func (e *Encoder) WriteUnknownFields() (err error) {
+	return e.writeUnknownBefore(256)
+}
+
+// 写入 tag 小于 limit 的未知字段
+func (e *Encoder) writeUnknownBefore(limit int) (err error) {
+	for len(e.unknown) > 0 && int(e.unknown[0].Tag) < limit {
+		if e.selectUnknown(e.unknown[0].Tag) {
+			if err = e.writeByteN(e.unknown[0].Data); err != nil {
+				return
+			}
+		}
+		e.unknown = e.unknown[1:]
+	}
+	return
+}
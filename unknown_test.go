@@ -0,0 +1,152 @@
+package jce
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// 老版本的协议，只认识 tag 3、12
+type oldMessage struct {
+	Name    string
+	Count   int64
+	unknown UnknownFields
+}
+
+func (m *oldMessage) ReadFrom(r io.Reader) (n int64, err error) {
+	d := NewDecoder(r)
+	d.CaptureUnknownFields(&m.unknown)
+
+	if err = d.ReadString(&m.Name, 3, true); err != nil {
+		return
+	}
+	if err = d.ReadInt64(&m.Count, 12, false); err != nil {
+		return
+	}
+	return 0, d.SkipToStructEnd()
+}
+
+func (m *oldMessage) WriteTo(w io.Writer) (n int64, err error) {
+	e := NewEncoder(w)
+	e.SetUnknownFields(m.unknown)
+
+	if err = e.WriteString(m.Name, 3); err != nil {
+		return
+	}
+	if err = e.WriteInt64(m.Count, 12); err != nil {
+		return
+	}
+	if err = e.WriteUnknownFields(); err != nil {
+		return
+	}
+	return 0, e.Flush()
+}
+
+// 新版本的协议编码的数据
+func newMessageBytes(t *testing.T, count int64) []byte {
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	must(e.WriteInt32(1, 0))
+	must(e.WriteString("a", 3))
+	must(e.WriteSliceUint8([]byte{1, 2, 3}, 7))
+	must(e.WriteHead(StructBegin, 10))
+	must(e.WriteInt32(5, 0))
+	must(e.WriteHead(StructEnd, 0))
+	must(e.WriteInt64(count, 12))
+	must(e.WriteString("new", 20))
+	must(e.Flush())
+
+	return data.Bytes()
+}
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	data := newMessageBytes(t, 9)
+
+	var m oldMessage
+	if err := Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Name != "a" || m.Count != 9 {
+		t.Errorf("decode failed, got:%+v", m)
+	}
+
+	var tags []byte
+	for _, f := range m.unknown {
+		tags = append(tags, f.Tag)
+	}
+	if !reflect.DeepEqual(tags, []byte{0, 7, 10, 20}) {
+		t.Errorf("unknown tags failed, got:%v", tags)
+	}
+
+	// 不修改时，序列化的结果和原始数据完全一致
+	got, err := Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("round trip failed\nwant:%v\ngot: %v", data, got)
+	}
+
+	// 修改已知字段，未知字段依然保留
+	m.Count = 100000
+	got, err = Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := newMessageBytes(t, 100000); !bytes.Equal(got, want) {
+		t.Errorf("modify failed\nwant:%v\ngot: %v", want, got)
+	}
+}
+
+func TestUnknownFieldsPayload(t *testing.T) {
+	f := RawField{Tag: 20, Type: String, Data: []byte{0x7f, 20, 1, 'a'}}
+	if !bytes.Equal(f.Payload(), []byte{1, 'a'}) {
+		t.Errorf("payload failed, got:%v", f.Payload())
+	}
+}
+
+// 嵌套的 struct 直接写在同一个 Encoder 上时，外层的未知字段要写在 struct 之后，不能写到里面去
+func TestUnknownFieldsNested(t *testing.T) {
+	data := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(data)
+	e.SetUnknownFields(UnknownFields{{Tag: 7, Type: Int1, Data: []byte{0x07, 0x03}}})
+
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(e.WriteHead(StructBegin, 5))
+	e.SetUnknownFields(UnknownFields{{Tag: 9, Type: Int1, Data: []byte{0x09, 0x04}}})
+	must(e.WriteInt32(1, 8))
+	must(e.WriteHead(StructEnd, 0))
+	must(e.WriteUnknownFields())
+	must(e.Flush())
+
+	// tag 5: {tag 8: 1, tag 9: 4}, tag 7: 3
+	want := []byte{0xb5, 0x08, 0x01, 0x09, 0x04, 0xc0, 0x07, 0x03}
+	if !bytes.Equal(data.Bytes(), want) {
+		t.Fatalf("want:% x\ngot: % x", want, data.Bytes())
+	}
+
+	var inner, outer int32
+	d := NewDecoder(bytes.NewReader(data.Bytes()))
+	if _, _, err := d.ReadHead(5, true); err != nil {
+		t.Fatal(err)
+	}
+	must(d.ReadInt32(&inner, 8, true))
+	must(d.SkipToStructEnd())
+	must(d.ReadInt32(&outer, 7, true))
+	if inner != 1 || outer != 3 {
+		t.Errorf("got %d, %d, want 1, 3", inner, outer)
+	}
+}
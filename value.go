@@ -0,0 +1,347 @@
+package jce
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// 通用的 jce 值
+// 不依赖代码生成的 go 类型，直接按编码类型表示数据，用于在字节层面查看和操作编码数据
+// ---------------------------------------------------------------------------
+
+// Value 一个 jce 值，根据 Type 使用不同的字段
+type Value struct {
+	Type   JceEncodeType
+	Int    uint64     // Int1、Int2、Int4、Int8，和 readInt* 一样按无符号零扩展
+	Float  float64    // Float4、Float8
+	Bytes  []byte     // String、SimpleList
+	List   []Value    // List
+	Map    []MapEntry // Map
+	Fields []Field    // StructBegin，按编码的顺序排列
+}
+
+// Field struct 中的一个字段
+type Field struct {
+	Tag   byte
+	Value Value
+}
+
+// MapEntry map 中的一个 key、value 对
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+// 返回 tag 对应的字段，只对 StructBegin 有效
+func (v Value) Field(tag byte) (f Value, ok bool) {
+	for _, field := range v.Fields {
+		if field.Tag == tag {
+			return field.Value, true
+		}
+	}
+	return
+}
+
+// 是否为数字类型，包括 Zero
+func (v Value) IsNumber() bool {
+	return v.Type <= Float8 || v.Type == Zero
+}
+
+func (v Value) String() string {
+	var b strings.Builder
+	v.format(&b)
+	return b.String()
+}
+
+func (v Value) format(b *strings.Builder) {
+	switch v.Type {
+	case Int1, Int2, Int4, Int8:
+		fmt.Fprintf(b, "%s(%d)", v.Type, v.Int)
+	case Float4, Float8:
+		fmt.Fprintf(b, "%s(%v)", v.Type, v.Float)
+	case Zero:
+		b.WriteString("Zero")
+	case String:
+		b.WriteString(strconv.Quote(string(v.Bytes)))
+	case SimpleList:
+		fmt.Fprintf(b, "%v", v.Bytes)
+	case List:
+		b.WriteString("[")
+		for i, item := range v.List {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			item.format(b)
+		}
+		b.WriteString("]")
+	case Map:
+		b.WriteString("map[")
+		for i, entry := range v.Map {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			entry.Key.format(b)
+			b.WriteString(": ")
+			entry.Value.format(b)
+		}
+		b.WriteString("]")
+	case StructBegin:
+		b.WriteString("{")
+		for i, field := range v.Fields {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(b, "%d: ", field.Tag)
+			field.Value.format(b)
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString(v.Type.String())
+	}
+}
+
+// 把一个完整的消息解析为 StructBegin 类型的 Value
+func DecodeValue(data []byte) (v Value, err error) {
+	d := NewDecoder(bytes.NewReader(data))
+	return d.readStructValue()
+}
+
+// 和 DecodeValue 一样，但是要求解析完所有的数据，最外层的 StructEnd 也认为是错误，
+// 错误中带有失败的偏移，用于转换为其他格式
+func decodeMessage(data []byte) (v Value, err error) {
+	d := NewDecoder(bytes.NewReader(data))
+	if v, err = d.readStructValue(); err != nil {
+		return v, fmt.Errorf("invalid jce at offset %d, err:%s", d.Offset(), err)
+	}
+	if d.Offset() != int64(len(data)) {
+		return v, fmt.Errorf("invalid jce at offset %d, err:unexpected %s", d.Offset(), StructEnd)
+	}
+	return
+}
+
+// 把 StructBegin 类型的 Value 编码为一个完整的消息，不写 struct 的 begin、end
+// 数字按 Value 中的类型原样编码，不做压缩
+func EncodeValue(v Value) (data []byte, err error) {
+	if v.Type != StructBegin {
+		return nil, fmt.Errorf("encode value need %s, but got %s", StructBegin, v.Type)
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0))
+	e := NewEncoder(b)
+	for _, field := range v.Fields {
+		if err = e.writeValue(field.Value, field.Tag); err != nil {
+			return
+		}
+	}
+	if err = e.Flush(); err != nil {
+		return
+	}
+	return b.Bytes(), nil
+}
+
+// 读取 struct 内的所有字段，直到 StructEnd（会被读掉）或者数据结束
+func (d *Decoder) readStructValue() (v Value, err error) {
+	v.Type = StructBegin
+	for {
+		// [step 1] 读 head
+		ty, tag, err := d.readHead()
+		if err == io.EOF {
+			return v, nil
+		}
+		if err != nil {
+			return v, err
+		}
+
+		// [step 2] struct 结束
+		if ty == StructEnd {
+			return v, nil
+		}
+
+		// [step 3] 读数据
+		field, err := d.readValue(ty)
+		if err != nil {
+			return v, fmt.Errorf("read tag %d failed, err:%s", tag, err)
+		}
+		v.Fields = append(v.Fields, Field{Tag: tag, Value: field})
+	}
+}
+
+// 读取一个 ty 类型的数据部分，head 已经读过了
+func (d *Decoder) readValue(ty JceEncodeType) (v Value, err error) {
+	v.Type = ty
+
+	switch ty {
+	case Zero:
+		return
+	case Int1:
+		var tmp uint8
+		tmp, err = d.readByte()
+		v.Int = uint64(tmp)
+	case Int2:
+		var tmp uint16
+		tmp, err = d.readByte2()
+		v.Int = uint64(tmp)
+	case Int4:
+		var tmp uint32
+		tmp, err = d.readByte4()
+		v.Int = uint64(tmp)
+	case Int8:
+		v.Int, err = d.readByte8()
+	case Float4:
+		var tmp uint32
+		tmp, err = d.readByte4()
+		v.Float = float64(math.Float32frombits(tmp))
+	case Float8:
+		var tmp uint64
+		tmp, err = d.readByte8()
+		v.Float = math.Float64frombits(tmp)
+	case String:
+		var length uint32
+		if length, err = d.readLength(); err != nil {
+			return
+		}
+		v.Bytes, err = d.readByteN(int(length))
+	case SimpleList:
+		var length uint32
+		if length, err = d.readByte4(); err != nil {
+			return
+		}
+		var itemType uint8
+		if itemType, err = d.readByte(); err != nil {
+			return
+		}
+		if JceEncodeType(itemType) != Int1 {
+			return v, fmt.Errorf("simple list need byte head. but get %d", itemType)
+		}
+		v.Bytes, err = d.readByteN(int(length))
+	case List:
+		var length uint32
+		if length, err = d.readLength(); err != nil {
+			return
+		}
+		v.List = make([]Value, 0, SizeHint(length))
+		for i := uint32(0); i < length; i++ {
+			var item Value
+			if item, err = d.readItemValue(); err != nil {
+				return v, fmt.Errorf("read list item %d failed, err:%s", i, err)
+			}
+			v.List = append(v.List, item)
+		}
+	case Map:
+		var length uint32
+		if length, err = d.readLength(); err != nil {
+			return
+		}
+		v.Map = make([]MapEntry, 0, SizeHint(length))
+		for i := uint32(0); i < length; i++ {
+			var entry MapEntry
+			if entry.Key, err = d.readItemValue(); err != nil {
+				return v, fmt.Errorf("read map key %d failed, err:%s", i, err)
+			}
+			if entry.Value, err = d.readItemValue(); err != nil {
+				return v, fmt.Errorf("read map value %d failed, err:%s", i, err)
+			}
+			v.Map = append(v.Map, entry)
+		}
+	case StructBegin:
+		return d.readStructValue()
+	default:
+		return v, fmt.Errorf("read value failed, invalid type %s", ty)
+	}
+
+	return
+}
+
+// SizeHint 读到的 list、map 长度来自数据，不一定可信，预分配的大小需要限制一下
+// 生成的代码也使用这个函数，按 SizeHint(length) 预分配后逐个 append
+func SizeHint(length uint32) int {
+	if length > 1024 {
+		return 1024
+	}
+	return int(length)
+}
+
+// 读取 list、map 中的一个元素，元素的 tag 没有意义，直接忽略
+func (d *Decoder) readItemValue() (v Value, err error) {
+	ty, _, err := d.readHead()
+	if err != nil {
+		return
+	}
+	return d.readValue(ty)
+}
+
+// 按 Value 中的类型原样写入一个字段
+func (e *Encoder) writeValue(v Value, tag byte) (err error) {
+	// [step 1] 写 head
+	if err = e.writeHead(v.Type, tag); err != nil {
+		return
+	}
+
+	// [step 2] 写数据
+	switch v.Type {
+	case Zero:
+		return
+	case Int1:
+		return e.writeByte(uint8(v.Int))
+	case Int2:
+		return e.writeByte2(uint16(v.Int))
+	case Int4:
+		return e.writeByte4(uint32(v.Int))
+	case Int8:
+		return e.writeByte8(v.Int)
+	case Float4:
+		return e.writeByte4(e.float32bits(float32(v.Float)))
+	case Float8:
+		return e.writeByte8(e.float64bits(v.Float))
+	case String:
+		if err = e.writeLength(uint32(len(v.Bytes))); err != nil {
+			return
+		}
+		return e.writeByteN(v.Bytes)
+	case SimpleList:
+		if err = e.writeByte4(uint32(len(v.Bytes))); err != nil {
+			return
+		}
+		if err = e.writeByte(uint8(Int1)); err != nil {
+			return
+		}
+		return e.writeByteN(v.Bytes)
+	case List:
+		if err = e.writeLength(uint32(len(v.List))); err != nil {
+			return
+		}
+		for _, item := range v.List {
+			if err = e.writeValue(item, 0); err != nil {
+				return
+			}
+		}
+		return
+	case Map:
+		if err = e.writeLength(uint32(len(v.Map))); err != nil {
+			return
+		}
+		for _, entry := range v.Map {
+			if err = e.writeValue(entry.Key, 0); err != nil {
+				return
+			}
+			if err = e.writeValue(entry.Value, 1); err != nil {
+				return
+			}
+		}
+		return
+	case StructBegin:
+		for _, field := range v.Fields {
+			if err = e.writeValue(field.Value, field.Tag); err != nil {
+				return
+			}
+		}
+		return e.writeHead(StructEnd, 0)
+	default:
+		return fmt.Errorf("write value failed, invalid type %s", v.Type)
+	}
+}